@@ -1,11 +1,14 @@
 package main
 
 import (
+	"Q-Solver/pkg/audio"
 	"Q-Solver/pkg/config"
 	"Q-Solver/pkg/live"
 	"Q-Solver/pkg/llm"
 	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/ocr"
 	"Q-Solver/pkg/platform"
+	"Q-Solver/pkg/prompts"
 	"Q-Solver/pkg/resume"
 	"Q-Solver/pkg/screen"
 	"Q-Solver/pkg/shortcut"
@@ -15,7 +18,12 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -28,6 +36,8 @@ type App struct {
 	configManager *config.ConfigManager
 	stateManager  *state.StateManager
 	taskManager   *task.TaskCoordinator
+	promptLibrary *prompts.Library
+	recordings    *live.RecordingStore
 
 	// 业务服务
 	llmService      *llm.Service
@@ -46,6 +56,8 @@ func NewApp() *App {
 		configManager: configManager,
 		stateManager:  state.NewStateManager(),
 		taskManager:   task.NewTaskCoordinator(),
+		promptLibrary: prompts.NewLibrary(filepath.Join(configManager.ConfigDir(), "prompts")),
+		recordings:    live.NewRecordingStore(filepath.Join(configManager.ConfigDir(), "recordings")),
 		screenService: screen.NewService(),
 	}
 
@@ -77,9 +89,11 @@ func (a *App) Startup(ctx context.Context) {
 	// 初始化 LLM 服务
 	a.llmService = llm.NewService(a.configManager.Get(), a.configManager)
 	a.solver = solution.NewSolver(a.llmService.GetProvider())
+	a.solver.SetProviderFactory(a.llmService.ProviderForModel)
+	a.solver.SetPromptLibrary(a.promptLibrary)
 
 	// 初始化简历服务
-	a.resumeService = resume.NewService(a.configManager.Get(), a.configManager)
+	a.resumeService = resume.NewService(a.configManager.Get(), a.configManager, a.promptLibrary)
 
 	// 初始化快捷键服务
 	a.shortcutService = shortcut.NewService(a, a.configManager.Get().Shortcuts, func(callback func(map[string]shortcut.KeyBinding)) {
@@ -100,6 +114,8 @@ func (a *App) Startup(ctx context.Context) {
 		a.llmService,
 		a.configManager,
 		a.screenService,
+		a.promptLibrary,
+		a.recordings,
 		a.EmitEvent,
 	)
 
@@ -173,6 +189,15 @@ func (a *App) UpdateSettings(configJson string) string {
 	return ""
 }
 
+// updateConfig 以修改后的配置副本全量更新配置（会通知订阅者并保存）
+func (a *App) updateConfig(cfg config.Config) error {
+	jsonData, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return a.configManager.UpdateFromJSON(string(jsonData))
+}
+
 // SyncSettingsToDefaultSettings 兼容旧接口
 // Deprecated: 使用 UpdateSettings 替代
 func (a *App) SyncSettingsToDefaultSettings(configJson string) string {
@@ -210,6 +235,38 @@ func (a *App) RemoveFocus() {
 
 // TriggerSolve 触发解题（快捷键调用）
 func (a *App) TriggerSolve() {
+	a.startSolve(func(ctx context.Context) bool {
+		return a.solveInternal(ctx, nil)
+	})
+}
+
+// SolveFromClipboard 以剪贴板中的图片或文字代替截图解题
+func (a *App) SolveFromClipboard() {
+	input, err := a.screenService.ReadClipboard()
+	if err != nil {
+		logger.Printf("读取剪贴板失败: %v", err)
+		a.EmitEvent("toast", err.Error())
+		return
+	}
+	a.startSolve(func(ctx context.Context) bool {
+		return a.solveInternal(ctx, &input)
+	})
+}
+
+// SolveFromDrop 以前端拖入的文件（data URL，图片或纯文本）代替截图解题
+func (a *App) SolveFromDrop(dataURL string) error {
+	input, err := screen.DecodeDataURL(dataURL)
+	if err != nil {
+		return err
+	}
+	a.startSolve(func(ctx context.Context) bool {
+		return a.solveInternal(ctx, &input)
+	})
+	return nil
+}
+
+// startSolve 检查状态后在新任务中执行解题
+func (a *App) startSolve(solve func(ctx context.Context) bool) {
 	cfg := a.configManager.Get()
 
 	// Live 模式下禁用手动截图
@@ -229,7 +286,7 @@ func (a *App) TriggerSolve() {
 	ctx, taskID := a.taskManager.StartTask("solve")
 
 	go func() {
-		success := a.solveInternal(ctx)
+		success := solve(ctx)
 
 		if success {
 			a.taskManager.CompleteTask(taskID)
@@ -237,8 +294,8 @@ func (a *App) TriggerSolve() {
 	}()
 }
 
-// solveInternal 内部解题逻辑
-func (a *App) solveInternal(ctx context.Context) bool {
+// solveInternal 内部解题逻辑，input 为空时截取屏幕
+func (a *App) solveInternal(ctx context.Context, input *screen.Input) bool {
 	cfg := a.configManager.Get()
 
 	if cfg.APIKey == "" {
@@ -252,35 +309,118 @@ func (a *App) solveInternal(ctx context.Context) bool {
 		logger.Printf("读取简历失败: %v\n", err)
 	}
 
-	// 获取截图
-	previewResult, err := a.GetScreenshotPreview(
-		cfg.CompressionQuality,
-		cfg.Sharpening,
-		cfg.Grayscale,
-		cfg.NoCompression,
-		cfg.ScreenshotMode,
-	)
+	cb := solution.Callbacks{
+		EmitEvent: a.EmitEvent,
+	}
+
+	// 剪贴板或文件中的文字直接作为题目
+	if input != nil && input.Image == nil {
+		a.EmitEvent("user-message", "", input.Text)
+		return a.solver.Solve(ctx, solution.Request{
+			Config:       cfg,
+			InputText:    input.Text,
+			ResumeBase64: resumeBase64,
+		}, cb)
+	}
+
+	// 获取截图（或压缩剪贴板、文件中的图片）
+	var previewResult screen.PreviewResult
+	if input != nil {
+		previewResult, err = screen.EncodeImage(input.Image, screen.OptionsFromConfig(cfg))
+	} else {
+		opts := screen.OptionsFromConfig(cfg)
+		opts.TrackChange = true
+		previewResult, err = a.screenService.CapturePreview(opts)
+	}
 	if err != nil {
 		logger.Printf("图片编码失败: %v\n", err)
 		return false
 	}
 
+	logger.Printf("截图变化比例: %.4f", previewResult.ChangedRatio)
+
 	// 发送用户截图到前端（用于导出图片显示用户输入）
 	a.EmitEvent("user-message", previewResult.Base64)
 
 	req := solution.Request{
 		Config:           cfg,
 		ScreenshotBase64: previewResult.Base64,
+		ScreenshotHash:   previewResult.Hash,
+		ScreenSignature:  previewResult.Signature,
+		ExtraScreenshots: previewResult.Extra,
 		ResumeBase64:     resumeBase64,
 	}
-
-	cb := solution.Callbacks{
-		EmitEvent: a.EmitEvent,
+	// 截图没有变化时直接复用答案，不必再做 OCR
+	if a.solver.ReuseUnchanged(req, cb) {
+		return true
+	}
+	req.SkipCache = true
+	if cfg.OCRMode != config.OCRModeOff {
+		a.applyOCR(ctx, cfg, previewResult, &req)
 	}
 
 	return a.solver.Solve(ctx, req, cb)
 }
 
+// applyOCR 本地识别截图文字，置信度足够时以文字（或文字 + 低清截图）代替原图发送
+func (a *App) applyOCR(ctx context.Context, cfg config.Config, preview screen.PreviewResult, req *solution.Request) {
+	engine, err := ocr.NewEngine(cfg)
+	if err != nil {
+		logger.Printf("[OCR] 初始化失败，发送原图: %v", err)
+		a.EmitEvent("toast", "OCR 不可用，已发送原图")
+		return
+	}
+
+	start := time.Now()
+	result, useText, err := solution.ApplyOCR(ctx, engine, preview.Images, req)
+	if err != nil {
+		logger.Printf("[OCR] 识别失败，发送原图: %v", err)
+		return
+	}
+
+	logger.Printf("[OCR] 识别完成: %d 行, 置信度 %.2f, 耗时 %v, 使用文字: %v", len(result.Lines), result.Confidence, time.Since(start), useText)
+	a.EmitEvent("ocr-result", result, useText)
+}
+
+// FlipCodingLanguage 切换到下一个候选编程语言并重新生成当前解答（快捷键调用）
+func (a *App) FlipCodingLanguage() {
+	cfg := a.configManager.Get()
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持重新生成")
+		return
+	}
+
+	if !a.solver.CanRegenerate() {
+		a.EmitEvent("toast", "还没有可以重新生成的题目")
+		return
+	}
+
+	languages := prompts.CodingLanguages(cfg)
+	if len(languages) < 2 {
+		a.EmitEvent("toast", "请先在设置中配置候选编程语言")
+		return
+	}
+
+	current := a.solver.LastLanguage()
+	next := languages[0]
+	for i, lang := range languages {
+		if strings.EqualFold(lang, current) {
+			next = languages[(i+1)%len(languages)]
+			break
+		}
+	}
+
+	a.EmitEvent("toast", fmt.Sprintf("切换为 %s 重新生成", next))
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("solve")
+	go func() {
+		if a.solver.Regenerate(ctx, cfg, next, solution.Callbacks{EmitEvent: a.EmitEvent}) {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
 // CancelRunningTask 取消当前运行的任务
 func (a *App) CancelRunningTask() bool {
 	return a.taskManager.CancelCurrentTask()
@@ -315,6 +455,75 @@ func (a *App) CopyCode() {
 	a.EmitEvent("copy-code")
 }
 
+// ==================== 提示词模板 ====================
+
+// ListPromptTemplates 列出指定类型的提示词模板
+func (a *App) ListPromptTemplates(kind string) ([]prompts.TemplateInfo, error) {
+	return a.promptLibrary.List(prompts.Kind(kind))
+}
+
+// GetPromptTemplate 获取模板内容（名称为空返回当前语言的内置模板）
+func (a *App) GetPromptTemplate(kind string, name string) (string, error) {
+	return a.promptLibrary.Get(prompts.Kind(kind), name, a.configManager.Get().Locale)
+}
+
+// SavePromptTemplate 保存模板
+func (a *App) SavePromptTemplate(kind string, name string, content string) error {
+	return a.promptLibrary.Save(prompts.Kind(kind), name, content)
+}
+
+// DeletePromptTemplate 删除模板，如果是当前选中的模板则回退到内置模板
+func (a *App) DeletePromptTemplate(kind string, name string) error {
+	if err := a.promptLibrary.Delete(prompts.Kind(kind), name); err != nil {
+		return err
+	}
+	cfg := a.configManager.Get()
+	if cfg.PromptTemplates[kind] == name {
+		return a.SetActivePromptTemplate(kind, "")
+	}
+	return nil
+}
+
+// SetActivePromptTemplate 设置指定类型当前使用的模板
+func (a *App) SetActivePromptTemplate(kind string, name string) error {
+	cfg := a.configManager.Get()
+	templates := make(map[string]string, len(cfg.PromptTemplates)+1)
+	maps.Copy(templates, cfg.PromptTemplates)
+	templates[kind] = name
+	cfg.PromptTemplates = templates
+	return a.updateConfig(cfg)
+}
+
+// CyclePromptTemplate 切换到下一个解题模板（快捷键调用）
+func (a *App) CyclePromptTemplate() {
+	list, err := a.promptLibrary.List(prompts.KindSolve)
+	if err != nil {
+		logger.Printf("读取解题模板失败: %v", err)
+		return
+	}
+
+	current := a.configManager.Get().PromptTemplates[string(prompts.KindSolve)]
+	next := list[0]
+	for i, info := range list {
+		if info.Name == current {
+			next = list[(i+1)%len(list)]
+			break
+		}
+	}
+
+	if err := a.SetActivePromptTemplate(string(prompts.KindSolve), next.Name); err != nil {
+		logger.Printf("切换解题模板失败: %v", err)
+		return
+	}
+
+	name := next.Name
+	if next.Builtin {
+		name = "默认"
+	}
+	a.EmitEvent("toast", fmt.Sprintf("当前解题模板: %s", name))
+	a.EmitEvent("prompt-template-changed", next)
+}
+
 // ==================== 简历相关 ====================
 
 // SelectResume 选择简历文件
@@ -324,10 +533,7 @@ func (a *App) SelectResume() string {
 		// 获取配置副本，修改后保存
 		cfg := a.configManager.Get()
 		cfg.ResumePath = path
-		// 序列化并更新配置
-		if jsonData, err := json.Marshal(cfg); err == nil {
-			_ = a.configManager.UpdateFromJSON(string(jsonData))
-		}
+		_ = a.updateConfig(cfg)
 	}
 	return path
 }
@@ -340,10 +546,7 @@ func (a *App) ClearResume() {
 	cfg.ResumePath = ""
 	cfg.ResumeBase64 = ""
 	cfg.ResumeContent = ""
-	// 序列化并更新配置
-	if jsonData, err := json.Marshal(cfg); err == nil {
-		_ = a.configManager.UpdateFromJSON(string(jsonData))
-	}
+	_ = a.updateConfig(cfg)
 }
 
 // GetResumePDF 获取简历 Base64
@@ -358,13 +561,43 @@ func (a *App) ParseResume() (string, error) {
 
 // ==================== 截图相关 ====================
 
-// GetScreenshotPreview 获取截图预览
-func (a *App) GetScreenshotPreview(quality int, sharpen float64, grayscale bool, noCompression bool, screenshotMode string) (screen.PreviewResult, error) {
-	mode := screenshotMode
-	if mode == "" {
-		mode = a.configManager.Get().ScreenshotMode
+// GetScreenshotPreview 获取截图预览，screenshotMode、displayTarget 为空时使用已保存的配置
+func (a *App) GetScreenshotPreview(quality int, sharpen float64, grayscale bool, noCompression bool, screenshotMode string, displayTarget string, displayIndex int) (screen.PreviewResult, error) {
+	opts := screen.OptionsFromConfig(a.configManager.Get())
+	opts.Quality = quality
+	opts.Sharpen = sharpen
+	opts.Grayscale = grayscale
+	opts.NoCompression = noCompression
+	if screenshotMode != "" {
+		opts.Mode = screenshotMode
+	}
+	if displayTarget != "" {
+		opts.DisplayTarget = displayTarget
+		opts.DisplayIndex = displayIndex
+	}
+	return a.screenService.CapturePreview(opts)
+}
+
+// ListDisplays 列出所有屏幕（序号、位置尺寸、缩放比例）
+func (a *App) ListDisplays() []screen.DisplayInfo {
+	return a.screenService.ListDisplays()
+}
+
+// BeginRegionSelection 截取主屏幕作为底图，供前端框选截图区域
+func (a *App) BeginRegionSelection() (screen.RegionSelection, error) {
+	return a.screenService.CaptureForRegionSelection()
+}
+
+// SetCaptureRegion 保存框选的截图区域（屏幕坐标）并切换到区域模式
+func (a *App) SetCaptureRegion(x, y, width, height int) error {
+	region := config.Region{X: x, Y: y, Width: width, Height: height}
+	if region.Empty() {
+		return &config.ValidationError{Field: "captureRegion", Message: "框选区域不能为空"}
 	}
-	return a.screenService.CapturePreview(quality, sharpen, grayscale, noCompression, mode)
+	cfg := a.configManager.Get()
+	cfg.CaptureRegion = region
+	cfg.ScreenshotMode = config.ScreenshotModeRegion
+	return a.updateConfig(cfg)
 }
 
 // CheckScreenCapturePermission 检查截图权限 (macOS)
@@ -398,6 +631,11 @@ func (a *App) OpenMicrophoneSettings() {
 	platform.OpenMicrophoneSettings()
 }
 
+// ListAudioDevices 列出可用于采集的音频设备，ID 可保存到配置 audioDeviceId
+func (a *App) ListAudioDevices() ([]audio.DeviceInfo, error) {
+	return audio.ListDevices()
+}
+
 // SetWindowAlwaysOnTop 设置窗口是否置顶
 func (a *App) SetWindowAlwaysOnTop(alwaysOnTop bool) {
 	hwnd := a.stateManager.GetHwnd()
@@ -489,3 +727,23 @@ func (a *App) StartLiveSession() error {
 func (a *App) StopLiveSession() {
 	a.liveManager.Stop()
 }
+
+// ConfirmLiveToolCall 答复模型工具调用的确认请求（如运行代码）
+func (a *App) ConfirmLiveToolCall(id string, approved bool) error {
+	return a.liveManager.ConfirmToolCall(id, approved)
+}
+
+// ListLiveRecordings 列出保存的 Live 会话录音，最新的排在前面
+func (a *App) ListLiveRecordings() ([]live.RecordingInfo, error) {
+	return a.recordings.List()
+}
+
+// GetLiveRecording 获取录音详情（带时间戳的转写和回答）
+func (a *App) GetLiveRecording(id string) (live.RecordingDetail, error) {
+	return a.recordings.Get(id)
+}
+
+// DeleteLiveRecording 删除录音及其转写文件
+func (a *App) DeleteLiveRecording(id string) error {
+	return a.recordings.Delete(id)
+}
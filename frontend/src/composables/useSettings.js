@@ -14,11 +14,21 @@ export function useSettings(shortcuts, tempShortcuts, uiState, callbacks) {
     baseURL: '',
     model: '',
     assistantModel: '',
+    selfConsistency: false,
+    consistencyModels: [],
+    codingLanguage: '',
+    languageVersion: '',
+    namingConvention: '',
+    alternateLanguages: [],
+    codingStyleRules: [],
     prompt: '',
     transparency: 0,
     mode: 'interview',
     keepContext: false,
     screenshotMode: 'window',
+    displayTarget: 'index',
+    displayIndex: 0,
+    captureRegion: null, // 由 SetCaptureRegion 直接保存，不随设置提交
     resumePath: '',
     resumeContent: '',
     useMarkdownResume: false,
@@ -27,6 +37,16 @@ export function useSettings(shortcuts, tempShortcuts, uiState, callbacks) {
     grayscale: true,
     noCompression: false,
     useLiveApi: false,
+    liveMicrophone: false,
+    audioDeviceId: '',
+    liveVadMode: '',
+    liveTextModality: false,
+    recordLive: false,
+    notesDir: '',
+    liveRunCode: false,
+    livePipeline: false,
+    transcribeUrl: '',
+    transcribeApiKey: '',
     // LLM 生成参数
     temperature: 1.0,
     topP: 0.95,
@@ -105,6 +125,13 @@ export function useSettings(shortcuts, tempShortcuts, uiState, callbacks) {
     settings.baseURL = config.baseURL || ''
     settings.model = config.model || 'gemini-2.5-flash'
     settings.assistantModel = config.assistantModel || ''
+    settings.selfConsistency = config.selfConsistency || false
+    settings.consistencyModels = config.consistencyModels || []
+    settings.codingLanguage = config.codingLanguage || ''
+    settings.languageVersion = config.languageVersion || ''
+    settings.namingConvention = config.namingConvention || ''
+    settings.alternateLanguages = config.alternateLanguages || []
+    settings.codingStyleRules = config.codingStyleRules || []
     settings.prompt = config.prompt || ''
     settings.compressionQuality = config.compressionQuality || 80
     settings.sharpening = config.sharpening || 0
@@ -115,7 +142,20 @@ export function useSettings(shortcuts, tempShortcuts, uiState, callbacks) {
     settings.resumeContent = config.resumeContent || ''
     settings.useMarkdownResume = config.useMarkdownResume || false
     settings.screenshotMode = config.screenshotMode || 'window'
+    settings.displayTarget = config.displayTarget || 'index'
+    settings.displayIndex = config.displayIndex || 0
+    settings.captureRegion = config.captureRegion || null
     settings.useLiveApi = config.useLiveApi || false
+    settings.liveMicrophone = config.liveMicrophone || false
+    settings.audioDeviceId = config.audioDeviceId || ''
+    settings.liveVadMode = config.liveVadMode || ''
+    settings.liveTextModality = config.liveTextModality || false
+    settings.recordLive = config.recordLive || false
+    settings.notesDir = config.notesDir || ''
+    settings.liveRunCode = config.liveRunCode || false
+    settings.livePipeline = config.livePipeline || false
+    settings.transcribeUrl = config.transcribeUrl || ''
+    settings.transcribeApiKey = config.transcribeApiKey || ''
     // LLM 生成参数
     settings.temperature = config.temperature !== undefined ? config.temperature : 1.0
     settings.topP = config.topP !== undefined ? config.topP : 0.95
@@ -226,14 +266,25 @@ export function useSettings(shortcuts, tempShortcuts, uiState, callbacks) {
 
       // 构建要保存的配置
       const configToSave = {
+        // 保留设置面板未涉及的配置项（提示词模板、截图区域等）
+        ...(await GetSettings()),
         apiKey: tempSettings.apiKey,
         baseURL: tempSettings.baseURL,
         model: tempSettings.model,
         assistantModel: tempSettings.assistantModel,
+        selfConsistency: tempSettings.selfConsistency,
+        consistencyModels: tempSettings.consistencyModels,
+        codingLanguage: tempSettings.codingLanguage,
+        languageVersion: tempSettings.languageVersion,
+        namingConvention: tempSettings.namingConvention,
+        alternateLanguages: tempSettings.alternateLanguages,
+        codingStyleRules: tempSettings.codingStyleRules,
         prompt: tempSettings.prompt,
         opacity: 1.0 - tempSettings.transparency,
         keepContext: tempSettings.keepContext,
         screenshotMode: tempSettings.screenshotMode,
+        displayTarget: tempSettings.displayTarget,
+        displayIndex: tempSettings.displayIndex,
         compressionQuality: tempSettings.compressionQuality,
         sharpening: tempSettings.sharpening,
         grayscale: tempSettings.grayscale,
@@ -243,6 +294,16 @@ export function useSettings(shortcuts, tempShortcuts, uiState, callbacks) {
         useMarkdownResume: tempSettings.useMarkdownResume,
         provider: tempSettings.provider,
         useLiveApi: tempSettings.useLiveApi,
+        liveMicrophone: tempSettings.liveMicrophone,
+        audioDeviceId: tempSettings.audioDeviceId,
+        liveVadMode: tempSettings.liveVadMode,
+        liveTextModality: tempSettings.liveTextModality,
+        recordLive: tempSettings.recordLive,
+        notesDir: tempSettings.notesDir,
+        liveRunCode: tempSettings.liveRunCode,
+        livePipeline: tempSettings.livePipeline,
+        transcribeUrl: tempSettings.transcribeUrl,
+        transcribeApiKey: tempSettings.transcribeApiKey,
         // LLM 生成参数
         temperature: tempSettings.temperature,
         topP: tempSettings.topP,
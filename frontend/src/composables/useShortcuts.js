@@ -27,6 +27,8 @@ export function useShortcuts() {
     { action: 'move_right', label: '向右移动', default: 'Alt+→', macDefault: '⌘⌥→' },
     { action: 'scroll_up', label: '向上滚动', default: 'Alt+PgUp', macDefault: '⌘⌥⇧↑' },
     { action: 'scroll_down', label: '向下滚动', default: 'Alt+PgDn', macDefault: '⌘⌥⇧↓' },
+    { action: 'cycle_prompt', label: '切换解题模板', default: 'F7', macDefault: '⌘4' },
+    { action: 'flip_language', label: '切换编程语言', default: 'F6', macDefault: '⌘5' },
   ]
 
   // 获取当前平台的默认快捷键
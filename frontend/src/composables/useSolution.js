@@ -14,6 +14,7 @@ export function useSolution(settings) {
   let thinkingBuffer = ''  // 思维链缓冲区
   let thinkingStartTime = 0 // 思考开始时间
   let pendingUserScreenshot = ''  // 待关联到历史记录的用户截图
+  let pendingUserText = ''        // 待关联到历史记录的文字题目（剪贴板或文件）
 
   const errorState = reactive({
     show: false,
@@ -67,14 +68,18 @@ export function useSolution(settings) {
   /**
    * 创建新的历史项
    */
-  function createHistoryItem(userScreenshot) {
+  function createHistoryItem(userScreenshot, userText) {
     return {
       time: new Date().toLocaleTimeString(),
       rounds: [{
         userScreenshot: userScreenshot || '',
+        userText: userText || '',
         thinking: '',           // 思维链
         thinkingDuration: 0,    // 思考时长(秒)
-        aiResponse: ''          // AI 回复
+        aiResponse: '',         // AI 回复
+        modelAnswers: null,     // 自洽模式下各模型的回答 { [model]: { content, thinking, error } }
+        consistency: null,      // 自洽模式的评审结果
+        diff: null              // 重新解同一道题时与上次答案的代码差异
       }]
     }
   }
@@ -82,15 +87,19 @@ export function useSolution(settings) {
   /**
    * 向历史项添加新轮次
    */
-  function addRoundToItem(item, userScreenshot) {
+  function addRoundToItem(item, userScreenshot, userText) {
     if (!item.rounds) {
       item.rounds = []
     }
     item.rounds.push({
       userScreenshot: userScreenshot || '',
+      userText: userText || '',
       thinking: '',
       thinkingDuration: 0,
-      aiResponse: ''
+      aiResponse: '',
+      modelAnswers: null,
+      consistency: null,
+      diff: null
     })
   }
 
@@ -128,22 +137,22 @@ export function useSolution(settings) {
     if (settings.keepContext && history.value.length > 0 && !shouldOverwriteHistory.value) {
       // 追加模式：向当前历史项添加新轮次
       const currentItem = history.value[0]
-      addRoundToItem(currentItem, pendingUserScreenshot)
+      addRoundToItem(currentItem, pendingUserScreenshot, pendingUserText)
       activeHistoryIndex.value = 0
-      pendingUserScreenshot = ''
     } else {
       // 新建模式
       if (shouldOverwriteHistory.value && history.value.length > 0) {
         // 覆盖现有第一条
-        history.value[0] = createHistoryItem(pendingUserScreenshot)
+        history.value[0] = createHistoryItem(pendingUserScreenshot, pendingUserText)
         shouldOverwriteHistory.value = false
       } else {
         // 创建新历史项
-        history.value.unshift(createHistoryItem(pendingUserScreenshot))
+        history.value.unshift(createHistoryItem(pendingUserScreenshot, pendingUserText))
       }
       activeHistoryIndex.value = 0
-      pendingUserScreenshot = ''
     }
+    pendingUserScreenshot = ''
+    pendingUserText = ''
   }
 
   function handleStreamChunk(token) {
@@ -202,6 +211,53 @@ export function useSolution(settings) {
     })
   }
 
+  /**
+   * 自洽模式下某个模型的输出块（多个模型同时输出，按模型分别保存）
+   */
+  function handleModelChunk(model, token, thinking) {
+    if (isLoading.value) isLoading.value = false
+    if (isAppending.value) isAppending.value = false
+    if (history.value.length === 0) return
+
+    const round = getCurrentRound(history.value[0])
+    if (!round) return
+    if (!round.modelAnswers) round.modelAnswers = {}
+    if (!round.modelAnswers[model]) round.modelAnswers[model] = { content: '', thinking: '', error: '' }
+    const answer = round.modelAnswers[model]
+    if (thinking) {
+      answer.thinking += token
+    } else {
+      answer.content += token
+    }
+  }
+
+  /**
+   * 自洽模式的评审结果，补全没有流式输出的模型（如请求失败）
+   */
+  function handleConsistency(result) {
+    if (history.value.length === 0) return
+    const round = getCurrentRound(history.value[0])
+    if (!round) return
+    if (!round.modelAnswers) round.modelAnswers = {}
+    for (const ans of result.answers || []) {
+      round.modelAnswers[ans.model] = {
+        content: ans.content || '',
+        thinking: ans.thinking || '',
+        error: ans.error || ''
+      }
+    }
+    round.consistency = result
+  }
+
+  /**
+   * 同一道题重新解答时与上次答案的代码差异
+   */
+  function handleSolutionDiff(diff) {
+    if (history.value.length === 0) return
+    const round = getCurrentRound(history.value[0])
+    if (round) round.diff = diff
+  }
+
   function handleSolution(data) {
     isLoading.value = false
     isAppending.value = false
@@ -228,8 +284,10 @@ export function useSolution(settings) {
     streamBuffer = val
   }
 
-  function setUserScreenshot(screenshot) {
-    pendingUserScreenshot = screenshot
+  // 截图题目只有 screenshot，剪贴板或文件中的文字题目只有 text
+  function setUserScreenshot(screenshot, text) {
+    pendingUserScreenshot = screenshot || ''
+    pendingUserText = text || ''
   }
 
   /**
@@ -315,6 +373,20 @@ export function useSolution(settings) {
         " />
       `
       leftPanel.appendChild(imgContainer)
+    } else if (round.userText) {
+      const textContainer = document.createElement('div')
+      textContainer.style.cssText = `
+        padding: 10px;
+        font-size: 12px;
+        line-height: 1.6;
+        color: #334155;
+        background: #f8fafc;
+        border-radius: 6px;
+        white-space: pre-wrap;
+        word-break: break-word;
+      `
+      textContainer.textContent = round.userText
+      leftPanel.appendChild(textContainer)
     } else {
       const placeholder = document.createElement('div')
       placeholder.style.cssText = `
@@ -497,6 +569,9 @@ export function useSolution(settings) {
     handleStreamStart,
     handleStreamChunk,
     handleThinkingChunk,
+    handleModelChunk,
+    handleConsistency,
+    handleSolutionDiff,
     handleSolution,
     setStreamBuffer,
     setUserScreenshot,
@@ -2,6 +2,11 @@
 // This file is automatically generated. DO NOT EDIT
 import {screen} from '../models';
 import {config} from '../models';
+import {audio} from '../models';
+import {live} from '../models';
+import {prompts} from '../models';
+
+export function BeginRegionSelection():Promise<screen.RegionSelection>;
 
 export function CancelRunningTask():Promise<boolean>;
 
@@ -11,22 +16,44 @@ export function CheckScreenCapturePermission():Promise<boolean>;
 
 export function ClearResume():Promise<void>;
 
+export function ConfirmLiveToolCall(arg1:string,arg2:boolean):Promise<void>;
+
 export function CopyCode():Promise<void>;
 
+export function CyclePromptTemplate():Promise<void>;
+
+export function DeleteLiveRecording(arg1:string):Promise<void>;
+
+export function DeletePromptTemplate(arg1:string,arg2:string):Promise<void>;
+
 export function EmitEvent(arg1:string,arg2:Array<any>):Promise<void>;
 
+export function FlipCodingLanguage():Promise<void>;
+
 export function GetInitStatus():Promise<string>;
 
+export function GetLiveRecording(arg1:string):Promise<live.RecordingDetail>;
+
 export function GetModels(arg1:string,arg2:string):Promise<Array<string>>;
 
+export function GetPromptTemplate(arg1:string,arg2:string):Promise<string>;
+
 export function GetResumePDF():Promise<string>;
 
-export function GetScreenshotPreview(arg1:number,arg2:number,arg3:boolean,arg4:boolean,arg5:string):Promise<screen.PreviewResult>;
+export function GetScreenshotPreview(arg1:number,arg2:number,arg3:boolean,arg4:boolean,arg5:string,arg6:string,arg7:number):Promise<screen.PreviewResult>;
 
 export function GetSettings():Promise<config.Config>;
 
 export function IsInterruptThinkingEnabled():Promise<boolean>;
 
+export function ListAudioDevices():Promise<Array<audio.DeviceInfo>>;
+
+export function ListDisplays():Promise<Array<screen.DisplayInfo>>;
+
+export function ListLiveRecordings():Promise<Array<live.RecordingInfo>>;
+
+export function ListPromptTemplates(arg1:string):Promise<Array<prompts.TemplateInfo>>;
+
 export function MoveWindow(arg1:number,arg2:number):Promise<void>;
 
 export function OpenMicrophoneSettings():Promise<void>;
@@ -45,12 +72,22 @@ export function RestoreFocus():Promise<void>;
 
 export function SaveImageToFile(arg1:string):Promise<boolean>;
 
+export function SavePromptTemplate(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function ScrollContent(arg1:string):Promise<void>;
 
 export function SelectResume():Promise<string>;
 
+export function SetActivePromptTemplate(arg1:string,arg2:string):Promise<void>;
+
+export function SetCaptureRegion(arg1:number,arg2:number,arg3:number,arg4:number):Promise<void>;
+
 export function SetWindowAlwaysOnTop(arg1:boolean):Promise<void>;
 
+export function SolveFromClipboard():Promise<void>;
+
+export function SolveFromDrop(arg1:string):Promise<void>;
+
 export function StartLiveSession():Promise<void>;
 
 export function StartRecordingKey(arg1:string):Promise<void>;
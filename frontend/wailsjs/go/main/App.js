@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function BeginRegionSelection() {
+  return window['go']['main']['App']['BeginRegionSelection']();
+}
+
 export function CancelRunningTask() {
   return window['go']['main']['App']['CancelRunningTask']();
 }
@@ -18,28 +22,56 @@ export function ClearResume() {
   return window['go']['main']['App']['ClearResume']();
 }
 
+export function ConfirmLiveToolCall(arg1, arg2) {
+  return window['go']['main']['App']['ConfirmLiveToolCall'](arg1, arg2);
+}
+
 export function CopyCode() {
   return window['go']['main']['App']['CopyCode']();
 }
 
+export function CyclePromptTemplate() {
+  return window['go']['main']['App']['CyclePromptTemplate']();
+}
+
+export function DeleteLiveRecording(arg1) {
+  return window['go']['main']['App']['DeleteLiveRecording'](arg1);
+}
+
+export function DeletePromptTemplate(arg1, arg2) {
+  return window['go']['main']['App']['DeletePromptTemplate'](arg1, arg2);
+}
+
 export function EmitEvent(arg1, arg2) {
   return window['go']['main']['App']['EmitEvent'](arg1, arg2);
 }
 
+export function FlipCodingLanguage() {
+  return window['go']['main']['App']['FlipCodingLanguage']();
+}
+
 export function GetInitStatus() {
   return window['go']['main']['App']['GetInitStatus']();
 }
 
+export function GetLiveRecording(arg1) {
+  return window['go']['main']['App']['GetLiveRecording'](arg1);
+}
+
 export function GetModels(arg1, arg2) {
   return window['go']['main']['App']['GetModels'](arg1, arg2);
 }
 
+export function GetPromptTemplate(arg1, arg2) {
+  return window['go']['main']['App']['GetPromptTemplate'](arg1, arg2);
+}
+
 export function GetResumePDF() {
   return window['go']['main']['App']['GetResumePDF']();
 }
 
-export function GetScreenshotPreview(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['main']['App']['GetScreenshotPreview'](arg1, arg2, arg3, arg4, arg5);
+export function GetScreenshotPreview(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
+  return window['go']['main']['App']['GetScreenshotPreview'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
 export function GetSettings() {
@@ -50,6 +82,22 @@ export function IsInterruptThinkingEnabled() {
   return window['go']['main']['App']['IsInterruptThinkingEnabled']();
 }
 
+export function ListAudioDevices() {
+  return window['go']['main']['App']['ListAudioDevices']();
+}
+
+export function ListDisplays() {
+  return window['go']['main']['App']['ListDisplays']();
+}
+
+export function ListLiveRecordings() {
+  return window['go']['main']['App']['ListLiveRecordings']();
+}
+
+export function ListPromptTemplates(arg1) {
+  return window['go']['main']['App']['ListPromptTemplates'](arg1);
+}
+
 export function MoveWindow(arg1, arg2) {
   return window['go']['main']['App']['MoveWindow'](arg1, arg2);
 }
@@ -86,6 +134,10 @@ export function SaveImageToFile(arg1) {
   return window['go']['main']['App']['SaveImageToFile'](arg1);
 }
 
+export function SavePromptTemplate(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SavePromptTemplate'](arg1, arg2, arg3);
+}
+
 export function ScrollContent(arg1) {
   return window['go']['main']['App']['ScrollContent'](arg1);
 }
@@ -94,10 +146,26 @@ export function SelectResume() {
   return window['go']['main']['App']['SelectResume']();
 }
 
+export function SetActivePromptTemplate(arg1, arg2) {
+  return window['go']['main']['App']['SetActivePromptTemplate'](arg1, arg2);
+}
+
+export function SetCaptureRegion(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetCaptureRegion'](arg1, arg2, arg3, arg4);
+}
+
 export function SetWindowAlwaysOnTop(arg1) {
   return window['go']['main']['App']['SetWindowAlwaysOnTop'](arg1);
 }
 
+export function SolveFromClipboard() {
+  return window['go']['main']['App']['SolveFromClipboard']();
+}
+
+export function SolveFromDrop(arg1) {
+  return window['go']['main']['App']['SolveFromDrop'](arg1);
+}
+
 export function StartLiveSession() {
   return window['go']['main']['App']['StartLiveSession']();
 }
@@ -1,30 +1,117 @@
+export namespace audio {
+	
+	export class DeviceInfo {
+	    id: string;
+	    name: string;
+	    kind: string;
+	    isDefault: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.kind = source["kind"];
+	        this.isDefault = source["isDefault"];
+	    }
+	}
+
+}
+
 export namespace config {
 	
+	export class Region {
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Region(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
 	export class Config {
 	    apiKey?: string;
 	    provider?: string;
 	    model?: string;
 	    baseURL?: string;
 	    prompt?: string;
+	    promptTemplates?: Record<string, string>;
+	    codingLanguage?: string;
+	    locale?: string;
+	    answerLanguage?: string;
 	    opacity?: number;
 	    noCompression?: boolean;
 	    compressionQuality?: number;
 	    sharpening?: number;
 	    grayscale?: boolean;
+	    autoCrop?: boolean;
+	    focusTextBlock?: boolean;
+	    maxImageDimension?: number;
+	    binarize?: boolean;
+	    imageEncoder?: string;
+	    imageSteps?: string[];
+	    imageTargetBytes?: Record<string, number>;
+	    optimizeTokens?: boolean;
+	    minGlyphHeight?: number;
+	    forceResolve?: boolean;
+	    unchangedRatio?: number;
 	    keepContext?: boolean;
 	    interruptThinking?: boolean;
 	    screenshotMode?: string;
+	    captureRegion?: Region;
+	    displayTarget?: string;
+	    displayIndex?: number;
 	    resumePath?: string;
 	    resumeContent?: string;
 	    useMarkdownResume?: boolean;
 	    shortcuts?: Record<string, shortcut.KeyBinding>;
+	    ocrMode?: string;
+	    tesseractPath?: string;
+	    ocrLanguages?: string;
+	    ocrMinConfidence?: number;
 	    temperature?: number;
 	    topP?: number;
 	    topK?: number;
 	    maxTokens?: number;
 	    thinkingBudget?: number;
+	    languageVersion?: string;
+	    codingStyleRules?: string[];
+	    namingConvention?: string;
+	    alternateLanguages?: string[];
 	    assistantModel?: string;
+	    selfConsistency?: boolean;
+	    consistencyModels?: string[];
 	    useLiveApi?: boolean;
+	    liveMicrophone?: boolean;
+	    liveMicGain?: number;
+	    audioDeviceId?: string;
+	    liveVadMode?: string;
+	    vadThreshold?: number;
+	    vadMaxZcr?: number;
+	    vadHangoverMs?: number;
+	    vadPreRollMs?: number;
+	    liveTextModality?: boolean;
+	    recordLive?: boolean;
+	    recordOpus?: boolean;
+	    notesDir?: string;
+	    liveRunCode?: boolean;
+	    livePipeline?: boolean;
+	    transcribeUrl?: string;
+	    transcribeApiKey?: string;
+	    transcribeModel?: string;
+	    transcribeLanguage?: string;
 	    windowWidth?: number;
 	    windowHeight?: number;
 	
@@ -39,25 +126,71 @@ export namespace config {
 	        this.model = source["model"];
 	        this.baseURL = source["baseURL"];
 	        this.prompt = source["prompt"];
+	        this.promptTemplates = source["promptTemplates"];
+	        this.codingLanguage = source["codingLanguage"];
+	        this.locale = source["locale"];
+	        this.answerLanguage = source["answerLanguage"];
 	        this.opacity = source["opacity"];
 	        this.noCompression = source["noCompression"];
 	        this.compressionQuality = source["compressionQuality"];
 	        this.sharpening = source["sharpening"];
 	        this.grayscale = source["grayscale"];
+	        this.autoCrop = source["autoCrop"];
+	        this.focusTextBlock = source["focusTextBlock"];
+	        this.maxImageDimension = source["maxImageDimension"];
+	        this.binarize = source["binarize"];
+	        this.imageEncoder = source["imageEncoder"];
+	        this.imageSteps = source["imageSteps"];
+	        this.imageTargetBytes = source["imageTargetBytes"];
+	        this.optimizeTokens = source["optimizeTokens"];
+	        this.minGlyphHeight = source["minGlyphHeight"];
+	        this.forceResolve = source["forceResolve"];
+	        this.unchangedRatio = source["unchangedRatio"];
 	        this.keepContext = source["keepContext"];
 	        this.interruptThinking = source["interruptThinking"];
 	        this.screenshotMode = source["screenshotMode"];
+	        this.captureRegion = this.convertValues(source["captureRegion"], Region);
+	        this.displayTarget = source["displayTarget"];
+	        this.displayIndex = source["displayIndex"];
 	        this.resumePath = source["resumePath"];
 	        this.resumeContent = source["resumeContent"];
 	        this.useMarkdownResume = source["useMarkdownResume"];
 	        this.shortcuts = this.convertValues(source["shortcuts"], shortcut.KeyBinding, true);
+	        this.ocrMode = source["ocrMode"];
+	        this.tesseractPath = source["tesseractPath"];
+	        this.ocrLanguages = source["ocrLanguages"];
+	        this.ocrMinConfidence = source["ocrMinConfidence"];
 	        this.temperature = source["temperature"];
 	        this.topP = source["topP"];
 	        this.topK = source["topK"];
 	        this.maxTokens = source["maxTokens"];
 	        this.thinkingBudget = source["thinkingBudget"];
+	        this.languageVersion = source["languageVersion"];
+	        this.codingStyleRules = source["codingStyleRules"];
+	        this.namingConvention = source["namingConvention"];
+	        this.alternateLanguages = source["alternateLanguages"];
 	        this.assistantModel = source["assistantModel"];
+	        this.selfConsistency = source["selfConsistency"];
+	        this.consistencyModels = source["consistencyModels"];
 	        this.useLiveApi = source["useLiveApi"];
+	        this.liveMicrophone = source["liveMicrophone"];
+	        this.liveMicGain = source["liveMicGain"];
+	        this.audioDeviceId = source["audioDeviceId"];
+	        this.liveVadMode = source["liveVadMode"];
+	        this.vadThreshold = source["vadThreshold"];
+	        this.vadMaxZcr = source["vadMaxZcr"];
+	        this.vadHangoverMs = source["vadHangoverMs"];
+	        this.vadPreRollMs = source["vadPreRollMs"];
+	        this.liveTextModality = source["liveTextModality"];
+	        this.recordLive = source["recordLive"];
+	        this.recordOpus = source["recordOpus"];
+	        this.notesDir = source["notesDir"];
+	        this.liveRunCode = source["liveRunCode"];
+	        this.livePipeline = source["livePipeline"];
+	        this.transcribeUrl = source["transcribeUrl"];
+	        this.transcribeApiKey = source["transcribeApiKey"];
+	        this.transcribeModel = source["transcribeModel"];
+	        this.transcribeLanguage = source["transcribeLanguage"];
 	        this.windowWidth = source["windowWidth"];
 	        this.windowHeight = source["windowHeight"];
 	    }
@@ -83,12 +216,202 @@ export namespace config {
 
 }
 
+export namespace imageutil {
+	
+	export class TokenEstimate {
+	    pricing: string;
+	    width: number;
+	    height: number;
+	    tiles: number;
+	    tokens: number;
+	    glyphHeight: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TokenEstimate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pricing = source["pricing"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.tiles = source["tiles"];
+	        this.tokens = source["tokens"];
+	        this.glyphHeight = source["glyphHeight"];
+	    }
+	}
+
+}
+
+export namespace live {
+	
+	export class RecordedEvent {
+	    offsetMs: number;
+	    type: string;
+	    speaker?: string;
+	    text?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordedEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.offsetMs = source["offsetMs"];
+	        this.type = source["type"];
+	        this.speaker = source["speaker"];
+	        this.text = source["text"];
+	    }
+	}
+	export class RecordingDetail {
+	    id: string;
+	    // Go type: time
+	    startedAt: any;
+	    durationMs: number;
+	    model?: string;
+	    sampleRate: number;
+	    audio: string;
+	    opus?: string;
+	    rounds: number;
+	    events: RecordedEvent[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingDetail(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.durationMs = source["durationMs"];
+	        this.model = source["model"];
+	        this.sampleRate = source["sampleRate"];
+	        this.audio = source["audio"];
+	        this.opus = source["opus"];
+	        this.rounds = source["rounds"];
+	        this.events = this.convertValues(source["events"], RecordedEvent);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingInfo {
+	    id: string;
+	    // Go type: time
+	    startedAt: any;
+	    durationMs: number;
+	    model?: string;
+	    sampleRate: number;
+	    audio: string;
+	    opus?: string;
+	    rounds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.durationMs = source["durationMs"];
+	        this.model = source["model"];
+	        this.sampleRate = source["sampleRate"];
+	        this.audio = source["audio"];
+	        this.opus = source["opus"];
+	        this.rounds = source["rounds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace prompts {
+	
+	export class TemplateInfo {
+	    name: string;
+	    kind: string;
+	    builtin: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TemplateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.kind = source["kind"];
+	        this.builtin = source["builtin"];
+	    }
+	}
+
+}
+
 export namespace screen {
 	
+	export class DisplayInfo {
+	    index: number;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	    scaleFactor: number;
+	    primary: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DisplayInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.scaleFactor = source["scaleFactor"];
+	        this.primary = source["primary"];
+	    }
+	}
 	export class PreviewResult {
 	    imgBytes: number[];
 	    base64: string;
 	    size: string;
+	    extra?: string[];
+	    tokens?: imageutil.TokenEstimate;
+	    changedRatio: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new PreviewResult(source);
@@ -99,6 +422,47 @@ export namespace screen {
 	        this.imgBytes = source["imgBytes"];
 	        this.base64 = source["base64"];
 	        this.size = source["size"];
+	        this.extra = source["extra"];
+	        this.tokens = this.convertValues(source["tokens"], imageutil.TokenEstimate);
+	        this.changedRatio = source["changedRatio"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RegionSelection {
+	    base64: string;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RegionSelection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.base64 = source["base64"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
 	    }
 	}
 
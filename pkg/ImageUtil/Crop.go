@@ -0,0 +1,203 @@
+package imageutil
+
+import (
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	uniformTolerance = 12    // 亮度差不超过该值视为同一颜色
+	uniformRatio     = 0.995 // 一行/列中同色像素占比达到该值视为纯色
+	cropPadding      = 8     // 裁剪后保留的边距（像素）
+	edgeThreshold    = 40    // 相邻像素亮度差超过该值视为文字边缘
+	minBlockArea     = 0.1   // 文字块面积小于原图该比例时放弃裁剪，避免误裁
+)
+
+// TrimBorders 去掉四周的纯色边框和空白边距（黑边、窗口留白、编辑器空白区域等）
+func TrimBorders(img image.Image) image.Image {
+	rect := ContentBounds(img)
+	if rect == img.Bounds() {
+		return img
+	}
+	return imaging.Crop(img, rect)
+}
+
+// ContentBounds 计算去掉纯色边框后的内容区域，整张图都是纯色时返回原区域
+func ContentBounds(img image.Image) image.Rectangle {
+	gray := imaging.Grayscale(img)
+	w, h := gray.Bounds().Dx(), gray.Bounds().Dy()
+	luma := func(x, y int) int {
+		return int(gray.Pix[y*gray.Stride+x*4])
+	}
+
+	uniformRow := func(y, left, right int) bool {
+		return isUniform(right-left, func(i int) int { return luma(left+i, y) })
+	}
+	uniformCol := func(x, top, bottom int) bool {
+		return isUniform(bottom-top, func(i int) int { return luma(x, top+i) })
+	}
+
+	top, bottom := 0, h
+	for top < bottom && uniformRow(top, 0, w) {
+		top++
+	}
+	for bottom > top && uniformRow(bottom-1, 0, w) {
+		bottom--
+	}
+	if top >= bottom {
+		return img.Bounds()
+	}
+
+	left, right := 0, w
+	for left < right && uniformCol(left, top, bottom) {
+		left++
+	}
+	for right > left && uniformCol(right-1, top, bottom) {
+		right--
+	}
+	// 左右边框去掉后，上下可能还有只在左右两侧有内容的行（如黑边内的留白），再收缩一次
+	for top < bottom && uniformRow(top, left, right) {
+		top++
+	}
+	for bottom > top && uniformRow(bottom-1, left, right) {
+		bottom--
+	}
+
+	rect := image.Rect(left-cropPadding, top-cropPadding, right+cropPadding, bottom+cropPadding).
+		Intersect(image.Rect(0, 0, w, h))
+	return rect.Add(img.Bounds().Min)
+}
+
+// isUniform 判断一行（列）像素是否基本为同一颜色
+// 以第一个像素为基准会受噪点影响，这里先统计亮度直方图取众数
+func isUniform(n int, at func(i int) int) bool {
+	if n <= 0 {
+		return true
+	}
+	var hist [256]int
+	for i := 0; i < n; i++ {
+		hist[at(i)]++
+	}
+	mode := 0
+	for v := range hist {
+		if hist[v] > hist[mode] {
+			mode = v
+		}
+	}
+	same := 0
+	for v := max(mode-uniformTolerance, 0); v <= min(mode+uniformTolerance, 255); v++ {
+		same += hist[v]
+	}
+	return float64(same) >= float64(n)*uniformRatio
+}
+
+// CropToTextBlock 裁剪到文字最密集的区域（题面或代码区），去掉侧边栏、工具栏等 IDE 界面元素
+// 找不到足够大的文字块时返回原图
+func CropToTextBlock(img image.Image) image.Image {
+	rect := TextBlockBounds(img)
+	if rect == img.Bounds() {
+		return img
+	}
+	return imaging.Crop(img, rect)
+}
+
+// TextBlockBounds 基于边缘密度找出最主要的文字块
+// 先按行统计边缘像素找出最密集的行带，再在行带内按列统计找出最密集的列带
+func TextBlockBounds(img image.Image) image.Rectangle {
+	bounds := img.Bounds()
+	// 缩小后计算，4K 截图也只需处理几十万像素
+	const analyzeSize = 800
+	small := img
+	if bounds.Dx() > analyzeSize || bounds.Dy() > analyzeSize {
+		if bounds.Dx() > bounds.Dy() {
+			small = imaging.Resize(img, analyzeSize, 0, imaging.Box)
+		} else {
+			small = imaging.Resize(img, 0, analyzeSize, imaging.Box)
+		}
+	}
+	gray := imaging.Grayscale(small)
+	w, h := gray.Bounds().Dx(), gray.Bounds().Dy()
+	if w < 2 || h < 2 {
+		return bounds
+	}
+
+	// 水平方向亮度突变的像素视为文字边缘
+	edges := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		row := gray.Pix[y*gray.Stride:]
+		for x := 0; x+1 < w; x++ {
+			diff := int(row[x*4]) - int(row[(x+1)*4])
+			if diff > edgeThreshold || diff < -edgeThreshold {
+				edges[y*w+x] = true
+			}
+		}
+	}
+
+	rowCounts := make([]int, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if edges[y*w+x] {
+				rowCounts[y]++
+			}
+		}
+	}
+	top, bottom := densestBand(rowCounts, max(w/100, 1), max(h/40, 1))
+	if top >= bottom {
+		return bounds
+	}
+
+	colCounts := make([]int, w)
+	for y := top; y < bottom; y++ {
+		for x := 0; x < w; x++ {
+			if edges[y*w+x] {
+				colCounts[x]++
+			}
+		}
+	}
+	left, right := densestBand(colCounts, max((bottom-top)/100, 1), max(w/40, 1))
+	if left >= right {
+		return bounds
+	}
+
+	// 映射回原图坐标并留出边距
+	scaleX := float64(bounds.Dx()) / float64(w)
+	scaleY := float64(bounds.Dy()) / float64(h)
+	rect := image.Rect(
+		int(float64(left)*scaleX)-cropPadding,
+		int(float64(top)*scaleY)-cropPadding,
+		int(float64(right)*scaleX)+cropPadding,
+		int(float64(bottom)*scaleY)+cropPadding,
+	).Add(bounds.Min).Intersect(bounds)
+
+	if float64(rect.Dx()*rect.Dy()) < float64(bounds.Dx()*bounds.Dy())*minBlockArea {
+		return bounds
+	}
+	return rect
+}
+
+// densestBand 在一维边缘计数中找出总计数最大的连续区间 [start, end)
+// 计数达到 threshold 的位置视为有内容，相隔不超过 gap 的内容合并为同一区间
+func densestBand(counts []int, threshold, gap int) (int, int) {
+	bestStart, bestEnd, bestSum := 0, 0, 0
+	start, end, sum := -1, -1, 0
+	flush := func() {
+		if start >= 0 && sum > bestSum {
+			bestStart, bestEnd, bestSum = start, end, sum
+		}
+	}
+
+	for i, c := range counts {
+		if c < threshold {
+			continue
+		}
+		if start < 0 || i-end > gap {
+			flush()
+			start, sum = i, 0
+		}
+		end = i + 1
+		sum += c
+	}
+	flush()
+	return bestStart, bestEnd
+}
@@ -0,0 +1,64 @@
+package imageutil
+
+import (
+	"image"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+// PerceptualHash 计算图片的感知哈希（dHash，64 位）
+// 图片缩放为 9x8 灰度图后比较相邻像素的亮度，对压缩、缩放和轻微噪点不敏感
+func PerceptualHash(img image.Image) uint64 {
+	small := imaging.Grayscale(imaging.Resize(img, 9, 8, imaging.Box))
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := small.Pix[y*small.Stride+x*4]
+			right := small.Pix[y*small.Stride+(x+1)*4]
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HashDistance 两个感知哈希之间的汉明距离（0 表示几乎相同，最大 64）
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// 变化检测的网格大小和判定阈值
+const (
+	signatureSize      = 64 // 截图缩放为 64x64 的灰度网格
+	signatureTolerance = 10 // 单个格子亮度变化超过该值视为有变化
+)
+
+// ChangeSignature 生成用于变化检测的灰度网格
+// 感知哈希只有 64 位，改动一行代码往往不会反映出来，网格能定位到局部的变化
+func ChangeSignature(img image.Image) []uint8 {
+	small := imaging.Grayscale(imaging.Resize(img, signatureSize, signatureSize, imaging.Box))
+	signature := make([]uint8, 0, signatureSize*signatureSize)
+	for i := 0; i < len(small.Pix); i += 4 {
+		signature = append(signature, small.Pix[i])
+	}
+	return signature
+}
+
+// ChangedRatio 两个网格之间发生变化的格子比例（0-1），无法比较时返回 1
+func ChangedRatio(a, b []uint8) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 1
+	}
+	changed := 0
+	for i := range a {
+		diff := int(a[i]) - int(b[i])
+		if diff > signatureTolerance || diff < -signatureTolerance {
+			changed++
+		}
+	}
+	return float64(changed) / float64(len(a))
+}
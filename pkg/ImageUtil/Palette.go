@@ -0,0 +1,113 @@
+package imageutil
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sort"
+)
+
+// EncodePalettePNG 将图片量化为最多 maxColors 色的调色板后编码为 PNG
+// 截图颜色通常集中在少数几种背景和文字颜色上，调色板 PNG 往往比真彩色小很多
+func EncodePalettePNG(w io.Writer, img image.Image, maxColors int) error {
+	if maxColors < 2 || maxColors > 256 {
+		maxColors = 256
+	}
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	return encoder.Encode(w, Quantize(img, maxColors))
+}
+
+// Quantize 按颜色出现频率生成调色板（RGB 各取高 5 位分桶，取最常见的桶）
+// 颜色数不超过上限时调色板是精确的
+func Quantize(img image.Image, maxColors int) *image.Paletted {
+	bounds := img.Bounds()
+
+	// 先统计精确颜色，颜色较少时直接使用
+	exact := make(map[color.NRGBA]int)
+	for y := bounds.Min.Y; y < bounds.Max.Y && len(exact) <= maxColors; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			exact[color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)]++
+			if len(exact) > maxColors {
+				break
+			}
+		}
+	}
+	if len(exact) <= maxColors {
+		palette := make(color.Palette, 0, len(exact))
+		index := make(map[color.NRGBA]uint8, len(exact))
+		for c := range exact {
+			index[c] = uint8(len(palette))
+			palette = append(palette, c)
+		}
+		dst := image.NewPaletted(bounds, palette)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				dst.SetColorIndex(x, y, index[color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)])
+			}
+		}
+		return dst
+	}
+
+	// 颜色过多：按 15 位颜色分桶，桶内取平均色
+	type bucket struct {
+		count      int
+		r, g, b, a int
+	}
+	buckets := make([]bucket, 1<<15)
+	key := func(c color.NRGBA) int {
+		return int(c.R>>3)<<10 | int(c.G>>3)<<5 | int(c.B>>3)
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			b := &buckets[key(c)]
+			b.count++
+			b.r += int(c.R)
+			b.g += int(c.G)
+			b.b += int(c.B)
+			b.a += int(c.A)
+		}
+	}
+
+	var used []int
+	for k, b := range buckets {
+		if b.count > 0 {
+			used = append(used, k)
+		}
+	}
+	sort.Slice(used, func(i, j int) bool {
+		return buckets[used[i]].count > buckets[used[j]].count
+	})
+	if len(used) > maxColors {
+		used = used[:maxColors]
+	}
+	palette := make(color.Palette, len(used))
+	for i, k := range used {
+		b := buckets[k]
+		palette[i] = color.NRGBA{
+			R: uint8(b.r / b.count),
+			G: uint8(b.g / b.count),
+			B: uint8(b.b / b.count),
+			A: uint8(b.a / b.count),
+		}
+	}
+
+	// 每个桶只查一次最近的调色板颜色
+	lookup := make([]int16, 1<<15)
+	for i := range lookup {
+		lookup[i] = -1
+	}
+	dst := image.NewPaletted(bounds, palette)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			k := key(c)
+			if lookup[k] < 0 {
+				lookup[k] = int16(palette.Index(c))
+			}
+			dst.SetColorIndex(x, y, uint8(lookup[k]))
+		}
+	}
+	return dst
+}
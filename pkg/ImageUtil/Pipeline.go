@@ -0,0 +1,306 @@
+package imageutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/disintegration/imaging"
+)
+
+// DefaultMaxDimension 默认的长边上限
+// 2000px 对于绝大多数 OCR 场景已经足够清晰，且能显著减少 Token 消耗
+const DefaultMaxDimension = 2000
+
+// minTargetQuality 目标大小模式下 JPEG 质量的下限，再低文字会糊成一片，不如缩小尺寸
+const minTargetQuality = 30
+
+// 编码格式
+const (
+	EncoderJPEG         = "jpeg"
+	EncoderPNG          = "png"
+	EncoderPalettePNG   = "png-palette"   // 最多 256 色的调色板 PNG
+	EncoderWebPLossless = "webp-lossless" // 无损 WebP
+)
+
+// 处理步骤
+const (
+	StepFocusText = "focus-text" // 裁剪到文字最密集的区域
+	StepAutoCrop  = "auto-crop"  // 去掉纯色边框和空白边距
+	StepResize    = "resize"     // 缩放到长边上限，设置了计费方式时再缩放到 Token 最少的尺寸
+	StepGrayscale = "grayscale"  // 灰度化
+	StepSharpen   = "sharpen"    // 锐化
+	StepBinarize  = "binarize"   // 二值化（Otsu 阈值），适合纯文字题面
+)
+
+// DefaultSteps 默认的步骤顺序
+var DefaultSteps = []string{StepFocusText, StepAutoCrop, StepResize, StepGrayscale, StepSharpen, StepBinarize}
+
+// IsCropStep 是否为裁剪步骤
+func IsCropStep(step string) bool {
+	return step == StepFocusText || step == StepAutoCrop
+}
+
+// Pipeline 截图上传前的处理流程：按 Steps 的顺序执行各步骤，最后编码
+// 裁剪步骤总是先于其余步骤执行，裁剪结果单独用于 OCR 和不压缩模式
+type Pipeline struct {
+	Steps        []string // 要执行的步骤及顺序，未列出的步骤不执行
+	MaxDimension int      // 长边上限，0 使用默认值；没有 resize 步骤时也会在编码前生效
+	Sharpen      float64  // 锐化强度，0 不锐化
+	Encoder      string   // jpeg / png / png-palette / webp-lossless
+	Quality      int      // JPEG 质量
+	TargetBytes  int      // 编码后 Base64 长度上限，0 不限制
+
+	Pricing        string  // 目标模型的图片计费方式，为空不估算 Token
+	MinGlyphHeight float64 // 大于 0 时在文字行高不低于该值的前提下缩放到 Token 最少的尺寸
+}
+
+// Encoded 编码结果
+type Encoded struct {
+	Data     []byte
+	MimeType string
+	Quality  int // 实际使用的 JPEG 质量（目标大小模式下可能低于配置值）
+	Width    int
+	Height   int
+	Estimate *TokenEstimate // 视觉 Token 估算，未指定计费方式时为空
+}
+
+// DataURL 转为 data URL
+func (e Encoded) DataURL() string {
+	return fmt.Sprintf("data:%s;base64,%s", e.MimeType, base64.StdEncoding.EncodeToString(e.Data))
+}
+
+// Run 执行完整流程
+func (p Pipeline) Run(img image.Image) (Encoded, error) {
+	return p.Encode(p.Transform(p.Crop(img)))
+}
+
+// Crop 按顺序执行裁剪步骤
+func (p Pipeline) Crop(img image.Image) image.Image {
+	for _, step := range p.Steps {
+		switch step {
+		case StepFocusText:
+			img = CropToTextBlock(img)
+		case StepAutoCrop:
+			img = TrimBorders(img)
+		}
+	}
+	return img
+}
+
+// Transform 按顺序执行裁剪以外的步骤
+func (p Pipeline) Transform(img image.Image) image.Image {
+	resized := false
+	for _, step := range p.Steps {
+		switch step {
+		case StepResize:
+			img = p.resize(img)
+			resized = true
+		case StepGrayscale:
+			// 灰度化能去掉颜色干扰，并减小文件体积
+			img = imaging.Grayscale(img)
+		case StepSharpen:
+			// 稍微锐化一点点有助于 OCR 识别文字边缘，但不要过度
+			if p.Sharpen > 0 {
+				img = imaging.Sharpen(img, p.Sharpen)
+			}
+		case StepBinarize:
+			img = binarize(img)
+		}
+	}
+	if !resized {
+		img = resizeToFit(img, p.maxDimension())
+	}
+	return img
+}
+
+func (p Pipeline) maxDimension() int {
+	if p.MaxDimension <= 0 {
+		return DefaultMaxDimension
+	}
+	return p.MaxDimension
+}
+
+// resize 缩放到长边上限，再按计费方式缩放到 Token 最少、文字仍清晰的尺寸
+func (p Pipeline) resize(img image.Image) image.Image {
+	img = resizeToFit(img, p.maxDimension())
+	if p.Pricing != "" && p.MinGlyphHeight > 0 {
+		bounds := img.Bounds()
+		glyph := EstimateGlyphHeight(img)
+		if scale := PlanScale(p.Pricing, bounds.Dx(), bounds.Dy(), glyph, p.MinGlyphHeight); scale < 1 {
+			img = imaging.Resize(img, int(float64(bounds.Dx())*scale), 0, imaging.Lanczos)
+		}
+	}
+	return img
+}
+
+// Encode 按编码器编码，并估算视觉 Token
+func (p Pipeline) Encode(img image.Image) (Encoded, error) {
+	encoded, final, err := p.encodeToTarget(img)
+	if err != nil {
+		return Encoded{}, err
+	}
+	bounds := final.Bounds()
+	encoded.Width, encoded.Height = bounds.Dx(), bounds.Dy()
+	if p.Pricing != "" {
+		estimate := EstimateTokens(p.Pricing, encoded.Width, encoded.Height)
+		estimate.GlyphHeight = EstimateGlyphHeight(final) * float64(estimate.Width) / float64(encoded.Width)
+		encoded.Estimate = &estimate
+	}
+	return encoded, nil
+}
+
+// encodeToTarget 设置了目标大小时 JPEG 二分查找质量，其余编码器逐步缩小尺寸，返回编码结果和最终图片
+func (p Pipeline) encodeToTarget(img image.Image) (Encoded, image.Image, error) {
+	encoded, err := p.encodeOnce(img, p.Quality)
+	if err != nil || p.TargetBytes <= 0 || fits(encoded, p.TargetBytes) {
+		return encoded, img, err
+	}
+
+	for attempt := 0; attempt < 6; attempt++ {
+		if p.Encoder == "" || p.Encoder == EncoderJPEG {
+			best, found, err := p.searchQuality(img)
+			if err != nil {
+				return Encoded{}, nil, err
+			}
+			if found {
+				return best, img, nil
+			}
+		}
+
+		// 最低质量仍然超出（或无损编码器）：缩小到 80% 再试
+		bounds := img.Bounds()
+		img = imaging.Resize(img, bounds.Dx()*4/5, 0, imaging.Lanczos)
+		encoded, err = p.encodeOnce(img, p.Quality)
+		if err != nil {
+			return Encoded{}, nil, err
+		}
+		if fits(encoded, p.TargetBytes) {
+			return encoded, img, nil
+		}
+	}
+	return encoded, img, nil
+}
+
+// searchQuality 二分查找不超过目标大小的最高 JPEG 质量（不低于 minTargetQuality）
+func (p Pipeline) searchQuality(img image.Image) (Encoded, bool, error) {
+	var best Encoded
+	found := false
+	hi := clampQuality(p.Quality)
+	lo := min(minTargetQuality, hi)
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		encoded, err := encodeJPEG(img, mid)
+		if err != nil {
+			return Encoded{}, false, err
+		}
+		if fits(encoded, p.TargetBytes) {
+			best, found = encoded, true
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	return best, found, nil
+}
+
+func (p Pipeline) encodeOnce(img image.Image, quality int) (Encoded, error) {
+	var buf bytes.Buffer
+	switch p.Encoder {
+	case EncoderPNG:
+		if err := png.Encode(&buf, img); err != nil {
+			return Encoded{}, err
+		}
+		return Encoded{Data: buf.Bytes(), MimeType: "image/png"}, nil
+	case EncoderPalettePNG:
+		if err := EncodePalettePNG(&buf, img, 256); err != nil {
+			return Encoded{}, err
+		}
+		return Encoded{Data: buf.Bytes(), MimeType: "image/png"}, nil
+	case EncoderWebPLossless:
+		if err := EncodeWebPLossless(&buf, img); err != nil {
+			return Encoded{}, err
+		}
+		return Encoded{Data: buf.Bytes(), MimeType: "image/webp"}, nil
+	default:
+		return encodeJPEG(img, quality)
+	}
+}
+
+func encodeJPEG(img image.Image, quality int) (Encoded, error) {
+	quality = clampQuality(quality)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return Encoded{}, err
+	}
+	return Encoded{Data: buf.Bytes(), MimeType: "image/jpeg", Quality: quality}, nil
+}
+
+// clampQuality JPEG 质量范围 1-90，超过 90 体积增长明显但清晰度几乎没有提升
+func clampQuality(quality int) int {
+	return min(max(quality, 1), 90)
+}
+
+// fits 编码后的 Base64 长度是否不超过目标大小
+func fits(encoded Encoded, targetBytes int) bool {
+	return base64.StdEncoding.EncodedLen(len(encoded.Data)) <= targetBytes
+}
+
+// resizeToFit 长边超过上限时等比例缩小
+func resizeToFit(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return img
+	}
+	if width > height {
+		return imaging.Resize(img, maxDimension, 0, imaging.Lanczos)
+	}
+	return imaging.Resize(img, 0, maxDimension, imaging.Lanczos)
+}
+
+// binarize 使用 Otsu 方法自动选择阈值，将灰度图转为黑白
+func binarize(img image.Image) image.Image {
+	gray := imaging.Grayscale(img)
+	var hist [256]int
+	for i := 0; i < len(gray.Pix); i += 4 {
+		hist[gray.Pix[i]]++
+	}
+
+	total := len(gray.Pix) / 4
+	var sum float64
+	for v, n := range hist {
+		sum += float64(v * n)
+	}
+	var sumBackground, bestVariance float64
+	var weightBackground, threshold int
+	for v, n := range hist {
+		weightBackground += n
+		if weightBackground == 0 {
+			continue
+		}
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+		sumBackground += float64(v * n)
+		meanBackground := sumBackground / float64(weightBackground)
+		meanForeground := (sum - sumBackground) / float64(weightForeground)
+		variance := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if variance > bestVariance {
+			bestVariance, threshold = variance, v
+		}
+	}
+
+	for i := 0; i < len(gray.Pix); i += 4 {
+		v := uint8(0)
+		if int(gray.Pix[i]) > threshold {
+			v = 255
+		}
+		gray.Pix[i], gray.Pix[i+1], gray.Pix[i+2] = v, v, v
+	}
+	return gray
+}
@@ -0,0 +1,192 @@
+package imageutil
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+)
+
+// testScreenshot 白底、中间一块彩色内容的截图
+func testScreenshot(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	content := image.Rect(width/4, height/4, width*3/4, height*3/4)
+	draw.Draw(img, content, image.NewUniform(color.NRGBA{R: 200, G: 30, B: 60, A: 255}), image.Point{}, draw.Src)
+	return img
+}
+
+func isGray(img image.Image) bool {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r != g || g != b {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestPipelineRunsOnlyListedSteps(t *testing.T) {
+	src := testScreenshot(400, 300)
+
+	plain := Pipeline{}.Transform(src)
+	if isGray(plain) {
+		t.Error("未列出 grayscale 步骤时不应灰度化")
+	}
+	gray := Pipeline{Steps: []string{StepGrayscale}}.Transform(src)
+	if !isGray(gray) {
+		t.Error("列出 grayscale 步骤后应灰度化")
+	}
+
+	// 裁剪步骤只在 Crop 中执行
+	if got := (Pipeline{Steps: []string{StepAutoCrop}}).Transform(src).Bounds(); got != src.Bounds() {
+		t.Errorf("Transform 不应执行裁剪步骤: %v", got)
+	}
+	cropped := Pipeline{Steps: []string{StepAutoCrop}}.Crop(src).Bounds()
+	if cropped.Dx() >= 400 || cropped.Dy() >= 300 {
+		t.Errorf("auto-crop 未去掉空白边距: %v", cropped)
+	}
+}
+
+func TestPipelineStepOrder(t *testing.T) {
+	src := testScreenshot(400, 300)
+
+	// 先缩放再二值化只剩纯黑白，先二值化再缩放则边缘会插值出中间灰度
+	binarizeLast := Pipeline{Steps: []string{StepResize, StepBinarize}, MaxDimension: 150}.Transform(src)
+	resizeLast := Pipeline{Steps: []string{StepBinarize, StepResize}, MaxDimension: 150}.Transform(src)
+	if !blackAndWhite(binarizeLast) {
+		t.Error("最后一步为 binarize 时应只有黑白两色")
+	}
+	if blackAndWhite(resizeLast) {
+		t.Error("binarize 之后缩放应产生中间灰度")
+	}
+}
+
+func blackAndWhite(img image.Image) bool {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			if r != 0 && r != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestPipelineAlwaysCapsDimension(t *testing.T) {
+	src := testScreenshot(800, 200)
+	for _, steps := range [][]string{nil, {StepResize}, {StepGrayscale}} {
+		got := Pipeline{Steps: steps, MaxDimension: 400}.Transform(src).Bounds()
+		if got.Dx() != 400 || got.Dy() != 100 {
+			t.Errorf("步骤 %v: 尺寸 %v, 期望 400x100", steps, got)
+		}
+	}
+}
+
+func TestPipelineEncoders(t *testing.T) {
+	src := testScreenshot(64, 48)
+	cases := map[string]string{
+		EncoderJPEG:         "image/jpeg",
+		EncoderPNG:          "image/png",
+		EncoderPalettePNG:   "image/png",
+		EncoderWebPLossless: "image/webp",
+		"":                  "image/jpeg",
+	}
+	for encoder, mime := range cases {
+		encoded, err := Pipeline{Encoder: encoder, Quality: 80}.Run(src)
+		if err != nil {
+			t.Fatalf("%q: %v", encoder, err)
+		}
+		if encoded.MimeType != mime || encoded.Width != 64 || encoded.Height != 48 {
+			t.Errorf("%q: %s %dx%d", encoder, encoded.MimeType, encoded.Width, encoded.Height)
+		}
+	}
+}
+
+// noise 随机噪声图片，任何编码器都很难压缩
+func noise(width, height int) *image.NRGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rng.Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+func TestPipelineTargetBytesJPEGQuality(t *testing.T) {
+	src := noise(300, 200)
+	for _, quality := range []int{40, 60, 75} {
+		// 目标大小恰好容纳该质量的编码结果
+		want, err := encodeJPEG(src, quality)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target := base64.StdEncoding.EncodedLen(len(want.Data))
+
+		encoded, err := Pipeline{Quality: 90, TargetBytes: target}.Encode(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fits(encoded, target) || encoded.Width != 300 || encoded.Height != 200 {
+			t.Errorf("目标 %d: %d 字节 %dx%d", target, len(encoded.Data), encoded.Width, encoded.Height)
+		}
+		// 应当是能放下的最高质量
+		if encoded.Quality < quality {
+			t.Errorf("目标 %d: 质量 %d, 期望不低于 %d", target, encoded.Quality, quality)
+		}
+		higher, err := encodeJPEG(src, encoded.Quality+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fits(higher, target) {
+			t.Errorf("目标 %d: 质量 %d 也能放下，却选了 %d", target, encoded.Quality+1, encoded.Quality)
+		}
+	}
+}
+
+func TestPipelineTargetBytesShrinks(t *testing.T) {
+	src := noise(300, 200)
+	cases := []struct {
+		encoder string
+		mime    string
+	}{
+		{EncoderPNG, "image/png"},
+		{EncoderPalettePNG, "image/png"},
+		{EncoderWebPLossless, "image/webp"},
+		{EncoderJPEG, "image/jpeg"}, // 最低质量仍然超出时也会缩小尺寸
+	}
+	for _, c := range cases {
+		p := Pipeline{Encoder: c.encoder, Quality: 90}
+		full, err := p.Encode(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.TargetBytes = base64.StdEncoding.EncodedLen(len(full.Data)) / 3
+		if c.encoder == EncoderJPEG {
+			lowest, err := encodeJPEG(src, minTargetQuality)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.TargetBytes = base64.StdEncoding.EncodedLen(len(lowest.Data)) / 2
+		}
+
+		encoded, err := p.Encode(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fits(encoded, p.TargetBytes) || encoded.MimeType != c.mime {
+			t.Errorf("%s: %s %d 字节, 目标 %d", c.encoder, encoded.MimeType, len(encoded.Data), p.TargetBytes)
+		}
+		if encoded.Width >= 300 || encoded.Width*2 != encoded.Height*3 {
+			t.Errorf("%s: 尺寸 %dx%d, 期望等比例缩小", c.encoder, encoded.Width, encoded.Height)
+		}
+	}
+}
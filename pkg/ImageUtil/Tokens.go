@@ -0,0 +1,148 @@
+package imageutil
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+// 图片计费方式
+const (
+	PricingOpenAI = "openai" // 512px 分块：85 + 170 × 块数
+	PricingClaude = "claude" // 按像素面积：宽 × 高 / 750
+	PricingGemini = "gemini" // 768px 分块：258 × 块数
+)
+
+// TokenEstimate 截图的视觉 Token 估算
+type TokenEstimate struct {
+	Pricing     string  `json:"pricing"`
+	Width       int     `json:"width"` // 提供商实际处理的尺寸（会按其规则再缩放）
+	Height      int     `json:"height"`
+	Tiles       int     `json:"tiles"` // 分块计费时的块数
+	Tokens      int     `json:"tokens"`
+	GlyphHeight float64 `json:"glyphHeight"` // 提供商处理后最小文字行高的估计（像素），0 表示未检测到文字
+}
+
+// EstimateTokens 估算一张 width × height 的图片在指定计费方式下的 Token 数
+func EstimateTokens(pricing string, width, height int) TokenEstimate {
+	w, h := providerSize(pricing, width, height)
+	estimate := TokenEstimate{Pricing: pricing, Width: w, Height: h}
+	switch pricing {
+	case PricingOpenAI:
+		estimate.Tiles = ceilDiv(w, 512) * ceilDiv(h, 512)
+		estimate.Tokens = 85 + 170*estimate.Tiles
+	case PricingClaude:
+		estimate.Tokens = (w*h + 749) / 750
+	case PricingGemini:
+		if w <= 384 && h <= 384 {
+			estimate.Tiles = 1
+		} else {
+			estimate.Tiles = ceilDiv(w, 768) * ceilDiv(h, 768)
+		}
+		estimate.Tokens = 258 * estimate.Tiles
+	}
+	return estimate
+}
+
+// providerSize 提供商收到图片后自行缩放得到的尺寸
+func providerSize(pricing string, width, height int) (int, int) {
+	scale := 1.0
+	switch pricing {
+	case PricingOpenAI:
+		// 先缩放到 2048×2048 以内，再把短边缩到 768
+		scale = math.Min(1, 2048/float64(max(width, height)))
+		if short := float64(min(width, height)) * scale; short > 768 {
+			scale *= 768 / short
+		}
+	case PricingClaude:
+		// 长边不超过 1568，且总像素约 1.15MP（约 1600 Token）以内
+		scale = math.Min(1, 1568/float64(max(width, height)))
+		scale = math.Min(scale, math.Sqrt(1_150_000/float64(width*height)))
+	}
+	return max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+}
+
+// PlanScale 在保证最小文字行高不低于 minGlyph 的前提下，选择 Token 最少的缩放比例（0-1）
+// glyphHeight 为原图中最小文字行高，未知（0）时不缩放
+func PlanScale(pricing string, width, height int, glyphHeight, minGlyph float64) float64 {
+	if pricing == "" || glyphHeight <= 0 || minGlyph <= 0 {
+		return 1
+	}
+
+	// 提供商自身的缩放也会让文字变小，需要一并考虑
+	effectiveGlyph := func(scale float64) float64 {
+		w, _ := providerSize(pricing, int(float64(width)*scale), int(float64(height)*scale))
+		return glyphHeight * float64(w) / float64(width)
+	}
+	if effectiveGlyph(1) < minGlyph {
+		return 1 // 原图的文字已经偏小，不再缩小
+	}
+
+	best, bestTokens := 1.0, EstimateTokens(pricing, width, height).Tokens
+	for step := 99; step >= 10; step-- {
+		scale := float64(step) / 100
+		if effectiveGlyph(scale) < minGlyph {
+			break
+		}
+		tokens := EstimateTokens(pricing, int(float64(width)*scale), int(float64(height)*scale)).Tokens
+		if tokens < bestTokens {
+			best, bestTokens = scale, tokens
+		}
+	}
+	return best
+}
+
+// EstimateGlyphHeight 估算图片中较小一档文字的行高（像素）
+// 按行统计水平方向的亮度突变，连续有边缘的行视为一行文字，取行高的 20 分位数
+func EstimateGlyphHeight(img image.Image) float64 {
+	bounds := img.Bounds()
+	const analyzeSize = 1600
+	small := img
+	if bounds.Dx() > analyzeSize {
+		small = imaging.Resize(img, analyzeSize, 0, imaging.Box)
+	}
+	gray := imaging.Grayscale(small)
+	w, h := gray.Bounds().Dx(), gray.Bounds().Dy()
+	if w < 2 || h < 2 {
+		return 0
+	}
+
+	threshold := max(w/200, 2)
+	var runs []int
+	run := 0
+	for y := 0; y <= h; y++ {
+		active := false
+		if y < h {
+			row := gray.Pix[y*gray.Stride:]
+			count := 0
+			for x := 0; x+1 < w; x++ {
+				diff := int(row[x*4]) - int(row[(x+1)*4])
+				if diff > edgeThreshold || diff < -edgeThreshold {
+					count++
+				}
+			}
+			active = count >= threshold
+		}
+		if active {
+			run++
+			continue
+		}
+		if run >= 2 {
+			runs = append(runs, run)
+		}
+		run = 0
+	}
+	if len(runs) == 0 {
+		return 0
+	}
+
+	sort.Ints(runs)
+	glyph := float64(runs[len(runs)/5])
+	return glyph * float64(bounds.Dy()) / float64(h)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,50 @@
+package imageutil
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	cases := []struct {
+		pricing       string
+		width, height int
+		w, h          int // 提供商缩放后的尺寸
+		tiles, tokens int
+	}{
+		{PricingOpenAI, 512, 512, 512, 512, 1, 255},
+		{PricingOpenAI, 1024, 1024, 768, 768, 4, 765},
+		{PricingOpenAI, 2048, 4096, 768, 1536, 6, 1105},
+		{PricingClaude, 1000, 1000, 1000, 1000, 0, 1334},
+		{PricingClaude, 200, 3136, 100, 1568, 0, 210},
+		{PricingGemini, 384, 384, 384, 384, 1, 258},
+		{PricingGemini, 1024, 1024, 1024, 1024, 4, 1032},
+	}
+	for _, c := range cases {
+		got := EstimateTokens(c.pricing, c.width, c.height)
+		if got.Width != c.w || got.Height != c.h || got.Tiles != c.tiles || got.Tokens != c.tokens {
+			t.Errorf("%s %dx%d: %dx%d %d 块 %d Token, 期望 %dx%d %d 块 %d Token",
+				c.pricing, c.width, c.height, got.Width, got.Height, got.Tiles, got.Tokens, c.w, c.h, c.tiles, c.tokens)
+		}
+	}
+}
+
+func TestPlanScale(t *testing.T) {
+	cases := []struct {
+		name          string
+		pricing       string
+		width, height int
+		glyph, min    float64
+		want          float64
+	}{
+		{"未知文字行高", PricingClaude, 1000, 1000, 0, 15, 1},
+		{"未选择计费方式", "", 1000, 1000, 20, 15, 1},
+		// 20 × 0.75 = 15，再缩小文字就低于 15 像素
+		{"缩小到最小行高为止", PricingClaude, 1000, 1000, 20, 15, 0.75},
+		{"原图文字已偏小", PricingClaude, 1000, 1000, 10, 15, 1},
+		// OpenAI 会把 2048×2048 缩到 768×768，文字只剩 7.5 像素
+		{"提供商缩放后文字已偏小", PricingOpenAI, 2048, 2048, 20, 12, 1},
+	}
+	for _, c := range cases {
+		if got := PlanScale(c.pricing, c.width, c.height, c.glyph, c.min); got != c.want {
+			t.Errorf("%s: 缩放 %v, 期望 %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,689 @@
+package imageutil
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// 无损 WebP (VP8L) 编码器
+// 依次做 subtract-green 和预测变换，再用 LZ77 回溯引用和 Huffman 编码，不使用颜色缓存。
+// 截图中大面积纯色和渐变经预测后几乎都是 0 残差，重复的文字靠回溯引用压缩。
+
+const (
+	vp8lMaxDimension  = 1 << 14
+	vp8lMaxCodeLength = 15
+	vp8lNumLiterals   = 256
+	vp8lNumLengths    = 24
+	vp8lNumDistances  = 40
+	vp8lMinMatch      = 3
+	vp8lMaxMatch      = 4096
+	vp8lHashBits      = 16
+	vp8lMaxChain      = 32
+	vp8lMaxDistance   = 1<<20 - 120 // 40 个距离前缀码能表示的最大距离
+	vp8lPredictorBits = 4           // 预测模式按 16x16 分块选择
+)
+
+// VP8L 变换类型（规范 4）
+const (
+	vp8lTransformPredictor     = 0
+	vp8lTransformSubtractGreen = 2
+)
+
+// codeLengthCodeOrder 码长码的写入顺序（规范 3.7.2.1.2）
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebPLossless 将图片编码为无损 WebP
+func EncodeWebPLossless(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return errors.New("webp: 图片尺寸为空")
+	}
+	if width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return errors.New("webp: 图片尺寸超过 16384")
+	}
+
+	// 读取为非预乘的 ARGB，同时做 subtract-green 变换
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			if c.A != 0xff {
+				hasAlpha = true
+			}
+			argb[y*width+x] = uint32(c.A)<<24 | uint32(c.R-c.G)<<16 | uint32(c.G)<<8 | uint32(c.B-c.G)
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8) // VP8L 签名
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3) // 版本号
+
+	// 解码器按相反顺序逆变换：先还原预测，再加回绿色
+	bw.write(1, 1)
+	bw.write(vp8lTransformSubtractGreen, 2)
+
+	residuals, modes, blocksWidth := predict(argb, width, height, vp8lPredictorBits)
+	bw.write(1, 1)
+	bw.write(vp8lTransformPredictor, 2)
+	bw.write(vp8lPredictorBits-2, 3)
+	writeImageStream(bw, modes, blocksWidth, false)
+
+	bw.write(0, 1) // 没有更多变换
+
+	writeImageStream(bw, residuals, width, true)
+	data := bw.flush()
+
+	// RIFF 容器
+	chunkSize := len(data)
+	padding := chunkSize & 1
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+chunkSize+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunkSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding != 0 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// writeImageStream 写入一幅熵编码图像：主图像或变换用到的子图像（子图像没有 meta 前缀码标志位）
+func writeImageStream(bw *bitWriter, argb []uint32, width int, main bool) {
+	bw.write(0, 1) // 不使用颜色缓存
+	if main {
+		bw.write(0, 1) // 不使用 meta 前缀码
+	}
+
+	tokens := lz77(argb, width)
+
+	// 统计各字母表的频率
+	var freqs [5][]int
+	freqs[0] = make([]int, vp8lNumLiterals+vp8lNumLengths)
+	freqs[1] = make([]int, vp8lNumLiterals)
+	freqs[2] = make([]int, vp8lNumLiterals)
+	freqs[3] = make([]int, vp8lNumLiterals)
+	freqs[4] = make([]int, vp8lNumDistances)
+	for _, t := range tokens {
+		if t.length == 0 {
+			freqs[0][(t.argb>>8)&0xff]++
+			freqs[1][(t.argb>>16)&0xff]++
+			freqs[2][t.argb&0xff]++
+			freqs[3][t.argb>>24]++
+			continue
+		}
+		code, _, _ := prefixEncode(t.length)
+		freqs[0][vp8lNumLiterals+code]++
+		code, _, _ = prefixEncode(t.distCode)
+		freqs[4][code]++
+	}
+
+	var codes [5]huffmanCode
+	for i := range freqs {
+		codes[i] = buildHuffmanCode(freqs[i], vp8lMaxCodeLength)
+		writeHuffmanCode(bw, codes[i])
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(bw, int((t.argb>>8)&0xff))
+			codes[1].write(bw, int((t.argb>>16)&0xff))
+			codes[2].write(bw, int(t.argb&0xff))
+			codes[3].write(bw, int(t.argb>>24))
+			continue
+		}
+		code, extraBits, extra := prefixEncode(t.length)
+		codes[0].write(bw, vp8lNumLiterals+code)
+		bw.write(extra, extraBits)
+		code, extraBits, extra = prefixEncode(t.distCode)
+		codes[4].write(bw, code)
+		bw.write(extra, extraBits)
+	}
+}
+
+// predict 预测变换：每个分块选残差最小的预测模式，返回残差图、模式子图像及其宽度
+// 第一行和第一列的预测方式由规范固定，不参与模式选择
+func predict(argb []uint32, width, height int, bits uint) (residuals, modes []uint32, blocksWidth int) {
+	blockSize := 1 << bits
+	blocksWidth = (width + blockSize - 1) >> bits
+	blocksHeight := (height + blockSize - 1) >> bits
+	modes = make([]uint32, blocksWidth*blocksHeight)
+	residuals = make([]uint32, len(argb))
+
+	for by := 0; by < blocksHeight; by++ {
+		for bx := 0; bx < blocksWidth; bx++ {
+			x0, y0 := max(bx*blockSize, 1), max(by*blockSize, 1)
+			x1, y1 := min((bx+1)*blockSize, width), min((by+1)*blockSize, height)
+
+			best, bestCost := 0, -1
+			for mode := 0; mode < vp8lNumPredictors; mode++ {
+				cost := 0
+				for y := y0; y < y1 && (bestCost < 0 || cost < bestCost); y++ {
+					for x := x0; x < x1; x++ {
+						i := y*width + x
+						cost += residualCost(subPixels(argb[i], predictPixel(mode, argb, i, width)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[by*blocksWidth+bx] = 0xff000000 | uint32(best)<<8
+
+			for y := by * blockSize; y < min((by+1)*blockSize, height); y++ {
+				for x := bx * blockSize; x < x1; x++ {
+					i := y*width + x
+					var pred uint32
+					switch {
+					case x == 0 && y == 0:
+						pred = 0xff000000
+					case y == 0:
+						pred = argb[i-1]
+					case x == 0:
+						pred = argb[i-width]
+					default:
+						pred = predictPixel(best, argb, i, width)
+					}
+					residuals[i] = subPixels(argb[i], pred)
+				}
+			}
+		}
+	}
+	return residuals, modes, blocksWidth
+}
+
+const vp8lNumPredictors = 14
+
+// predictPixel 按规范 4.1 的 14 种模式预测像素，i 不在第一行和第一列
+// 最右一列的右上角像素按规范取当前行的第一个像素，正好是线性下标 i-width+1
+func predictPixel(mode int, argb []uint32, i, width int) uint32 {
+	l, t, tl, tr := argb[i-1], argb[i-width], argb[i-width-1], argb[i-width+1]
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return l
+	case 2:
+		return t
+	case 3:
+		return tr
+	case 4:
+		return tl
+	case 5:
+		return average2(average2(l, tr), t)
+	case 6:
+		return average2(l, tl)
+	case 7:
+		return average2(l, t)
+	case 8:
+		return average2(tl, t)
+	case 9:
+		return average2(t, tr)
+	case 10:
+		return average2(average2(l, tl), average2(t, tr))
+	case 11:
+		return selectPredictor(l, t, tl)
+	case 12:
+		return mapChannels(func(c int) int { return channel(l, c) + channel(t, c) - channel(tl, c) })
+	default:
+		a := average2(l, t)
+		return mapChannels(func(c int) int { return channel(a, c) + (channel(a, c)-channel(tl, c))/2 })
+	}
+}
+
+// average2 逐通道取平均（向下取整）
+func average2(a, b uint32) uint32 {
+	return ((a^b)&0xfefefefe)>>1 + a&b
+}
+
+// selectPredictor 在左侧和上方像素中选择与梯度预测值更接近的一个
+func selectPredictor(l, t, tl uint32) uint32 {
+	distL, distT := 0, 0
+	for c := 0; c < 4; c++ {
+		distL += abs(channel(t, c) - channel(tl, c))
+		distT += abs(channel(l, c) - channel(tl, c))
+	}
+	if distL < distT {
+		return l
+	}
+	return t
+}
+
+// mapChannels 逐通道计算并截断到 0-255
+func mapChannels(f func(c int) int) uint32 {
+	var out uint32
+	for c := 0; c < 4; c++ {
+		out |= uint32(min(max(f(c), 0), 255)) << (8 * c)
+	}
+	return out
+}
+
+func channel(argb uint32, c int) int {
+	return int(argb>>(8*c)) & 0xff
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// subPixels 逐通道相减（模 256）
+func subPixels(a, b uint32) uint32 {
+	ag := ((a | 0x00ff00ff) - (b & 0xff00ff00)) & 0xff00ff00
+	rb := ((a | 0xff00ff00) - (b & 0x00ff00ff)) & 0x00ff00ff
+	return ag | rb
+}
+
+// residualCost 残差的代价：各通道按有符号数取绝对值求和
+func residualCost(residual uint32) int {
+	cost := 0
+	for c := 0; c < 4; c++ {
+		cost += abs(int(int8(residual >> (8 * c))))
+	}
+	return cost
+}
+
+// lz77Token 字面像素（length 为 0）或回溯引用
+type lz77Token struct {
+	argb     uint32
+	length   int
+	distCode int
+}
+
+// lz77 贪心查找回溯引用，优先尝试左侧像素和上一行（截图中最常见的重复）
+func lz77(argb []uint32, width int) []lz77Token {
+	n := len(argb)
+	tokens := make([]lz77Token, 0, n/4)
+	head := make([]int32, 1<<vp8lHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, n)
+
+	hashAt := func(i int) uint32 {
+		h := argb[i]*0x1e35a7bd ^ argb[i+1]*0x9e3779b1 ^ argb[i+2]*0x85ebca6b
+		return h >> (32 - vp8lHashBits)
+	}
+	insert := func(i int) {
+		if i+2 < n {
+			h := hashAt(i)
+			prev[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+	matchLen := func(i, j int) int {
+		limit := min(n-i, vp8lMaxMatch)
+		l := 0
+		for l < limit && argb[i+l] == argb[j+l] {
+			l++
+		}
+		return l
+	}
+
+	for i := 0; i < n; {
+		bestLen, bestDist := 0, 0
+		if i+vp8lMinMatch <= n {
+			for _, d := range [2]int{1, width} {
+				if d <= i {
+					if l := matchLen(i, i-d); l > bestLen {
+						bestLen, bestDist = l, d
+					}
+				}
+			}
+			if i+2 < n {
+				for j, chain := int(head[hashAt(i)]), 0; j >= 0 && chain < vp8lMaxChain; j, chain = int(prev[j]), chain+1 {
+					if i-j > vp8lMaxDistance {
+						break
+					}
+					if l := matchLen(i, j); l > bestLen {
+						bestLen, bestDist = l, i-j
+					}
+				}
+			}
+		}
+
+		if bestLen < vp8lMinMatch {
+			tokens = append(tokens, lz77Token{argb: argb[i]})
+			insert(i)
+			i++
+			continue
+		}
+		tokens = append(tokens, lz77Token{length: bestLen, distCode: distanceCode(bestDist, width)})
+		for k := 0; k < bestLen; k++ {
+			insert(i + k)
+		}
+		i += bestLen
+	}
+	return tokens
+}
+
+// distanceCode 把线性距离转换为距离码，上一行和左侧像素使用二维短码
+func distanceCode(dist, width int) int {
+	switch dist {
+	case width:
+		return 1 // (0, 1)
+	case 1:
+		return 2 // (1, 0)
+	}
+	return dist + 120
+}
+
+// prefixEncode 长度和距离的前缀编码（规范 5.2.2），返回前缀码、额外位数和额外位的值
+func prefixEncode(value int) (code int, extraBits uint, extra uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	highest := 31
+	for d>>highest == 0 {
+		highest--
+	}
+	second := (d >> (highest - 1)) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+// bitWriter 低位优先的位写入器
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	nacc uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nacc
+	w.nacc += n
+	for w.nacc >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nacc -= 8
+	}
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.nacc > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nacc = 0, 0
+	}
+	return w.buf
+}
+
+// huffmanCode 规范 Huffman 编码
+type huffmanCode struct {
+	lengths []int
+	codes   []uint32 // 已按位反转，可直接低位优先写入
+	single  int      // 只用到一个符号时为该符号（编码时不占位），否则为 -1
+}
+
+func (h huffmanCode) write(w *bitWriter, symbol int) {
+	if l := h.lengths[symbol]; l > 0 {
+		w.write(h.codes[symbol], uint(l))
+	}
+}
+
+// buildHuffmanCode 根据频率生成码长不超过 maxLength 的规范 Huffman 编码
+func buildHuffmanCode(freqs []int, maxLength int) huffmanCode {
+	code := huffmanCode{single: -1}
+	used := 0
+	for sym, f := range freqs {
+		if f > 0 {
+			used++
+			code.single = sym
+		}
+	}
+	if used != 1 {
+		code.single = -1
+	}
+	code.lengths = huffmanLengths(freqs, maxLength)
+	code.codes = canonicalCodes(code.lengths)
+	return code
+}
+
+// headerLengths 写入码长表时使用的码长
+// 只有一个符号时写入码长 1，解码器会将其视为不占位的编码
+func (h huffmanCode) headerLengths() []int {
+	if h.single < 0 {
+		return h.lengths
+	}
+	lengths := make([]int, len(h.lengths))
+	lengths[h.single] = 1
+	return lengths
+}
+
+// huffmanLengths 计算码长；超过长度上限时把频率减半后重算
+func huffmanLengths(freqs []int, maxLength int) []int {
+	scaled := append([]int(nil), freqs...)
+	for {
+		lengths := make([]int, len(scaled))
+		var nodes []huffNode
+		h := &nodeHeap{nodes: &nodes}
+		for sym, f := range scaled {
+			if f > 0 {
+				nodes = append(nodes, huffNode{freq: f, symbol: sym, left: -1, right: -1})
+				heap.Push(h, len(nodes)-1)
+			}
+		}
+		// 只有一个符号时码长为 0，解码时不消耗位
+		if len(nodes) <= 1 {
+			return lengths
+		}
+		for h.Len() > 1 {
+			a := heap.Pop(h).(int)
+			b := heap.Pop(h).(int)
+			nodes = append(nodes, huffNode{freq: nodes[a].freq + nodes[b].freq, symbol: -1, left: a, right: b})
+			heap.Push(h, len(nodes)-1)
+		}
+
+		tooLong := false
+		var walk func(i, depth int)
+		walk = func(i, depth int) {
+			if nodes[i].symbol >= 0 {
+				lengths[nodes[i].symbol] = depth
+				if depth > maxLength {
+					tooLong = true
+				}
+				return
+			}
+			walk(nodes[i].left, depth+1)
+			walk(nodes[i].right, depth+1)
+		}
+		walk(heap.Pop(h).(int), 0)
+		if !tooLong {
+			return lengths
+		}
+		for i, f := range scaled {
+			if f > 0 {
+				scaled[i] = (f + 1) / 2
+			}
+		}
+	}
+}
+
+type huffNode struct {
+	freq        int
+	symbol      int
+	left, right int
+}
+
+// nodeHeap 按频率排序的节点下标堆
+type nodeHeap struct {
+	items []int
+	nodes *[]huffNode
+}
+
+func (h *nodeHeap) Len() int { return len(h.items) }
+func (h *nodeHeap) Less(i, j int) bool {
+	a, b := (*h.nodes)[h.items[i]], (*h.nodes)[h.items[j]]
+	if a.freq != b.freq {
+		return a.freq < b.freq
+	}
+	return h.items[i] < h.items[j]
+}
+func (h *nodeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *nodeHeap) Push(x any)    { h.items = append(h.items, x.(int)) }
+func (h *nodeHeap) Pop() any {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
+
+// canonicalCodes 由码长生成规范码，并反转为低位优先
+func canonicalCodes(lengths []int) []uint32 {
+	var count [vp8lMaxCodeLength + 1]uint32
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+	var next [vp8lMaxCodeLength + 2]uint32
+	code := uint32(0)
+	for l := 1; l <= vp8lMaxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	codes := make([]uint32, len(lengths))
+	for sym, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		var rev uint32
+		for i := 0; i < l; i++ {
+			rev = rev<<1 | (c>>i)&1
+		}
+		codes[sym] = rev
+	}
+	return codes
+}
+
+// writeHuffmanCode 写入一个前缀码的码长表
+func writeHuffmanCode(w *bitWriter, code huffmanCode) {
+	lengths := code.headerLengths()
+	var symbols []int
+	for sym, l := range lengths {
+		if l > 0 {
+			symbols = append(symbols, sym)
+		}
+	}
+
+	// 没有用到的字母表写一个只含符号 0 的简单码
+	if len(symbols) == 0 {
+		writeSimpleCode(w, []int{0})
+		return
+	}
+	// 最多两个符号且都小于 256 时使用简单码
+	if len(symbols) <= 2 && symbols[len(symbols)-1] < 256 {
+		writeSimpleCode(w, symbols)
+		return
+	}
+
+	// 码长序列做游程编码：16 重复上一个非零码长，17/18 表示连续的 0
+	type rleToken struct {
+		symbol int
+		extra  uint32
+		bits   uint
+	}
+	var rle []rleToken
+	prev := 8
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		i += run
+		if l == 0 {
+			for run >= 11 {
+				n := min(run, 138)
+				rle = append(rle, rleToken{18, uint32(n - 11), 7})
+				run -= n
+			}
+			if run >= 3 {
+				rle = append(rle, rleToken{17, uint32(run - 3), 3})
+				run = 0
+			}
+			for ; run > 0; run-- {
+				rle = append(rle, rleToken{symbol: 0})
+			}
+			continue
+		}
+		if l != prev {
+			rle = append(rle, rleToken{symbol: l})
+			prev = l
+			run--
+		}
+		for run >= 3 {
+			n := min(run, 6)
+			rle = append(rle, rleToken{16, uint32(n - 3), 2})
+			run -= n
+		}
+		for ; run > 0; run-- {
+			rle = append(rle, rleToken{symbol: l})
+		}
+	}
+
+	clFreqs := make([]int, 19)
+	for _, t := range rle {
+		clFreqs[t.symbol]++
+	}
+	clCode := buildHuffmanCode(clFreqs, 7)
+	clLengths := clCode.headerLengths()
+
+	numCodes := 4
+	for i, sym := range codeLengthCodeOrder {
+		if clLengths[sym] > 0 {
+			numCodes = max(numCodes, i+1)
+		}
+	}
+
+	w.write(0, 1) // 普通码
+	w.write(uint32(numCodes-4), 4)
+	for i := 0; i < numCodes; i++ {
+		w.write(uint32(clLengths[codeLengthCodeOrder[i]]), 3)
+	}
+	w.write(0, 1) // max_symbol 使用整个字母表
+	for _, t := range rle {
+		clCode.write(w, t.symbol)
+		if t.bits > 0 {
+			w.write(t.extra, t.bits)
+		}
+	}
+}
+
+// writeSimpleCode 写入 1-2 个符号的简单码
+func writeSimpleCode(w *bitWriter, symbols []int) {
+	w.write(1, 1)
+	w.write(uint32(len(symbols)-1), 1)
+	if symbols[0] < 2 {
+		w.write(0, 1)
+		w.write(uint32(symbols[0]), 1)
+	} else {
+		w.write(1, 1)
+		w.write(uint32(symbols[0]), 8)
+	}
+	if len(symbols) == 2 {
+		w.write(uint32(symbols[1]), 8)
+	}
+}
@@ -0,0 +1,136 @@
+package imageutil
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// roundTrip 编码后用 x/image/webp 解码，逐像素与原图（非预乘）比较
+func roundTrip(t *testing.T, name string, img image.Image) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := EncodeWebPLossless(&buf, img); err != nil {
+		t.Fatalf("%s: 编码失败: %v", name, err)
+	}
+	size := buf.Len()
+	got, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("%s: 解码失败: %v", name, err)
+	}
+
+	bounds := img.Bounds()
+	if got.Bounds().Dx() != bounds.Dx() || got.Bounds().Dy() != bounds.Dy() {
+		t.Fatalf("%s: 尺寸 %v, 期望 %v", name, got.Bounds(), bounds)
+	}
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			want := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+			have := color.NRGBAModel.Convert(got.At(got.Bounds().Min.X+x, got.Bounds().Min.Y+y))
+			if want != have {
+				t.Fatalf("%s: (%d,%d) 为 %v, 期望 %v", name, x, y, have, want)
+			}
+		}
+	}
+	return size
+}
+
+func TestWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// 渐变 + 噪声：各分块会选到不同的预测模式
+	noisy := image.NewNRGBA(image.Rect(0, 0, 53, 37))
+	for y := 0; y < 37; y++ {
+		for x := 0; x < 53; x++ {
+			noisy.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x*5 + rng.Intn(8)),
+				G: uint8(y*7 + rng.Intn(4)),
+				B: uint8((x + y) * 3),
+				A: 255,
+			})
+		}
+	}
+
+	// 半透明像素：预乘的 RGBA 输入要先还原为非预乘再编码
+	translucent := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			translucent.Set(x, y, color.NRGBA{R: 200, G: uint8(x * 12), B: 40, A: uint8(y * 13)})
+		}
+	}
+
+	// 截图式图片：纯色背景加重复的文字块
+	text := image.NewGray(image.Rect(0, 0, 120, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 120; x++ {
+			v := uint8(255)
+			if y%10 < 6 && x%8 < 5 && (x*y)%3 != 0 {
+				v = 20
+			}
+			text.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+
+	// 非零起点的子图
+	sub := noisy.SubImage(image.Rect(7, 3, 40, 30))
+
+	cases := []struct {
+		name string
+		img  image.Image
+	}{
+		{"1x1", image.NewNRGBA(image.Rect(0, 0, 1, 1))},
+		{"单列", noisy.SubImage(image.Rect(5, 0, 6, 37))},
+		{"噪声渐变", noisy},
+		{"半透明", translucent},
+		{"文字", text},
+		{"子图", sub},
+	}
+	for _, c := range cases {
+		roundTrip(t, c.name, c.img)
+	}
+}
+
+func TestWebPPredictorShrinksGradient(t *testing.T) {
+	// 平滑渐变没有可回溯的重复，只有预测变换才能压缩
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 255})
+		}
+	}
+	size := roundTrip(t, "渐变", img)
+	if raw := 256 * 256 * 3; size > raw/20 {
+		t.Errorf("渐变编码为 %d 字节，超过原始数据 %d 字节的 1/20", size, raw)
+	}
+}
+
+func TestPredictPixelModes(t *testing.T) {
+	// 3x2 图片中对 (1,1) 做预测：TL=(0,0) T=(1,0) TR=(2,0) L=(0,1)
+	tl, top, tr, l := uint32(0x10203040), uint32(0x20406080), uint32(0x30507090), uint32(0x40302010)
+	argb := []uint32{tl, top, tr, l, 0, 0}
+	want := []uint32{
+		0xff000000,
+		l,
+		top,
+		tr,
+		tl,
+		average2(average2(l, tr), top),
+		average2(l, tl),
+		average2(l, top),
+		average2(tl, top),
+		average2(top, tr),
+		average2(average2(l, tl), average2(top, tr)),
+		top,        // Select：|T-TL| 之和 160 不小于 |L-TL| 之和 128
+		0x50505050, // L+T-TL 逐通道截断
+		0x4044484c, // avg(L,T) + (avg(L,T)-TL)/2
+	}
+	for mode, w := range want {
+		if got := predictPixel(mode, argb, 4, 3); got != w {
+			t.Errorf("模式 %d: %08x, 期望 %08x", mode, got, w)
+		}
+	}
+}
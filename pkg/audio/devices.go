@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/gen2brain/malgo"
+)
+
+// 设备类型
+const (
+	DeviceKindCapture  = "capture"  // 麦克风等普通输入设备
+	DeviceKindLoopback = "loopback" // 可采集系统声音的设备（虚拟声卡、监视器音源、Windows 输出设备）
+)
+
+// 设备状态（live:audio-device 事件）
+const (
+	DeviceStatusOpened   = "opened"   // 设备已打开
+	DeviceStatusLost     = "lost"     // 设备断开
+	DeviceStatusReopened = "reopened" // 已切换到可用设备
+	DeviceStatusFailed   = "failed"   // 没有可用设备
+)
+
+// 采集的音源
+const (
+	SourceSystem     = "system"     // 系统声音
+	SourceMicrophone = "microphone" // 麦克风
+)
+
+// DeviceInfo 音频设备信息
+type DeviceInfo struct {
+	ID        string `json:"id"` // 设备 ID 的十六进制形式，可写入配置 audioDeviceId
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// DeviceEvent 采集设备状态变化
+type DeviceEvent struct {
+	Source string `json:"source"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+}
+
+// loopbackKeywords 虚拟声卡和监视器音源的名称关键字（按优先级排列）
+var loopbackKeywords = []string{
+	"blackhole",    // BlackHole (推荐)
+	"soundflower",  // Soundflower
+	"loopback",     // Loopback by Rogue Amoeba
+	"virtual",      // 其他虚拟设备
+	"multi-output", // 多输出设备
+	"monitor",      // PulseAudio/PipeWire 监视器音源
+}
+
+// ListDevices 列出可用于采集的音频设备
+// Windows 的输出设备可以通过 WASAPI Loopback 采集，也一并列出
+func ListDevices() ([]DeviceInfo, error) {
+	ctx, err := initContext()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = ctx.Uninit()
+		ctx.Free()
+	}()
+
+	infos, err := enumerateDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]DeviceInfo, 0, len(infos))
+	for _, info := range infos {
+		devices = append(devices, info.DeviceInfo)
+	}
+	return devices, nil
+}
+
+// initContext 初始化音频上下文
+// Linux 的监视器音源只有 PulseAudio 后端（PipeWire 通过 pipewire-pulse 兼容）能枚举到
+func initContext() (*malgo.AllocatedContext, error) {
+	var backends []malgo.Backend
+	if runtime.GOOS == "linux" {
+		backends = []malgo.Backend{malgo.BackendPulseaudio, malgo.BackendAlsa}
+	}
+	return malgo.InitContext(backends, malgo.ContextConfig{}, nil)
+}
+
+// enumeratedDevice 带原始 ID 的设备信息
+type enumeratedDevice struct {
+	DeviceInfo
+	id       malgo.DeviceID
+	playback bool // Windows 的输出设备，需要通过 WASAPI Loopback 采集
+}
+
+// enumerateDevices 枚举输入设备，Windows 额外枚举输出设备作为 Loopback 设备
+func enumerateDevices(ctx *malgo.AllocatedContext) ([]enumeratedDevice, error) {
+	captures, err := ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]enumeratedDevice, 0, len(captures))
+	for _, info := range captures {
+		kind := DeviceKindCapture
+		if isLoopbackName(info.Name()) {
+			kind = DeviceKindLoopback
+		}
+		devices = append(devices, newEnumeratedDevice(info, kind))
+	}
+
+	if runtime.GOOS == "windows" {
+		playbacks, err := ctx.Devices(malgo.Playback)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range playbacks {
+			device := newEnumeratedDevice(info, DeviceKindLoopback)
+			device.playback = true
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
+func newEnumeratedDevice(info malgo.DeviceInfo, kind string) enumeratedDevice {
+	return enumeratedDevice{
+		DeviceInfo: DeviceInfo{
+			ID:        info.ID.String(),
+			Name:      info.Name(),
+			Kind:      kind,
+			IsDefault: info.IsDefault != 0,
+		},
+		id: info.ID,
+	}
+}
+
+// isLoopbackName 设备名称是否像虚拟声卡或监视器音源
+func isLoopbackName(name string) bool {
+	name = strings.ToLower(name)
+	for _, keyword := range loopbackKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
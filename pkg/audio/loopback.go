@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gen2brain/malgo"
@@ -14,18 +15,21 @@ import (
 
 const (
 	PacketDurationMs = 30                                                    // 每个数据包的时长（毫秒）
-	SampleRate       = 16000                                                 // 采样率 16kHz
+	SampleRate       = 16000                                                 // 默认输出采样率 16kHz（可通过 SetSampleRate 修改）
 	BytesPerSample   = 2                                                     // S16 格式，每个样本 2 字节
 	PacketSize       = SampleRate * BytesPerSample * PacketDurationMs / 1000 // 每包字节数
 	ChannelCapacity  = 100                                                   // channel 容量（可存储的数据包数量）
 	RingBufferSize   = PacketSize * 200                                      // 环形缓冲区大小（可存储 200 个数据包，约 8 秒）
+	DeviceCheckDelay = 2 * time.Second                                       // 检查设备是否拔出的间隔
 )
 
 var (
 	ErrNoLoopbackDevice = errors.New("未找到可用的系统音频捕获设备，macOS 需要安装 BlackHole 或类似的虚拟音频驱动")
+	ErrNoMonitorSource  = errors.New("未找到 PulseAudio/PipeWire 的监视器 (Monitor) 音源，请确认音频服务正在运行")
 )
 
 // LoopbackCapture 扬声器音频采集（使用环形缓冲区 + channel）
+// 设备按原生采样率和声道数采集，写入环形缓冲区前转换为单声道、Live 服务需要的采样率
 type LoopbackCapture struct {
 	ctx     *malgo.AllocatedContext
 	device  *malgo.Device
@@ -42,36 +46,106 @@ type LoopbackCapture struct {
 	stopChan chan struct{}
 	wg       sync.WaitGroup
 
-	// macOS 虚拟音频设备 ID（BlackHole 等）
+	// macOS 虚拟音频设备（BlackHole 等）或 Linux 监视器音源的 ID
 	loopbackDeviceID *malgo.DeviceID
+
+	// 采集默认麦克风而不是系统声音
+	microphone bool
+
+	// 输出采样率和对应的每包字节数
+	sampleRate int
+	packetSize int
+
+	// 配置中指定的设备 ID（为空时自动选择），设备拔出后回退到自动选择，重新插入后切回
+	preferredID string
+
+	// 设备意外停止（如被拔出），由 watcher 重新打开
+	deviceLost atomic.Bool
+
+	// 设备状态变化回调
+	onDeviceChange func(DeviceEvent)
+
+	// 输出数据包的音量和丢包统计
+	meter levelMeter
 }
 
 // NewLoopbackCapture 创建 Loopback 采集器
 func NewLoopbackCapture(onData func([]byte)) (*LoopbackCapture, error) {
-	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	return newCapture(false, "")
+}
+
+// NewLoopbackCaptureForDevice 创建采集指定设备的 Loopback 采集器，deviceID 来自 ListDevices，为空时自动选择
+func NewLoopbackCaptureForDevice(deviceID string) (*LoopbackCapture, error) {
+	return newCapture(false, deviceID)
+}
+
+// NewMicrophoneCapture 创建默认麦克风的采集器，输出格式与 Loopback 相同
+func NewMicrophoneCapture() (*LoopbackCapture, error) {
+	return newCapture(true, "")
+}
+
+func newCapture(microphone bool, deviceID string) (*LoopbackCapture, error) {
+	ctx, err := initContext()
 	if err != nil {
 		return nil, err
 	}
 
 	capture := &LoopbackCapture{
-		ctx:        ctx,
-		ringBuffer: common.NewRingBuffer(RingBufferSize),
-		audioChan:  make(chan []byte, ChannelCapacity),
-		stopChan:   make(chan struct{}),
-	}
-
-	// macOS 需要查找虚拟音频设备
-	if runtime.GOOS == "darwin" {
-		deviceID, err := capture.findLoopbackDevice()
-		if err != nil {
-			logger.Printf("警告: %v", err)
-			// 不返回错误，允许创建但 Start 时会失败
-		} else {
-			capture.loopbackDeviceID = deviceID
+		ctx:         ctx,
+		ringBuffer:  common.NewRingBuffer(RingBufferSize),
+		audioChan:   make(chan []byte, ChannelCapacity),
+		stopChan:    make(chan struct{}),
+		microphone:  microphone,
+		preferredID: deviceID,
+		sampleRate:  SampleRate,
+		packetSize:  PacketSize,
+	}
+	capture.loopbackDeviceID = capture.resolveDevice()
+	return capture, nil
+}
+
+// resolveDevice 选择要采集的设备：优先使用配置指定的设备，否则自动查找
+// 返回 nil 表示使用系统默认设备（Windows Loopback、麦克风），macOS/Linux 的系统声音则会在 Start 时失败
+func (c *LoopbackCapture) resolveDevice() *malgo.DeviceID {
+	if c.preferredID != "" {
+		if id, ok := c.findDeviceByID(c.preferredID); ok {
+			return id
 		}
+		logger.Printf("警告: 未找到配置的音频设备 %s，改为自动选择", c.preferredID)
+	}
+	if c.microphone {
+		return nil
 	}
 
-	return capture, nil
+	// macOS 需要查找虚拟音频设备，Linux 需要查找监视器音源
+	var deviceID *malgo.DeviceID
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		deviceID, err = c.findLoopbackDevice()
+	case "linux":
+		deviceID, err = c.findMonitorSource()
+	}
+	if err != nil {
+		logger.Printf("警告: %v", err)
+		// 不返回错误，允许创建但 Start 时会失败
+	}
+	return deviceID
+}
+
+// findDeviceByID 按 ListDevices 返回的 ID 查找当前可用的设备
+func (c *LoopbackCapture) findDeviceByID(id string) (*malgo.DeviceID, bool) {
+	devices, err := enumerateDevices(c.ctx)
+	if err != nil {
+		return nil, false
+	}
+	for _, device := range devices {
+		if device.ID == id {
+			deviceID := device.id
+			return &deviceID, true
+		}
+	}
+	return nil, false
 }
 
 // findLoopbackDevice 查找 macOS 上的虚拟音频设备（如 BlackHole）
@@ -88,14 +162,6 @@ func (c *LoopbackCapture) findLoopbackDevice() (*malgo.DeviceID, error) {
 	}
 
 	// 按优先级查找虚拟音频设备
-	loopbackKeywords := []string{
-		"blackhole",    // BlackHole (推荐)
-		"soundflower",  // Soundflower
-		"loopback",     // Loopback by Rogue Amoeba
-		"virtual",      // 其他虚拟设备
-		"multi-output", // 多输出设备
-	}
-
 	for _, keyword := range loopbackKeywords {
 		for _, info := range infos {
 			deviceName := strings.ToLower(info.Name())
@@ -110,6 +176,51 @@ func (c *LoopbackCapture) findLoopbackDevice() (*malgo.DeviceID, error) {
 	return nil, ErrNoLoopbackDevice
 }
 
+// findMonitorSource 查找 Linux 上默认输出设备的监视器音源（"Monitor of ..."）
+func (c *LoopbackCapture) findMonitorSource() (*malgo.DeviceID, error) {
+	infos, err := c.ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Printf("可用的捕获设备列表 (%d 个):", len(infos))
+	for i, info := range infos {
+		logger.Printf("  [%d] %s", i, info.Name())
+	}
+
+	// 优先使用默认输出设备对应的监视器
+	var defaultSink string
+	if playbacks, err := c.ctx.Devices(malgo.Playback); err == nil {
+		for _, info := range playbacks {
+			if info.IsDefault != 0 {
+				defaultSink = strings.ToLower(info.Name())
+				break
+			}
+		}
+	}
+
+	var fallback *malgo.DeviceID
+	for _, info := range infos {
+		deviceName := strings.ToLower(info.Name())
+		if !strings.Contains(deviceName, "monitor") {
+			continue
+		}
+		id := info.ID
+		if defaultSink != "" && strings.Contains(deviceName, defaultSink) {
+			logger.Printf("找到默认输出设备的监视器音源: %s", info.Name())
+			return &id, nil
+		}
+		if fallback == nil {
+			logger.Printf("找到监视器音源: %s", info.Name())
+			fallback = &id
+		}
+	}
+	if fallback != nil {
+		return fallback, nil
+	}
+	return nil, ErrNoMonitorSource
+}
+
 // Start 开始采集扬声器输出
 func (c *LoopbackCapture) Start() error {
 	c.mu.Lock()
@@ -119,54 +230,222 @@ func (c *LoopbackCapture) Start() error {
 		return nil
 	}
 
+	device, err := c.openDevice()
+	if err != nil {
+		return err
+	}
+
+	c.device = device
+	c.running = true
+	c.stopChan = make(chan struct{})
+	c.meter.reset()
+
+	// 启动消费者协程：从环形缓冲区读取固定大小数据包并发送到 channel
+	c.wg.Add(2)
+	go c.packetizer()
+	go c.watcher()
+
+	logger.Printf("%s 采集已启动（环形缓冲区模式）", c.name())
+	c.notifyDevice(DeviceStatusOpened)
+	return nil
+}
+
+// openDevice 按当前选择的设备创建并启动采集设备
+func (c *LoopbackCapture) openDevice() (*malgo.Device, error) {
 	var deviceConfig malgo.DeviceConfig
 
-	if runtime.GOOS == "darwin" {
+	switch {
+	case c.microphone:
+		// 麦克风：使用系统默认输入设备
+		deviceConfig = malgo.DefaultDeviceConfig(malgo.Capture)
+		if c.loopbackDeviceID != nil {
+			deviceConfig.Capture.DeviceID = c.loopbackDeviceID.Pointer()
+		}
+		logger.Println("使用麦克风进行音频捕获")
+	case runtime.GOOS == "darwin":
 		// macOS: 使用虚拟音频设备作为捕获输入
 		if c.loopbackDeviceID == nil {
-			return ErrNoLoopbackDevice
+			return nil, ErrNoLoopbackDevice
 		}
 		deviceConfig = malgo.DefaultDeviceConfig(malgo.Capture)
 		deviceConfig.Capture.DeviceID = c.loopbackDeviceID.Pointer()
 		logger.Println("macOS: 使用虚拟音频设备进行系统音频捕获")
-	} else {
-		// Windows: 使用 WASAPI Loopback
+	case runtime.GOOS == "linux":
+		// Linux: 使用 PulseAudio/PipeWire 的监视器音源作为捕获输入
+		if c.loopbackDeviceID == nil {
+			return nil, ErrNoMonitorSource
+		}
+		deviceConfig = malgo.DefaultDeviceConfig(malgo.Capture)
+		deviceConfig.Capture.DeviceID = c.loopbackDeviceID.Pointer()
+		logger.Println("Linux: 使用监视器音源进行系统音频捕获")
+	default:
+		// Windows: 输出设备使用 WASAPI Loopback 采集，指定的输入设备（如虚拟声卡）直接采集
+		if c.loopbackDeviceID != nil && !c.isPlaybackDevice(*c.loopbackDeviceID) {
+			deviceConfig = malgo.DefaultDeviceConfig(malgo.Capture)
+			deviceConfig.Capture.DeviceID = c.loopbackDeviceID.Pointer()
+			logger.Println("Windows: 使用输入设备进行系统音频捕获")
+			break
+		}
 		deviceConfig = malgo.DefaultDeviceConfig(malgo.Loopback)
+		if c.loopbackDeviceID != nil {
+			deviceConfig.Capture.DeviceID = c.loopbackDeviceID.Pointer()
+		}
 		logger.Println("Windows: 使用 WASAPI Loopback 进行系统音频捕获")
 	}
 
+	// 采样率和声道数为 0 表示使用设备原生格式，部分 WASAPI 和虚拟设备不支持强制指定
 	deviceConfig.Capture.Format = malgo.FormatS16
-	deviceConfig.Capture.Channels = 1
-	deviceConfig.SampleRate = SampleRate
+	deviceConfig.Capture.Channels = 0
+	deviceConfig.SampleRate = 0
 
-	// 数据回调 - 直接写入环形缓冲区
+	// 数据回调 - 转换格式后写入环形缓冲区（converter 在设备启动前创建）
+	var converter *Converter
 	onRecv := func(_, pInput []byte, frameCount uint32) {
-		if len(pInput) > 0 {
-			_, _ = c.ringBuffer.Write(pInput)
+		if len(pInput) > 0 && converter != nil {
+			_, _ = c.ringBuffer.Write(converter.Convert(pInput))
 		}
 	}
 
-	callbacks := malgo.DeviceCallbacks{Data: onRecv}
+	// 停止回调 - 设备被拔出等意外停止时标记，由 watcher 重新打开
+	onStop := func() {
+		c.deviceLost.Store(true)
+	}
+
+	callbacks := malgo.DeviceCallbacks{Data: onRecv, Stop: onStop}
 
 	device, err := malgo.InitDevice(c.ctx.Context, deviceConfig, callbacks)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	native := Format{SampleRate: int(device.SampleRate()), Channels: int(device.CaptureChannels())}
+	converter = NewConverter(native, c.sampleRate)
+	logger.Printf("%s 设备原生格式: %d Hz, %d 声道，输出 %d Hz 单声道", c.name(), native.SampleRate, native.Channels, c.sampleRate)
+
 	if err := device.Start(); err != nil {
 		device.Uninit()
-		return err
+		return nil, err
 	}
+	c.deviceLost.Store(false)
+	return device, nil
+}
 
+// watcher 定期检查设备是否被拔出，拔出后重新打开可用设备
+// 配置指定的设备重新插入时切回该设备
+func (c *LoopbackCapture) watcher() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(DeviceCheckDelay)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+
+		case <-ticker.C:
+			lost := c.deviceLost.Load() || (c.loopbackDeviceID != nil && !c.deviceAvailable(*c.loopbackDeviceID))
+			if !lost && !c.preferredReturned() {
+				continue
+			}
+			// Stop 持有锁时说明正在停止，跳过本次检查
+			if !c.mu.TryLock() {
+				continue
+			}
+			if c.running {
+				c.reopen(lost)
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// deviceAvailable 设备是否仍然存在
+func (c *LoopbackCapture) deviceAvailable(id malgo.DeviceID) bool {
+	_, ok := c.findDeviceByID(id.String())
+	return ok
+}
+
+// isPlaybackDevice 设备是否为输出设备，找不到时按输出设备处理
+func (c *LoopbackCapture) isPlaybackDevice(id malgo.DeviceID) bool {
+	devices, err := enumerateDevices(c.ctx)
+	if err != nil {
+		return true
+	}
+	for _, device := range devices {
+		if device.id == id {
+			return device.playback
+		}
+	}
+	return true
+}
+
+// preferredReturned 当前未使用配置指定的设备，但该设备已重新插入
+func (c *LoopbackCapture) preferredReturned() bool {
+	if c.preferredID == "" || (c.loopbackDeviceID != nil && c.loopbackDeviceID.String() == c.preferredID) {
+		return false
+	}
+	_, ok := c.findDeviceByID(c.preferredID)
+	return ok
+}
+
+// reopen 关闭当前设备并重新选择、打开设备（调用方持有 c.mu）
+func (c *LoopbackCapture) reopen(lost bool) {
+	if lost {
+		logger.Printf("%s 采集设备已断开，尝试重新打开", c.name())
+		c.notifyDevice(DeviceStatusLost)
+	}
+	if c.device != nil {
+		c.device.Uninit()
+		c.device = nil
+	}
+
+	c.loopbackDeviceID = c.resolveDevice()
+	device, err := c.openDevice()
+	if err != nil {
+		// 保持 deviceLost，下次检查时重试
+		logger.Printf("%s 采集设备重新打开失败: %v", c.name(), err)
+		c.deviceLost.Store(true)
+		c.notifyDevice(DeviceStatusFailed)
+		return
+	}
 	c.device = device
-	c.running = true
+	logger.Printf("%s 采集已切换到: %s", c.name(), c.deviceName())
+	c.notifyDevice(DeviceStatusReopened)
+}
 
-	// 启动消费者协程：从环形缓冲区读取固定大小数据包并发送到 channel
-	c.wg.Add(1)
-	go c.packetizer()
+// SetSampleRate 设置输出采样率（如 Gemini 16kHz、OpenAI Realtime 24kHz），需在 Start 前调用
+func (c *LoopbackCapture) SetSampleRate(rate int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running || rate <= 0 || rate == c.sampleRate {
+		return
+	}
+	c.sampleRate = rate
+	c.packetSize = rate * BytesPerSample * PacketDurationMs / 1000
+	c.ringBuffer = common.NewRingBuffer(c.packetSize * 200)
+}
 
-	logger.Println("Loopback 采集已启动（环形缓冲区模式）")
-	return nil
+// SetDeviceListener 设置设备状态变化回调（打开、断开、切换）
+func (c *LoopbackCapture) SetDeviceListener(listener func(DeviceEvent)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDeviceChange = listener
+}
+
+// notifyDevice 通知设备状态变化（调用方持有 c.mu）
+func (c *LoopbackCapture) notifyDevice(status string) {
+	if c.onDeviceChange == nil {
+		return
+	}
+	event := DeviceEvent{Source: SourceSystem, Status: status, Name: c.deviceName()}
+	if c.microphone {
+		event.Source = SourceMicrophone
+	}
+	if c.loopbackDeviceID != nil {
+		event.ID = c.loopbackDeviceID.String()
+	}
+	c.onDeviceChange(event)
 }
 
 // packetizer 从环形缓冲区读取固定大小的数据包并发送到 channel
@@ -176,7 +455,8 @@ func (c *LoopbackCapture) packetizer() {
 	ticker := time.NewTicker(time.Duration(PacketDurationMs) * time.Millisecond)
 	defer ticker.Stop()
 
-	packetBuffer := make([]byte, PacketSize)
+	packetSize := c.packetSize
+	packetBuffer := make([]byte, packetSize)
 
 	for {
 		select {
@@ -196,16 +476,18 @@ func (c *LoopbackCapture) packetizer() {
 				continue
 			}
 
-			if n == PacketSize {
+			if n == packetSize {
 				// 创建副本发送到 channel
-				packet := make([]byte, PacketSize)
+				packet := make([]byte, packetSize)
 				copy(packet, packetBuffer)
 
 				select {
 				case c.audioChan <- packet:
 					// 成功发送
+					c.meter.add(packet)
 				default:
 					// channel 满了，丢弃此包
+					c.meter.drop()
 				}
 			}
 		}
@@ -226,6 +508,10 @@ func (c *LoopbackCapture) Stop() {
 		return
 	}
 
+	// 停止分包器和设备检查协程（先于设备停止，避免停止回调被当作设备断开）
+	close(c.stopChan)
+	c.wg.Wait()
+
 	// 停止设备
 	if c.device != nil {
 		c.device.Stop()
@@ -233,10 +519,6 @@ func (c *LoopbackCapture) Stop() {
 		c.device = nil
 	}
 
-	// 停止分包器协程
-	close(c.stopChan)
-	c.wg.Wait()
-
 	// 重置环形缓冲区
 	c.ringBuffer.Reset()
 
@@ -246,7 +528,15 @@ func (c *LoopbackCapture) Stop() {
 	}
 
 	c.running = false
-	logger.Println("Loopback 采集已停止")
+	logger.Printf("%s 采集已停止", c.name())
+}
+
+// name 采集器名称（用于日志）
+func (c *LoopbackCapture) name() string {
+	if c.microphone {
+		return "麦克风"
+	}
+	return "Loopback"
 }
 
 // Close 释放资源
@@ -275,30 +565,59 @@ func (c *LoopbackCapture) GetBufferStatus() (bufferSize int, channelSize int) {
 	return c.ringBuffer.Len(), len(c.audioChan)
 }
 
+// Stats 获取采集健康状况，音量和包数为自上次调用以来的统计
+func (c *LoopbackCapture) Stats() []CaptureStats {
+	c.mu.Lock()
+	ringBuffer, packetSize := c.ringBuffer, c.packetSize
+	c.mu.Unlock()
+
+	stats := c.meter.take(SourceSystem)
+	if c.microphone {
+		stats.Source = SourceMicrophone
+	}
+	stats.Overruns = ringBuffer.Overruns()
+	stats.BufferedMs = ringBuffer.Len() * PacketDurationMs / packetSize
+	stats.QueuedPackets = len(c.audioChan)
+	return []CaptureStats{stats}
+}
+
 // HasLoopbackSupport 检查是否支持系统音频捕获
 func (c *LoopbackCapture) HasLoopbackSupport() bool {
 	if runtime.GOOS == "windows" {
 		return true // Windows 原生支持
 	}
-	return c.loopbackDeviceID != nil // macOS 需要虚拟音频设备
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loopbackDeviceID != nil // macOS 需要虚拟音频设备，Linux 需要监视器音源
 }
 
 // GetLoopbackDeviceName 获取当前使用的 loopback 设备名称
 func (c *LoopbackCapture) GetLoopbackDeviceName() string {
-	if runtime.GOOS == "windows" {
-		return "WASAPI Loopback"
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deviceName()
+}
+
+// deviceName 当前设备名称（调用方持有 c.mu）
+func (c *LoopbackCapture) deviceName() string {
 	if c.loopbackDeviceID == nil {
+		switch {
+		case c.microphone:
+			return "默认麦克风"
+		case runtime.GOOS == "windows":
+			return "WASAPI Loopback"
+		}
 		return ""
 	}
 	// 重新获取设备信息
-	infos, err := c.ctx.Devices(malgo.Capture)
+	devices, err := enumerateDevices(c.ctx)
 	if err != nil {
 		return "Unknown"
 	}
-	for _, info := range infos {
-		if info.ID == *c.loopbackDeviceID {
-			return info.Name()
+	id := c.loopbackDeviceID.String()
+	for _, device := range devices {
+		if device.ID == id {
+			return device.Name
 		}
 	}
 	return "Unknown"
@@ -0,0 +1,309 @@
+package audio
+
+import (
+	"Q-Solver/pkg/logger"
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+// 说话人标签
+const (
+	SpeakerSelf  = "self"  // 麦克风（自己）
+	SpeakerOther = "other" // 系统声音（对方）
+)
+
+const (
+	levelHistory   = 10 * time.Second // 保留多久的音量记录
+	silenceLevel   = 0.01             // RMS 低于该值视为静音
+	maxBacklog     = 2                // 麦克风包积压超过该包数时（系统声音暂时没有数据）单独输出，避免延迟累积
+	defaultMicGain = 1.0
+)
+
+// Capture 音频采集器，Live 会话从 GetAudioChannel 读取固定大小的 S16 数据包
+type Capture interface {
+	Start() error
+	Close()
+	GetAudioChannel() <-chan []byte
+	// SetDeviceListener 设置设备状态变化回调，需在 Start 前调用
+	SetDeviceListener(listener func(DeviceEvent))
+	// SetSampleRate 设置输出采样率，需在 Start 前调用
+	SetSampleRate(rate int)
+	// Stats 各路音频的健康状况，音量和包数为自上次调用以来的统计
+	Stats() []CaptureStats
+}
+
+// SpeakerDetector 能根据近期双方音量判断说话人的采集器
+type SpeakerDetector interface {
+	// Speaker 最近 window 时间内声音较大的一方，双方都静音时返回空
+	Speaker(window time.Duration) string
+}
+
+// levelSample 一个数据包时长内双方的音量
+type levelSample struct {
+	at    time.Time
+	self  float64
+	other float64
+}
+
+// DualCapture 同时采集系统声音（对方）和麦克风（自己），混音后作为一路输出
+// 混音时记录双方音量，转写结果可以据此标注说话人
+type DualCapture struct {
+	system  *LoopbackCapture
+	mic     *LoopbackCapture
+	micGain float64
+
+	audioChan chan []byte
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	running   bool
+	mu        sync.Mutex
+
+	levelMu sync.Mutex
+	levels  []levelSample
+
+	// 混音输出的音量和丢包统计
+	meter levelMeter
+}
+
+// NewDualCapture 创建系统声音 + 麦克风的双路采集器
+// deviceID 为系统声音的采集设备（为空时自动选择），micGain 为麦克风音量倍数（0 使用默认值）
+func NewDualCapture(deviceID string, micGain float64) (*DualCapture, error) {
+	system, err := NewLoopbackCaptureForDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	mic, err := NewMicrophoneCapture()
+	if err != nil {
+		system.Close()
+		return nil, err
+	}
+	if micGain <= 0 {
+		micGain = defaultMicGain
+	}
+	return &DualCapture{
+		system:    system,
+		mic:       mic,
+		micGain:   micGain,
+		audioChan: make(chan []byte, ChannelCapacity),
+		stopChan:  make(chan struct{}),
+	}, nil
+}
+
+// Start 启动两路采集，麦克风启动失败时只采集系统声音
+func (d *DualCapture) Start() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.running {
+		return nil
+	}
+	d.stopChan = make(chan struct{})
+	if err := d.system.Start(); err != nil {
+		return err
+	}
+	if err := d.mic.Start(); err != nil {
+		logger.Printf("麦克风采集启动失败，仅采集系统声音: %v", err)
+		d.mic.Close()
+		d.mic = nil
+	}
+
+	d.running = true
+	d.meter.reset()
+	d.wg.Add(1)
+	go d.mixer()
+	return nil
+}
+
+// mixer 以系统声音的数据包为节拍混音，两路采集各自的时钟抖动不会造成丢包或插入静音
+func (d *DualCapture) mixer() {
+	defer d.wg.Done()
+
+	var micChan <-chan []byte
+	if d.mic != nil {
+		micChan = d.mic.GetAudioChannel()
+	}
+	mixLoop(d.system.GetAudioChannel(), micChan, d.stopChan, d.output)
+	logger.Println("混音器已停止")
+}
+
+// mixLoop 每收到一个系统声音包就与最早排队的麦克风包混音，没有排队的麦克风包时按静音处理
+// 部分平台的回环设备在系统没有声音输出时不产生数据，此时麦克风包积压超过 maxBacklog 后单独输出
+func mixLoop(systemChan, micChan <-chan []byte, stop <-chan struct{}, output func(other, self []byte)) {
+	var pendingMic [][]byte
+	for {
+		select {
+		case <-stop:
+			return
+
+		case other, ok := <-systemChan:
+			if !ok {
+				return
+			}
+			var self []byte
+			if len(pendingMic) > 0 {
+				self, pendingMic = pendingMic[0], pendingMic[1:]
+			}
+			output(other, self)
+
+		case self, ok := <-micChan:
+			if !ok {
+				micChan = nil
+				continue
+			}
+			pendingMic = append(pendingMic, self)
+			if len(pendingMic) > maxBacklog {
+				output(nil, pendingMic[0])
+				pendingMic = pendingMic[1:]
+			}
+		}
+	}
+}
+
+// output 记录双方音量，混音后发送
+func (d *DualCapture) output(other, self []byte) {
+	d.recordLevel(rms(self)*d.micGain, rms(other))
+
+	mixed := mixPackets(other, self, d.micGain)
+	select {
+	case d.audioChan <- mixed:
+		d.meter.add(mixed)
+	default:
+		// channel 满了，丢弃此包
+		d.meter.drop()
+	}
+}
+
+// recordLevel 记录双方音量并丢弃过期记录
+func (d *DualCapture) recordLevel(self, other float64) {
+	now := time.Now()
+	d.levelMu.Lock()
+	defer d.levelMu.Unlock()
+
+	d.levels = append(d.levels, levelSample{at: now, self: self, other: other})
+	expired := 0
+	for expired < len(d.levels) && now.Sub(d.levels[expired].at) > levelHistory {
+		expired++
+	}
+	d.levels = d.levels[expired:]
+}
+
+// Speaker 比较最近 window 时间内双方的平均音量
+// 这是根据音量的推测而非真正的说话人分离：转写到达时回看一段时间，哪一路声音大就认为是谁在说话，
+// 双方同时说话、外放被麦克风收进去或转写延迟超过 window 时都可能标错
+func (d *DualCapture) Speaker(window time.Duration) string {
+	since := time.Now().Add(-window)
+	d.levelMu.Lock()
+	defer d.levelMu.Unlock()
+
+	var self, other float64
+	count := 0
+	for _, sample := range d.levels {
+		if sample.at.Before(since) {
+			continue
+		}
+		self += sample.self
+		other += sample.other
+		count++
+	}
+	if count == 0 || (self/float64(count) < silenceLevel && other/float64(count) < silenceLevel) {
+		return ""
+	}
+	if self > other {
+		return SpeakerSelf
+	}
+	return SpeakerOther
+}
+
+// SetDeviceListener 设置两路设备的状态变化回调
+func (d *DualCapture) SetDeviceListener(listener func(DeviceEvent)) {
+	d.system.SetDeviceListener(listener)
+	d.mic.SetDeviceListener(listener)
+}
+
+// SetSampleRate 设置两路的输出采样率
+func (d *DualCapture) SetSampleRate(rate int) {
+	d.system.SetSampleRate(rate)
+	d.mic.SetSampleRate(rate)
+}
+
+// Stats 混音输出和两路输入的健康状况
+func (d *DualCapture) Stats() []CaptureStats {
+	mixed := d.meter.take(SourceMixed)
+	mixed.QueuedPackets = len(d.audioChan)
+
+	stats := append([]CaptureStats{mixed}, d.system.Stats()...)
+	d.mu.Lock()
+	mic := d.mic
+	d.mu.Unlock()
+	if mic != nil {
+		stats = append(stats, mic.Stats()...)
+	}
+	return stats
+}
+
+// GetAudioChannel 获取混音后的音频数据 channel
+func (d *DualCapture) GetAudioChannel() <-chan []byte {
+	return d.audioChan
+}
+
+// Stop 停止采集
+func (d *DualCapture) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.running {
+		return
+	}
+	close(d.stopChan)
+	d.wg.Wait()
+
+	d.system.Stop()
+	if d.mic != nil {
+		d.mic.Stop()
+	}
+	d.running = false
+}
+
+// Close 释放资源
+func (d *DualCapture) Close() {
+	d.Stop()
+	close(d.audioChan)
+	d.system.Close()
+	if d.mic != nil {
+		d.mic.Close()
+	}
+}
+
+// mixPackets 按样本相加两路 S16 音频并截断到有效范围，任一路可以为空
+func mixPackets(other, self []byte, micGain float64) []byte {
+	size := max(len(other), len(self))
+	mixed := make([]byte, size)
+	for i := 0; i+1 < size; i += BytesPerSample {
+		var sum float64
+		if i+1 < len(other) {
+			sum += float64(int16(binary.LittleEndian.Uint16(other[i:])))
+		}
+		if i+1 < len(self) {
+			sum += float64(int16(binary.LittleEndian.Uint16(self[i:]))) * micGain
+		}
+		sum = math.Max(math.MinInt16, math.Min(math.MaxInt16, sum))
+		binary.LittleEndian.PutUint16(mixed[i:], uint16(int16(sum)))
+	}
+	return mixed
+}
+
+// rms 数据包的均方根音量（0-1）
+func rms(packet []byte) float64 {
+	samples := len(packet) / BytesPerSample
+	if samples == 0 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i+1 < len(packet); i += BytesPerSample {
+		v := float64(int16(binary.LittleEndian.Uint16(packet[i:]))) / math.MaxInt16
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(samples))
+}
@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+// runMixLoop 按 sends 的顺序逐个发送数据包（"s1" 为系统声音，"m1" 为麦克风），返回每次输出的 (系统, 麦克风) 包名
+func runMixLoop(t *testing.T, sends []string) [][2]string {
+	t.Helper()
+	systemChan := make(chan []byte)
+	micChan := make(chan []byte)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	var outputs [][2]string
+	go func() {
+		defer close(done)
+		mixLoop(systemChan, micChan, stop, func(other, self []byte) {
+			outputs = append(outputs, [2]string{string(other), string(self)})
+		})
+	}()
+
+	for _, name := range sends {
+		if name[0] == 's' {
+			systemChan <- []byte(name)
+		} else {
+			micChan <- []byte(name)
+		}
+	}
+	close(systemChan)
+	<-done
+	return outputs
+}
+
+func TestMixLoopPairsQueuedMicPackets(t *testing.T) {
+	// 麦克风先到两包、之后晚到一包：系统声音每来一包输出一次，麦克风包按顺序补上，不丢包也不重复
+	got := runMixLoop(t, []string{"m1", "m2", "s1", "s2", "s3", "m3", "s4", "s5"})
+	want := [][2]string{{"s1", "m1"}, {"s2", "m2"}, {"s3", ""}, {"s4", "m3"}, {"s5", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("输出 %v, 期望 %v", got, want)
+	}
+}
+
+func TestMixLoopFlushesMicWhenSystemSilent(t *testing.T) {
+	// 回环设备没有数据时，麦克风积压超过 maxBacklog 后单独输出
+	got := runMixLoop(t, []string{"m1", "m2", "m3", "m4", "m5", "s1"})
+	want := [][2]string{{"", "m1"}, {"", "m2"}, {"", "m3"}, {"s1", "m4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("输出 %v, 期望 %v", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// 重采样滤波器参数
+const (
+	resampleTapsPerPhase = 32   // 每个相位的基础滤波器抽头数（降采样时按比例增加），越大过渡带越窄、延迟越高
+	resampleRolloff      = 0.92 // 截止频率相对于奈奎斯特频率的比例，留出过渡带防止混叠
+)
+
+// Format 音频格式（S16 交错存储）
+type Format struct {
+	SampleRate int
+	Channels   int
+}
+
+// Resampler 多相 FIR 重采样器，按 up/down 的有理数比例转换采样率
+// 支持流式处理：跨调用保留滤波器历史，分块输入与一次性输入的结果相同
+type Resampler struct {
+	up, down int
+	taps     int
+	phases   [][]float64 // phases[p][k] 为原型滤波器第 p + k*up 个系数
+
+	history []float64 // 尚未完全消费的输入样本（开头为上一块的尾部）
+	pos     int       // 下一个输出在上采样序列中相对 history 开头的位置
+}
+
+// NewResampler 创建从 inRate 到 outRate 的重采样器
+func NewResampler(inRate, outRate int) *Resampler {
+	g := gcd(inRate, outRate)
+	up, down := outRate/g, inRate/g
+	// 降采样时截止频率按 down/up 缩小，抽头数同比增加以保持相同的过渡带宽度（相对输出采样率）
+	taps := resampleTapsPerPhase * max(1, (down+up-1)/up)
+
+	// 原型低通滤波器运行在上采样后的采样率（inRate*up）上，截止取两侧奈奎斯特频率的较小者
+	length := taps * up
+	cutoff := 0.5 / float64(max(up, down)) * resampleRolloff
+	center := float64(length-1) / 2
+	prototype := make([]float64, length)
+	var sum float64
+	for n := range prototype {
+		x := float64(n) - center
+		prototype[n] = 2 * cutoff * sinc(2*cutoff*x) * blackman(n, length)
+		sum += prototype[n]
+	}
+
+	// 归一化使直流增益为 1（补零上采样会让能量降为 1/up，因此总和为 up）
+	phases := make([][]float64, up)
+	for p := range phases {
+		phases[p] = make([]float64, taps)
+		for k := range phases[p] {
+			phases[p][k] = prototype[p+k*up] * float64(up) / sum
+		}
+	}
+
+	return &Resampler{
+		up:      up,
+		down:    down,
+		taps:    taps,
+		phases:  phases,
+		history: make([]float64, taps-1),
+		pos:     (taps - 1) * up,
+	}
+}
+
+// Process 重采样一块样本，返回本次可以输出的全部样本
+func (r *Resampler) Process(in []float64) []float64 {
+	r.history = append(r.history, in...)
+	out := make([]float64, 0, len(in)*r.up/r.down+1)
+
+	for r.pos/r.up < len(r.history) {
+		newest := r.pos / r.up
+		coeffs := r.phases[r.pos%r.up]
+		var y float64
+		for k, c := range coeffs {
+			y += c * r.history[newest-k]
+		}
+		out = append(out, y)
+		r.pos += r.down
+	}
+
+	// 只保留下一个输出仍需要的样本
+	drop := r.pos/r.up - (r.taps - 1)
+	if drop > len(r.history) {
+		drop = len(r.history)
+	}
+	if drop > 0 {
+		r.history = append(r.history[:0], r.history[drop:]...)
+		r.pos -= drop * r.up
+	}
+	return out
+}
+
+// Converter 把设备原生格式的 S16 音频转换为单声道、目标采样率的 S16 音频
+type Converter struct {
+	in        Format
+	outRate   int
+	resampler *Resampler // 采样率相同时为空
+	partial   []byte     // 上一块末尾不足一帧的字节
+}
+
+// NewConverter 创建格式转换器，in 为设备原生格式，outRate 为 Live 服务需要的采样率
+func NewConverter(in Format, outRate int) *Converter {
+	if in.Channels <= 0 {
+		in.Channels = 1
+	}
+	c := &Converter{in: in, outRate: outRate}
+	if in.SampleRate != outRate {
+		c.resampler = NewResampler(in.SampleRate, outRate)
+	}
+	return c
+}
+
+// Convert 转换一块交错存储的 S16 音频，返回单声道 S16 音频
+func (c *Converter) Convert(pcm []byte) []byte {
+	frameSize := BytesPerSample * c.in.Channels
+	if len(c.partial) > 0 {
+		pcm = append(c.partial, pcm...)
+		c.partial = nil
+	}
+	if rest := len(pcm) % frameSize; rest != 0 {
+		c.partial = append([]byte(nil), pcm[len(pcm)-rest:]...)
+		pcm = pcm[:len(pcm)-rest]
+	}
+
+	mono := Downmix(pcm, c.in.Channels)
+	if c.resampler != nil {
+		mono = c.resampler.Process(mono)
+	}
+	return floatToS16(mono)
+}
+
+// Downmix 把交错存储的多声道 S16 音频平均为单声道，样本范围 [-1, 1]
+func Downmix(pcm []byte, channels int) []float64 {
+	frameSize := BytesPerSample * channels
+	frames := len(pcm) / frameSize
+	mono := make([]float64, frames)
+	for i := range mono {
+		var sum float64
+		for ch := 0; ch < channels; ch++ {
+			offset := i*frameSize + ch*BytesPerSample
+			sum += float64(int16(binary.LittleEndian.Uint16(pcm[offset:])))
+		}
+		mono[i] = sum / float64(channels) / 32768
+	}
+	return mono
+}
+
+// floatToS16 把 [-1, 1] 的样本转换为 S16，超出范围时截断
+func floatToS16(samples []float64) []byte {
+	out := make([]byte, len(samples)*BytesPerSample)
+	for i, v := range samples {
+		v = math.Round(v * 32768)
+		v = math.Max(math.MinInt16, math.Min(math.MaxInt16, v))
+		binary.LittleEndian.PutUint16(out[i*BytesPerSample:], uint16(int16(v)))
+	}
+	return out
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman Blackman 窗，阻带衰减约 74dB
+func blackman(n, length int) float64 {
+	if length == 1 {
+		return 1
+	}
+	x := 2 * math.Pi * float64(n) / float64(length-1)
+	return 0.42 - 0.5*math.Cos(x) + 0.08*math.Cos(2*x)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
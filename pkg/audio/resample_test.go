@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// sine 生成幅度为 1 的正弦波
+func sine(freq float64, rate, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.Sin(2 * math.Pi * freq * float64(i) / float64(rate))
+	}
+	return out
+}
+
+// amplitude 计算正弦波幅度（均方根 × √2），跳过开头的滤波器暖机样本
+func amplitude(samples []float64, skip int) float64 {
+	samples = samples[skip:]
+	var sum float64
+	for _, v := range samples {
+		sum += v * v
+	}
+	return math.Sqrt(sum/float64(len(samples))) * math.Sqrt2
+}
+
+func TestResamplerPassbandGain(t *testing.T) {
+	cases := []struct {
+		in, out int
+		freq    float64
+	}{
+		{48000, 16000, 1000},
+		{48000, 16000, 6000},
+		{44100, 16000, 1000},
+		{16000, 24000, 3000},
+		{44100, 24000, 5000},
+	}
+	for _, c := range cases {
+		out := NewResampler(c.in, c.out).Process(sine(c.freq, c.in, c.in))
+		got := amplitude(out, resampleTapsPerPhase*2)
+		if math.Abs(got-1) > 0.02 {
+			t.Errorf("%d->%d %.0fHz: 通带增益 %.4f, 期望约 1", c.in, c.out, c.freq, got)
+		}
+	}
+}
+
+func TestResamplerStopbandAttenuation(t *testing.T) {
+	cases := []struct {
+		in, out int
+		freq    float64
+	}{
+		{48000, 16000, 12000},
+		{48000, 16000, 20000},
+		{44100, 16000, 10000},
+		{44100, 24000, 16000},
+	}
+	for _, c := range cases {
+		out := NewResampler(c.in, c.out).Process(sine(c.freq, c.in, c.in))
+		db := 20 * math.Log10(amplitude(out, resampleTapsPerPhase*2))
+		if db > -60 {
+			t.Errorf("%d->%d %.0fHz: 阻带衰减 %.1fdB, 期望低于 -60dB", c.in, c.out, c.freq, db)
+		}
+	}
+}
+
+func TestResamplerChunkedMatchesOneShot(t *testing.T) {
+	input := sine(440, 44100, 44100/2)
+	want := NewResampler(44100, 16000).Process(input)
+
+	r := NewResampler(44100, 16000)
+	var got []float64
+	chunks := []int{1, 7, 160, 441, 1023, 3}
+	for i, rest := 0, input; len(rest) > 0; i++ {
+		n := min(chunks[i%len(chunks)], len(rest))
+		got = append(got, r.Process(rest[:n])...)
+		rest = rest[n:]
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("分块输出 %d 个样本, 一次性输出 %d 个", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Fatalf("第 %d 个样本不同: 分块 %v, 一次性 %v", i, got[i], want[i])
+		}
+	}
+}
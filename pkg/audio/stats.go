@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// SourceMixed 双路采集混音后的输出
+const SourceMixed = "mixed"
+
+// CaptureStats 一路音频的健康状况
+// 音量和包数统计自上次获取以来的区间，丢包和溢出为本次采集开始以来的累计值
+type CaptureStats struct {
+	Source         string  `json:"source"`
+	RMS            float64 `json:"rms"`            // 平均音量（0-1）
+	Peak           float64 `json:"peak"`           // 峰值（0-1）
+	Packets        int     `json:"packets"`        // 输出的数据包数
+	DroppedPackets int     `json:"droppedPackets"` // channel 已满被丢弃的数据包
+	Overruns       int     `json:"overruns"`       // 环形缓冲区已满、覆盖旧数据的次数
+	BufferedMs     int     `json:"bufferedMs"`     // 环形缓冲区中待分包的音频时长
+	QueuedPackets  int     `json:"queuedPackets"`  // channel 中等待发送的数据包
+}
+
+// levelMeter 统计一段时间内数据包的音量
+type levelMeter struct {
+	mu         sync.Mutex
+	sumSquares float64
+	samples    int
+	peak       float64
+	packets    int
+	dropped    int
+}
+
+// add 记录一个 S16 数据包
+func (l *levelMeter) add(packet []byte) {
+	var sum, peak float64
+	samples := len(packet) / BytesPerSample
+	for i := 0; i < samples; i++ {
+		v := math.Abs(float64(int16(binary.LittleEndian.Uint16(packet[i*BytesPerSample:]))) / 32768)
+		sum += v * v
+		peak = math.Max(peak, v)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sumSquares += sum
+	l.samples += samples
+	l.peak = math.Max(l.peak, peak)
+	l.packets++
+}
+
+// drop 记录一个被丢弃的数据包
+func (l *levelMeter) drop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dropped++
+}
+
+// take 取出区间统计并重置音量和包数，丢包数保持累计
+func (l *levelMeter) take(source string) CaptureStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := CaptureStats{
+		Source:         source,
+		Peak:           l.peak,
+		Packets:        l.packets,
+		DroppedPackets: l.dropped,
+	}
+	if l.samples > 0 {
+		stats.RMS = math.Sqrt(l.sumSquares / float64(l.samples))
+	}
+	l.sumSquares, l.samples, l.peak, l.packets = 0, 0, 0, 0
+	return stats
+}
+
+// reset 清空所有统计（重新开始采集时）
+func (l *levelMeter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sumSquares, l.samples, l.peak, l.packets, l.dropped = 0, 0, 0, 0, 0
+}
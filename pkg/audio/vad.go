@@ -0,0 +1,156 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// VADEvent 语音活动检测事件
+type VADEvent int
+
+const (
+	VADNone        VADEvent = iota // 状态没有变化
+	VADSpeechStart                 // 开始说话
+	VADSpeechEnd                   // 说话结束（拖尾结束后）
+)
+
+const (
+	vadStartFrames  = 2    // 连续多少个语音帧才算开始说话，过滤按键声等短促噪声
+	vadNoiseRatio   = 3.0  // 语音帧的音量至少是底噪的多少倍
+	vadNoiseAdapt   = 0.05 // 底噪估计的更新速度（仅在音量不足的帧更新）
+	vadNoiseInitial = 0.002
+)
+
+// VADConfig 语音活动检测参数
+type VADConfig struct {
+	Threshold  float64 // 语音帧的最小 RMS 音量（0-1）
+	MaxZCR     float64 // 语音帧的最大过零率（0-1），超过视为嘶嘶声等宽带噪声
+	HangoverMs int     // 说话停顿后继续发送多久才判定结束，避免句中停顿被截断
+	PreRollMs  int     // 判定开始说话时补发之前多久的音频，避免丢掉第一个字
+}
+
+// DefaultVADConfig 默认参数
+func DefaultVADConfig() VADConfig {
+	return VADConfig{
+		Threshold:  0.01,
+		MaxZCR:     0.4,
+		HangoverMs: 800,
+		PreRollMs:  300,
+	}
+}
+
+// VAD 基于能量和过零率的语音活动检测，输入固定时长（PacketDurationMs）的 S16 数据包
+// 静音期间缓存最近的数据包作为预录，开始说话时一并输出；说话结束后的拖尾期间继续输出
+type VAD struct {
+	cfg            VADConfig
+	hangoverFrames int
+	preRollFrames  int
+
+	speaking   bool
+	voiced     int      // 连续语音帧数（静音状态下）
+	silence    int      // 连续静音帧数（说话状态下）
+	preRoll    [][]byte // 静音期间最近的数据包
+	noiseFloor float64
+}
+
+// NewVAD 创建语音活动检测器，参数为 0 时使用默认值
+func NewVAD(cfg VADConfig) *VAD {
+	defaults := DefaultVADConfig()
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = defaults.Threshold
+	}
+	if cfg.MaxZCR <= 0 {
+		cfg.MaxZCR = defaults.MaxZCR
+	}
+	if cfg.HangoverMs <= 0 {
+		cfg.HangoverMs = defaults.HangoverMs
+	}
+	if cfg.PreRollMs < 0 {
+		cfg.PreRollMs = 0
+	}
+	return &VAD{
+		cfg:            cfg,
+		hangoverFrames: max(1, cfg.HangoverMs/PacketDurationMs),
+		preRollFrames:  cfg.PreRollMs / PacketDurationMs,
+		noiseFloor:     vadNoiseInitial,
+	}
+}
+
+// Process 处理一个数据包，返回需要发送的数据包和状态变化
+// 静音时返回空；开始说话时返回预录 + 当前包；说话和拖尾期间返回当前包
+func (v *VAD) Process(packet []byte) ([][]byte, VADEvent) {
+	speech := v.isSpeech(packet)
+
+	if !v.speaking {
+		v.preRoll = append(v.preRoll, packet)
+		if speech {
+			v.voiced++
+		} else {
+			v.voiced = 0
+		}
+		// 预录保留最近 preRollFrames 个包，加上判定开始所需的语音帧
+		if limit := v.preRollFrames + vadStartFrames; len(v.preRoll) > limit {
+			v.preRoll = v.preRoll[len(v.preRoll)-limit:]
+		}
+		if v.voiced < vadStartFrames {
+			return nil, VADNone
+		}
+
+		v.speaking = true
+		v.voiced = 0
+		v.silence = 0
+		out := v.preRoll
+		v.preRoll = nil
+		return out, VADSpeechStart
+	}
+
+	if speech {
+		v.silence = 0
+		return [][]byte{packet}, VADNone
+	}
+	v.silence++
+	if v.silence < v.hangoverFrames {
+		return [][]byte{packet}, VADNone
+	}
+	v.speaking = false
+	v.silence = 0
+	return [][]byte{packet}, VADSpeechEnd
+}
+
+// Speaking 当前是否处于说话状态（含拖尾）
+func (v *VAD) Speaking() bool {
+	return v.speaking
+}
+
+// isSpeech 判断数据包是否为语音帧，并在音量不足的帧上更新底噪估计
+func (v *VAD) isSpeech(packet []byte) bool {
+	level, zcr := frameFeatures(packet)
+	threshold := math.Max(v.cfg.Threshold, v.noiseFloor*vadNoiseRatio)
+	if level < threshold {
+		// 只用音量不足的帧估计底噪，过零率过高的噪声不会抬高阈值
+		v.noiseFloor += (level - v.noiseFloor) * vadNoiseAdapt
+		return false
+	}
+	return zcr <= v.cfg.MaxZCR
+}
+
+// frameFeatures 计算 S16 数据包的 RMS 音量（0-1）和过零率（0-1）
+func frameFeatures(packet []byte) (float64, float64) {
+	samples := len(packet) / BytesPerSample
+	if samples < 2 {
+		return 0, 0
+	}
+	var sum float64
+	crossings := 0
+	prev := int16(binary.LittleEndian.Uint16(packet))
+	for i := 0; i < samples; i++ {
+		sample := int16(binary.LittleEndian.Uint16(packet[i*BytesPerSample:]))
+		v := float64(sample) / 32768
+		sum += v * v
+		if (sample >= 0) != (prev >= 0) {
+			crossings++
+		}
+		prev = sample
+	}
+	return math.Sqrt(sum / float64(samples)), float64(crossings) / float64(samples-1)
+}
@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// vadPacket 生成一个数据包：freq 为 0 时输出正负交替的宽带噪声（过零率为 1）
+func vadPacket(amp, freq float64) []byte {
+	packet := make([]byte, PacketSize)
+	for i := 0; i < PacketSize/BytesPerSample; i++ {
+		v := amp
+		if freq > 0 {
+			v = amp * math.Sin(2*math.Pi*freq*float64(i)/SampleRate)
+		} else if i%2 == 1 {
+			v = -amp
+		}
+		binary.LittleEndian.PutUint16(packet[i*BytesPerSample:], uint16(int16(v*32767)))
+	}
+	return packet
+}
+
+// vadPackets 数据包序列：'.' 静音，'v' 语音（200Hz），'n' 响亮的嘶嘶声，'q' 低于阈值的底噪
+var vadPackets = map[rune][]byte{
+	'.': vadPacket(0, 200),
+	'v': vadPacket(0.3, 200),
+	'n': vadPacket(0.3, 0),
+	'q': vadPacket(0.005, 200),
+}
+
+// vadStep 某个数据包的处理结果
+type vadStep struct {
+	event VADEvent
+	out   int // 输出的数据包数
+}
+
+func runVAD(v *VAD, frames string) []vadStep {
+	steps := make([]vadStep, 0, len(frames))
+	for _, f := range frames {
+		out, event := v.Process(vadPackets[f])
+		steps = append(steps, vadStep{event, len(out)})
+	}
+	return steps
+}
+
+func TestVADProcess(t *testing.T) {
+	// 拖尾 3 帧，预录 2 帧：开始说话时最多输出 2 + vadStartFrames 个包
+	cfg := VADConfig{HangoverMs: 3 * PacketDurationMs, PreRollMs: 2 * PacketDurationMs}
+	preRoll := cfg.PreRollMs/PacketDurationMs + vadStartFrames
+
+	cases := []struct {
+		name   string
+		frames string
+		want   map[int]vadStep // 数据包下标 -> 期望结果，未列出的为 {VADNone, 0}
+	}{
+		{"单个语音帧不算开始", "...v.v.v..", nil},
+		{"连续语音帧开始说话", "vv", map[int]vadStep{1: {VADSpeechStart, 2}}},
+		{"预录只保留最近的包", "......vv", map[int]vadStep{7: {VADSpeechStart, preRoll}}},
+		{"嘶嘶声不算语音", "....nnnnnn", nil},
+		{"静音满拖尾才结束", "..vv...", map[int]vadStep{
+			3: {VADSpeechStart, 4},
+			4: {VADNone, 1},
+			5: {VADNone, 1},
+			6: {VADSpeechEnd, 1},
+		}},
+		{"拖尾期间说话重新计时", "vv..v...", map[int]vadStep{
+			1: {VADSpeechStart, 2},
+			2: {VADNone, 1},
+			3: {VADNone, 1},
+			4: {VADNone, 1},
+			5: {VADNone, 1},
+			6: {VADNone, 1},
+			7: {VADSpeechEnd, 1},
+		}},
+		{"结束后重新开始", "vv...vv", map[int]vadStep{
+			1: {VADSpeechStart, 2},
+			2: {VADNone, 1},
+			3: {VADNone, 1},
+			4: {VADSpeechEnd, 1},
+			6: {VADSpeechStart, 2},
+		}},
+	}
+	for _, c := range cases {
+		steps := runVAD(NewVAD(cfg), c.frames)
+		for i, got := range steps {
+			if want := c.want[i]; got != want {
+				t.Errorf("%s: 第 %d 个包 %+v, 期望 %+v", c.name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestVADNoiseFloor(t *testing.T) {
+	cases := []struct {
+		name   string
+		frames string
+		rises  bool // 底噪估计是否升高
+	}{
+		{"持续说话", "vvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvv", false},
+		{"响亮的嘶嘶声", "nnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnnn", false},
+		{"安静的底噪", "qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq", true},
+	}
+	for _, c := range cases {
+		v := NewVAD(VADConfig{})
+		runVAD(v, c.frames)
+		if rises := v.noiseFloor > vadNoiseInitial; rises != c.rises {
+			t.Errorf("%s: 底噪 %.4f, 初始值 %.4f", c.name, v.noiseFloor, vadNoiseInitial)
+		}
+	}
+
+	// 长时间说话后，底噪仍不会把正常音量的语音判为静音
+	v := NewVAD(VADConfig{})
+	runVAD(v, "vvvvvvvvvvvvvvvvvvvvvvvvvvvvvv")
+	if !v.Speaking() || !v.isSpeech(vadPackets['v']) {
+		t.Error("长时间说话后语音帧不再被识别")
+	}
+}
@@ -0,0 +1,90 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+)
+
+const wavHeaderSize = 44
+
+// WAVWriter 流式写入 16-bit PCM WAV 文件，关闭时回填文件头中的长度
+type WAVWriter struct {
+	file       *os.File
+	buf        *bufio.Writer
+	sampleRate int
+	channels   int
+	dataBytes  int64
+}
+
+// NewWAVWriter 创建 WAV 文件
+func NewWAVWriter(path string, sampleRate, channels int) (*WAVWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &WAVWriter{
+		file:       file,
+		buf:        bufio.NewWriter(file),
+		sampleRate: sampleRate,
+		channels:   channels,
+	}
+	// 先写入长度为 0 的文件头，Close 时回填
+	if _, err := w.buf.Write(wavHeader(sampleRate, channels, 0)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write 写入 S16 交错存储的 PCM 数据
+func (w *WAVWriter) Write(pcm []byte) (int, error) {
+	n, err := w.buf.Write(pcm)
+	w.dataBytes += int64(n)
+	return n, err
+}
+
+// DurationMs 已写入音频的时长（毫秒）
+func (w *WAVWriter) DurationMs() int64 {
+	return w.dataBytes * 1000 / int64(w.sampleRate*w.channels*BytesPerSample)
+}
+
+// Close 回填文件头并关闭文件
+func (w *WAVWriter) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if _, err := w.file.WriteAt(wavHeader(w.sampleRate, w.channels, w.dataBytes), 0); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// EncodeWAV 把 S16 交错存储的 PCM 数据封装为内存中的 WAV 文件（用于上传转写）
+func EncodeWAV(pcm []byte, sampleRate, channels int) []byte {
+	data := make([]byte, 0, wavHeaderSize+len(pcm))
+	data = append(data, wavHeader(sampleRate, channels, int64(len(pcm)))...)
+	return append(data, pcm...)
+}
+
+// wavHeader 生成 44 字节的 RIFF/WAVE 文件头
+func wavHeader(sampleRate, channels int, dataBytes int64) []byte {
+	h := make([]byte, wavHeaderSize)
+	blockAlign := channels * BytesPerSample
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], uint32(36+dataBytes))
+	copy(h[8:], "WAVE")
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16) // fmt 块大小
+	binary.LittleEndian.PutUint16(h[20:], 1)  // PCM
+	binary.LittleEndian.PutUint16(h[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(h[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(h[34:], BytesPerSample*8)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], uint32(dataBytes))
+	return h
+}
@@ -14,6 +14,8 @@ type RingBuffer struct {
 	size  int
 	cap   int
 	mu    sync.Mutex
+
+	overruns int // 写入时覆盖了未读数据的次数
 }
 
 func NewRingBuffer(capacity int) *RingBuffer {
@@ -35,6 +37,9 @@ func (r *RingBuffer) Write(p []byte) (n int, err error) {
 
 	pSize := len(p)
 	totalWrite := pSize 
+	if r.size+pSize > r.cap {
+		r.overruns++
+	}
 
 	for pSize > 0 {
 		n1 := copy(r.data[r.wHead:], p)
@@ -86,10 +91,18 @@ func (r *RingBuffer) Len() int {
 }
 
 
+// Overruns 缓冲区满后覆盖旧数据的次数（消费者跟不上）
+func (r *RingBuffer) Overruns() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.overruns
+}
+
 func (r *RingBuffer) Reset() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.rHead = 0
 	r.wHead = 0
 	r.size = 0
+	r.overruns = 0
 }
\ No newline at end of file
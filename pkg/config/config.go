@@ -3,6 +3,7 @@ package config
 import (
 	"Q-Solver/pkg/shortcut"
 	"encoding/json"
+	"fmt"
 	"runtime"
 )
 
@@ -12,20 +13,44 @@ type Config struct {
 	Model              string                         `json:"model,omitempty"`
 	BaseURL            string                         `json:"baseURL,omitempty"`
 	Prompt             string                         `json:"prompt,omitempty"`
+	PromptTemplates    map[string]string              `json:"promptTemplates,omitempty"` // 各类型当前选中的模板名称，为空使用内置模板
+	CodingLanguage     string                         `json:"codingLanguage,omitempty"`  // 目标编程语言（模板变量 {{.Language}}）
+	Locale             string                         `json:"locale,omitempty"`          // 内置提示词语言包 (zh-CN / en-US)
+	AnswerLanguage     string                         `json:"answerLanguage,omitempty"`  // 强制回答语言，为空不限制
 	Opacity            float64                        `json:"opacity,omitempty"`
 	NoCompression      bool                           `json:"noCompression,omitempty"`
 	CompressionQuality int                            `json:"compressionQuality,omitempty"`
 	Sharpening         float64                        `json:"sharpening,omitempty"`
 	Grayscale          bool                           `json:"grayscale,omitempty"`
+	AutoCrop           bool                           `json:"autoCrop,omitempty"`          // 上传前去掉纯色边框和空白边距
+	FocusTextBlock     bool                           `json:"focusTextBlock,omitempty"`    // 上传前裁剪到文字最密集的区域
+	MaxImageDimension  int                            `json:"maxImageDimension,omitempty"` // 长边上限（像素）
+	Binarize           bool                           `json:"binarize,omitempty"`          // 二值化，适合纯文字题面
+	ImageEncoder       string                         `json:"imageEncoder,omitempty"`      // jpeg / png / png-palette / webp-lossless
+	ImageSteps         []string                       `json:"imageSteps,omitempty"`        // 截图处理步骤及顺序，设置后忽略 autoCrop 等开关；为空时按开关使用默认顺序
+	ImageTargetBytes   map[string]int                 `json:"imageTargetBytes,omitempty"`  // 各提供商的截图大小目标（Base64 字节），超出时自动降低质量或尺寸
+	OptimizeTokens     bool                           `json:"optimizeTokens,omitempty"`    // 按模型的图片计费方式缩放到 Token 最少的尺寸
+	MinGlyphHeight     int                            `json:"minGlyphHeight,omitempty"`    // 缩放后文字行高的下限（像素）
+	ForceResolve       bool                           `json:"forceResolve,omitempty"`      // 截图没有变化时也重新请求模型，不复用上次的答案
+	UnchangedRatio     float64                        `json:"unchangedRatio,omitempty"`    // 变化比例不超过该值视为截图没有变化
 	KeepContext        bool                           `json:"keepContext,omitempty"`
 	InterruptThinking  bool                           `json:"interruptThinking,omitempty"`
 	ScreenshotMode     string                         `json:"screenshotMode,omitempty"`
+	CaptureRegion      Region                         `json:"captureRegion,omitempty"` // region 模式下框选的截图区域
+	DisplayTarget      string                         `json:"displayTarget,omitempty"` // fullscreen 模式下截取哪块屏幕 (index / cursor / window)
+	DisplayIndex       int                            `json:"displayIndex,omitempty"`  // displayTarget 为 index 时的屏幕序号
 	ResumePath         string                         `json:"resumePath,omitempty"`
 	ResumeBase64       string                         `json:"-"`
 	ResumeContent      string                         `json:"resumeContent,omitempty"`
 	UseMarkdownResume  bool                           `json:"useMarkdownResume,omitempty"`
 	Shortcuts          map[string]shortcut.KeyBinding `json:"shortcuts,omitempty"`
 
+	// 本地 OCR：先识别截图文字，以文字（或文字 + 低清截图）代替原图发送
+	OCRMode          string  `json:"ocrMode,omitempty"`          // 为空不启用，text / text+image
+	TesseractPath    string  `json:"tesseractPath,omitempty"`    // tesseract 可执行文件路径，为空从 PATH 查找
+	OCRLanguages     string  `json:"ocrLanguages,omitempty"`     // 识别语言，如 chi_sim+eng
+	OCRMinConfidence float64 `json:"ocrMinConfidence,omitempty"` // 平均置信度低于该值时回退为发送原图
+
 	// LLM 生成参数
 	Temperature    float64 `json:"temperature,omitempty"`
 	TopP           float64 `json:"topP,omitempty"`
@@ -33,11 +58,41 @@ type Config struct {
 	MaxTokens      int     `json:"maxTokens,omitempty"`
 	ThinkingBudget int     `json:"thinkingBudget,omitempty"`
 
+	// 编程偏好（目标语言见 CodingLanguage，会注入解题的系统提示词）
+	LanguageVersion    string   `json:"languageVersion,omitempty"`    // 语言版本，如 C++17、Python 3.11
+	CodingStyleRules   []string `json:"codingStyleRules,omitempty"`   // 代码风格要求，如“不使用递归”“优先使用 STL”
+	NamingConvention   string   `json:"namingConvention,omitempty"`   // 命名规范，如 camelCase
+	AlternateLanguages []string `json:"alternateLanguages,omitempty"` // 快捷键切换时的候选语言
+
 	// 辅助模型（用于总结对话生成问题导图）
 	AssistantModel string `json:"assistantModel,omitempty"`
 
+	// 自洽模式：同一题目并行发给多个模型，再由辅助模型评审
+	SelfConsistency   bool     `json:"selfConsistency,omitempty"`
+	ConsistencyModels []string `json:"consistencyModels,omitempty"`
+
 	// Live API
-	UseLiveApi bool `json:"useLiveApi,omitempty"`
+	UseLiveApi       bool    `json:"useLiveApi,omitempty"`
+	LiveMicrophone   bool    `json:"liveMicrophone,omitempty"`   // 同时采集麦克风，与系统声音混音后发送，转写按说话人区分
+	LiveMicGain      float64 `json:"liveMicGain,omitempty"`      // 麦克风音量倍数
+	AudioDeviceID    string  `json:"audioDeviceId,omitempty"`    // 采集系统声音的设备（ListAudioDevices 返回的 ID），为空时自动选择
+	LiveVADMode      string  `json:"liveVadMode,omitempty"`      // 本地语音检测：空为关闭，gate 只发送有人说话的音频，client 同时由本地判定说话开始和结束
+	VADThreshold     float64 `json:"vadThreshold,omitempty"`     // 语音帧的最小 RMS 音量（0-1）
+	VADMaxZCR        float64 `json:"vadMaxZcr,omitempty"`        // 语音帧的最大过零率（0-1）
+	VADHangoverMs    int     `json:"vadHangoverMs,omitempty"`    // 停顿多久判定说话结束
+	VADPreRollMs     int     `json:"vadPreRollMs,omitempty"`     // 开始说话时补发之前多久的音频
+	LiveTextModality bool    `json:"liveTextModality,omitempty"` // 请求文本输出，省去音频生成和转写的延迟；原生音频模型自动回退为音频 + 转写
+	RecordLive       bool    `json:"recordLive,omitempty"`       // 在本地保存 Live 会话录音（WAV）和带时间戳的转写/回答
+	RecordOpus       bool    `json:"recordOpus,omitempty"`       // 录音结束后用 ffmpeg 额外转换为 Opus/Ogg（需要安装 ffmpeg）
+	NotesDir         string  `json:"notesDir,omitempty"`         // 本地笔记目录（.md/.txt），设置后模型可通过 search_notes 工具搜索
+	LiveRunCode      bool    `json:"liveRunCode,omitempty"`      // 允许模型通过 run_code 工具在本机运行 Python/JavaScript 代码（每次运行前需用户确认）
+
+	// 语音转写管线：不使用 Live API，改为本地语音检测分段 -> 转写服务 -> 当前文本模型回答
+	LivePipeline       bool   `json:"livePipeline,omitempty"`
+	TranscribeURL      string `json:"transcribeUrl,omitempty"`      // 转写接口完整地址，如 http://127.0.0.1:8080/inference（whisper.cpp）或 https://api.openai.com/v1/audio/transcriptions
+	TranscribeAPIKey   string `json:"transcribeApiKey,omitempty"`   // 转写接口的 API Key，本地服务可留空
+	TranscribeModel    string `json:"transcribeModel,omitempty"`    // 转写模型，转写管线默认 whisper-1，OpenAI Realtime 默认 gpt-4o-mini-transcribe
+	TranscribeLanguage string `json:"transcribeLanguage,omitempty"` // 语音语言（如 zh、en），为空自动识别，同样用于 OpenAI Realtime 的输入转写
 
 	// 窗口尺寸
 	WindowWidth  int `json:"windowWidth,omitempty"`
@@ -46,6 +101,75 @@ type Config struct {
 
 const DefaultModel = "gemini-2.5-flash"
 
+// 截图模式
+const (
+	ScreenshotModeFullscreen = "fullscreen" // 主屏幕全屏
+	ScreenshotModeWindow     = "window"     // 应用窗口所在区域
+	ScreenshotModeRegion     = "region"     // 用户框选的固定区域
+	ScreenshotModeAll        = "all"        // 所有屏幕，每块屏幕单独一张图
+)
+
+// 全屏模式下的屏幕选择方式
+const (
+	DisplayTargetIndex  = "index"  // 指定序号的屏幕
+	DisplayTargetCursor = "cursor" // 鼠标所在的屏幕
+	DisplayTargetWindow = "window" // 应用窗口所在的屏幕
+)
+
+// Region 屏幕区域（屏幕坐标）
+type Region struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Empty 区域是否为空
+func (r Region) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// 截图编码格式
+const (
+	ImageEncoderJPEG         = "jpeg"
+	ImageEncoderPNG          = "png"
+	ImageEncoderPalettePNG   = "png-palette"   // 最多 256 色的调色板 PNG
+	ImageEncoderWebPLossless = "webp-lossless" // 无损 WebP
+)
+
+// 截图处理步骤
+const (
+	ImageStepFocusText = "focus-text"
+	ImageStepAutoCrop  = "auto-crop"
+	ImageStepResize    = "resize"
+	ImageStepGrayscale = "grayscale"
+	ImageStepSharpen   = "sharpen"
+	ImageStepBinarize  = "binarize"
+)
+
+// OCR 模式
+const (
+	OCRModeOff       = ""           // 不使用 OCR
+	OCRModeText      = "text"       // 仅发送识别出的文字
+	OCRModeTextImage = "text+image" // 发送文字和低分辨率截图
+)
+
+// Live 本地语音检测模式
+const (
+	VADModeOff    = ""       // 发送全部音频，由服务端检测说话
+	VADModeGate   = "gate"   // 只发送有人说话的音频，说话开始和结束仍由服务端判断
+	VADModeClient = "client" // 只发送有人说话的音频，并由本地通知服务端说话开始和结束
+)
+
+// 内置提示词语言
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEnUS = "en-US"
+)
+
+// MaxConsistencyModels 自洽模式最多并行的模型数量
+const MaxConsistencyModels = 5
+
 func NewDefaultConfig() Config {
 	return Config{
 		APIKey:             "",
@@ -53,14 +177,32 @@ func NewDefaultConfig() Config {
 		BaseURL:            "",
 		ResumePath:         "",
 		Prompt:             "",
+		PromptTemplates:    map[string]string{},
+		CodingLanguage:     "",
+		Locale:             LocaleZhCN,
+		AnswerLanguage:     "",
 		Opacity:            1.0,
+		ForceResolve:       false,
+		UnchangedRatio:     0.0005,
 		KeepContext:        false,
 		InterruptThinking:  false,
-		ScreenshotMode:     "window",
+		ScreenshotMode:     ScreenshotModeWindow,
+		CaptureRegion:      Region{},
+		DisplayTarget:      DisplayTargetIndex,
+		DisplayIndex:       0,
 		NoCompression:      false,
 		CompressionQuality: 80,
 		Sharpening:         0.0,
 		Grayscale:          false,
+		AutoCrop:           false,
+		FocusTextBlock:     false,
+		MaxImageDimension:  2000,
+		Binarize:           false,
+		ImageEncoder:       ImageEncoderJPEG,
+		ImageSteps:         []string{},
+		ImageTargetBytes:   map[string]int{},
+		OptimizeTokens:     false,
+		MinGlyphHeight:     12,
 		UseMarkdownResume:  false,
 		ResumeBase64:       "",
 		ResumeContent:      "",
@@ -68,6 +210,12 @@ func NewDefaultConfig() Config {
 
 		Shortcuts: getDefaultShortcuts(),
 
+		// 本地 OCR
+		OCRMode:          OCRModeOff,
+		TesseractPath:    "",
+		OCRLanguages:     "chi_sim+eng",
+		OCRMinConfidence: 0.6,
+
 		// LLM 生成参数默认值
 		Temperature:    1.0,
 		TopP:           0.95,
@@ -75,11 +223,41 @@ func NewDefaultConfig() Config {
 		MaxTokens:      8192,
 		ThinkingBudget: 16000,
 
+		// 编程偏好
+		LanguageVersion:    "",
+		CodingStyleRules:   []string{},
+		NamingConvention:   "",
+		AlternateLanguages: []string{},
+
 		// 辅助模型
 		AssistantModel: "",
 
+		// 自洽模式
+		SelfConsistency:   false,
+		ConsistencyModels: []string{},
+
 		// Live API
-		UseLiveApi: false,
+		UseLiveApi:       false,
+		LiveMicrophone:   false,
+		LiveMicGain:      1.0,
+		AudioDeviceID:    "",
+		LiveVADMode:      VADModeOff,
+		VADThreshold:     0.01,
+		VADMaxZCR:        0.4,
+		VADHangoverMs:    800,
+		VADPreRollMs:     300,
+		LiveTextModality: false,
+		RecordLive:       false,
+		RecordOpus:       false,
+		NotesDir:         "",
+		LiveRunCode:      false,
+
+		// 语音转写管线
+		LivePipeline:       false,
+		TranscribeURL:      "",
+		TranscribeAPIKey:   "",
+		TranscribeModel:    "",
+		TranscribeLanguage: "",
 
 		// 窗口尺寸默认值
 		WindowWidth:  0,
@@ -92,28 +270,32 @@ func getDefaultShortcuts() map[string]shortcut.KeyBinding {
 	if runtime.GOOS == "darwin" {
 		// macOS 使用简化的快捷键（不依赖 Windows VK 码）
 		return map[string]shortcut.KeyBinding{
-			"solve":        {ComboID: "Cmd+1", KeyName: "⌘1"},
-			"toggle":       {ComboID: "Cmd+2", KeyName: "⌘2"},
-			"clickthrough": {ComboID: "Cmd+3", KeyName: "⌘3"},
-			"move_up":      {ComboID: "Cmd+Option+Up", KeyName: "⌘⌥↑"},
-			"move_down":    {ComboID: "Cmd+Option+Down", KeyName: "⌘⌥↓"},
-			"move_left":    {ComboID: "Cmd+Option+Left", KeyName: "⌘⌥←"},
-			"move_right":   {ComboID: "Cmd+Option+Right", KeyName: "⌘⌥→"},
-			"scroll_up":    {ComboID: "Cmd+Option+Shift+Up", KeyName: "⌘⌥⇧↑"},
-			"scroll_down":  {ComboID: "Cmd+Option+Shift+Down", KeyName: "⌘⌥⇧↓"},
+			"solve":         {ComboID: "Cmd+1", KeyName: "⌘1"},
+			"toggle":        {ComboID: "Cmd+2", KeyName: "⌘2"},
+			"clickthrough":  {ComboID: "Cmd+3", KeyName: "⌘3"},
+			"cycle_prompt":  {ComboID: "Cmd+4", KeyName: "⌘4"},
+			"flip_language": {ComboID: "Cmd+5", KeyName: "⌘5"},
+			"move_up":       {ComboID: "Cmd+Option+Up", KeyName: "⌘⌥↑"},
+			"move_down":     {ComboID: "Cmd+Option+Down", KeyName: "⌘⌥↓"},
+			"move_left":     {ComboID: "Cmd+Option+Left", KeyName: "⌘⌥←"},
+			"move_right":    {ComboID: "Cmd+Option+Right", KeyName: "⌘⌥→"},
+			"scroll_up":     {ComboID: "Cmd+Option+Shift+Up", KeyName: "⌘⌥⇧↑"},
+			"scroll_down":   {ComboID: "Cmd+Option+Shift+Down", KeyName: "⌘⌥⇧↓"},
 		}
 	}
 	// Windows 默认快捷键
 	return map[string]shortcut.KeyBinding{
-		"solve":        {ComboID: "119", KeyName: "F8"},
-		"toggle":       {ComboID: "120", KeyName: "F9"},
-		"clickthrough": {ComboID: "121", KeyName: "F10"},
-		"move_up":      {ComboID: "38+164", KeyName: "Alt+↑"},
-		"move_down":    {ComboID: "40+164", KeyName: "Alt+↓"},
-		"move_left":    {ComboID: "37+164", KeyName: "Alt+←"},
-		"move_right":   {ComboID: "39+164", KeyName: "Alt+→"},
-		"scroll_up":    {ComboID: "33+164", KeyName: "Alt+PgUp"},
-		"scroll_down":  {ComboID: "34+164", KeyName: "Alt+PgDn"},
+		"solve":         {ComboID: "119", KeyName: "F8"},
+		"toggle":        {ComboID: "120", KeyName: "F9"},
+		"clickthrough":  {ComboID: "121", KeyName: "F10"},
+		"cycle_prompt":  {ComboID: "118", KeyName: "F7"},
+		"flip_language": {ComboID: "117", KeyName: "F6"},
+		"move_up":       {ComboID: "38+164", KeyName: "Alt+↑"},
+		"move_down":     {ComboID: "40+164", KeyName: "Alt+↓"},
+		"move_left":     {ComboID: "37+164", KeyName: "Alt+←"},
+		"move_right":    {ComboID: "39+164", KeyName: "Alt+→"},
+		"scroll_up":     {ComboID: "33+164", KeyName: "Alt+PgUp"},
+		"scroll_down":   {ComboID: "34+164", KeyName: "Alt+PgDn"},
 	}
 }
 
@@ -123,8 +305,84 @@ func (c *Config) ToJSON() string {
 }
 
 func (c *Config) Validate() error {
-	if c.ScreenshotMode != "" && c.ScreenshotMode != "fullscreen" && c.ScreenshotMode != "window" {
-		return &ValidationError{Field: "screenshotMode", Message: "截图模式必须是 'fullscreen' 或 'window'"}
+	switch c.ScreenshotMode {
+	case "", ScreenshotModeFullscreen, ScreenshotModeWindow, ScreenshotModeAll:
+	case ScreenshotModeRegion:
+		if c.CaptureRegion.Empty() {
+			return &ValidationError{Field: "captureRegion", Message: "区域模式需要先框选截图区域"}
+		}
+	default:
+		return &ValidationError{Field: "screenshotMode", Message: "截图模式必须是 'fullscreen'、'window'、'region' 或 'all'"}
+	}
+	switch c.DisplayTarget {
+	case "", DisplayTargetIndex, DisplayTargetCursor, DisplayTargetWindow:
+	default:
+		return &ValidationError{Field: "displayTarget", Message: "屏幕选择必须是 'index'、'cursor' 或 'window'"}
+	}
+	if c.DisplayIndex < 0 {
+		return &ValidationError{Field: "displayIndex", Message: "屏幕序号不能为负数"}
+	}
+	switch c.ImageEncoder {
+	case "", ImageEncoderJPEG, ImageEncoderPNG, ImageEncoderPalettePNG, ImageEncoderWebPLossless:
+	default:
+		return &ValidationError{Field: "imageEncoder", Message: "图片编码必须是 'jpeg'、'png'、'png-palette' 或 'webp-lossless'"}
+	}
+	seenSteps := make(map[string]bool)
+	for _, step := range c.ImageSteps {
+		switch step {
+		case ImageStepFocusText, ImageStepAutoCrop, ImageStepResize, ImageStepGrayscale, ImageStepSharpen, ImageStepBinarize:
+		default:
+			return &ValidationError{Field: "imageSteps", Message: fmt.Sprintf("未知的截图处理步骤 '%s'", step)}
+		}
+		if seenSteps[step] {
+			return &ValidationError{Field: "imageSteps", Message: fmt.Sprintf("截图处理步骤 '%s' 重复", step)}
+		}
+		seenSteps[step] = true
+	}
+	if c.UnchangedRatio < 0 || c.UnchangedRatio > 1 {
+		return &ValidationError{Field: "unchangedRatio", Message: "截图变化阈值必须在 0-1 之间"}
+	}
+	if c.LiveMicGain < 0 || c.LiveMicGain > 4 {
+		return &ValidationError{Field: "liveMicGain", Message: "麦克风音量倍数必须在 0-4 之间"}
+	}
+	switch c.LiveVADMode {
+	case VADModeOff, VADModeGate, VADModeClient:
+	default:
+		return &ValidationError{Field: "liveVadMode", Message: "语音检测模式必须为空、'gate' 或 'client'"}
+	}
+	if c.VADThreshold < 0 || c.VADThreshold > 1 {
+		return &ValidationError{Field: "vadThreshold", Message: "语音检测音量阈值必须在 0-1 之间"}
+	}
+	if c.VADMaxZCR < 0 || c.VADMaxZCR > 1 {
+		return &ValidationError{Field: "vadMaxZcr", Message: "语音检测过零率阈值必须在 0-1 之间"}
+	}
+	if c.VADHangoverMs < 0 || c.VADPreRollMs < 0 {
+		return &ValidationError{Field: "vadHangoverMs", Message: "语音检测的拖尾和预录时长不能为负数"}
+	}
+	if c.LivePipeline && c.TranscribeURL == "" {
+		return &ValidationError{Field: "transcribeUrl", Message: "开启语音转写管线需要填写转写服务地址"}
+	}
+	if c.MinGlyphHeight < 0 {
+		return &ValidationError{Field: "minGlyphHeight", Message: "最小文字高度不能为负数"}
+	}
+	if c.MaxImageDimension < 0 {
+		return &ValidationError{Field: "maxImageDimension", Message: "图片长边上限不能为负数"}
+	}
+	for provider, target := range c.ImageTargetBytes {
+		if target < 0 {
+			return &ValidationError{Field: "imageTargetBytes", Message: fmt.Sprintf("%s 的截图大小目标不能为负数", provider)}
+		}
+	}
+	switch c.OCRMode {
+	case OCRModeOff, OCRModeText, OCRModeTextImage:
+	default:
+		return &ValidationError{Field: "ocrMode", Message: "OCR 模式必须为空、'text' 或 'text+image'"}
+	}
+	if c.OCRMinConfidence < 0 || c.OCRMinConfidence > 1 {
+		return &ValidationError{Field: "ocrMinConfidence", Message: "OCR 置信度阈值必须在 0-1 之间"}
+	}
+	if c.Locale != "" && c.Locale != LocaleZhCN && c.Locale != LocaleEnUS {
+		return &ValidationError{Field: "locale", Message: "提示词语言必须是 'zh-CN' 或 'en-US'"}
 	}
 	if c.Opacity < 0 || c.Opacity > 1 {
 		return &ValidationError{Field: "opacity", Message: "透明度必须在 0-1 之间"}
@@ -132,6 +390,16 @@ func (c *Config) Validate() error {
 	if c.CompressionQuality < 1 || c.CompressionQuality > 100 {
 		return &ValidationError{Field: "compressionQuality", Message: "压缩质量必须在 1-100 之间"}
 	}
+	if len(c.ConsistencyModels) > MaxConsistencyModels {
+		return &ValidationError{Field: "consistencyModels", Message: fmt.Sprintf("自洽模式最多选择 %d 个模型", MaxConsistencyModels)}
+	}
+	seenModels := make(map[string]bool)
+	for _, model := range c.ConsistencyModels {
+		if seenModels[model] {
+			return &ValidationError{Field: "consistencyModels", Message: fmt.Sprintf("自洽模式的模型 '%s' 重复", model)}
+		}
+		seenModels[model] = true
+	}
 	return nil
 }
 
@@ -49,6 +49,11 @@ func (cm *ConfigManager) getConfigPath() string {
 	return fullPath
 }
 
+// ConfigDir 获取配置文件所在目录（模板等数据也存放在此）
+func (cm *ConfigManager) ConfigDir() string {
+	return filepath.Dir(cm.configPath)
+}
+
 func (cm *ConfigManager) Load() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
@@ -32,6 +32,7 @@ type Graph struct {
 	cancelCtx     context.Context
 	configManager *config.ConfigManager
 	llmService    *llm.Service
+	promptLibrary *prompts.Library
 	emitEvent     func(string, ...any)
 
 	// channel 接收对话，无需加锁
@@ -50,6 +51,7 @@ func NewGraph(
 	cancelCtx context.Context,
 	configManager *config.ConfigManager,
 	llmService *llm.Service,
+	promptLibrary *prompts.Library,
 	emitEvent func(string, ...any),
 	triggerRound int,
 ) *Graph {
@@ -60,6 +62,7 @@ func NewGraph(
 		cancelCtx:     cancelCtx,
 		configManager: configManager,
 		llmService:    llmService,
+		promptLibrary: promptLibrary,
 		emitEvent:     emitEvent,
 		roundChan:     make(chan ChatRound, 100),
 		triggerRound:  triggerRound,
@@ -139,7 +142,7 @@ func (g *Graph) summarize(rounds []ChatRound) {
 	logger.Printf("Graph: 开始总结 %d 轮对话", len(rounds))
 
 	// 构建 prompt（包含已有节点信息）
-	prompt := g.buildPrompt(rounds)
+	prompt := g.buildPrompt(cfg, rounds)
 	logger.Println("生成导图的prompt: ", prompt)
 	// 调用模型
 	ctx, cancel := context.WithTimeout(g.cancelCtx, 60*time.Second)
@@ -153,7 +156,7 @@ func (g *Graph) summarize(rounds []ChatRound) {
 		logger.Printf("Graph: 总结失败: %v", err)
 		return
 	}
-	logger.Println("导图总结回复 %s", response.Content)
+	logger.Printf("导图总结回复 %s", response.Content)
 	// 解析并添加节点
 	newNodes := g.parseResponse(response.Content, rounds)
 	for _, node := range newNodes {
@@ -164,25 +167,30 @@ func (g *Graph) summarize(rounds []ChatRound) {
 }
 
 // buildPrompt 构建提示词
-func (g *Graph) buildPrompt(rounds []ChatRound) string {
+func (g *Graph) buildPrompt(cfg config.Config, rounds []ChatRound) string {
 	var nodesSb strings.Builder
 	var dialogSb strings.Builder
+	pack := prompts.GetPack(cfg.Locale)
 
 	// 构建已有节点信息
 	if len(g.nodes) > 0 {
 		for _, node := range g.nodes {
-			nodesSb.WriteString(fmt.Sprintf("- NodeID: %s NodeTitle: %s NodeAnswer: %s \n", node.ID, node.Title, node.Answer))
+			nodesSb.WriteString(fmt.Sprintf(pack.GraphNodeLine, node.ID, node.Title, node.Answer))
 		}
 	} else {
-		nodesSb.WriteString("（暂无已有节点）\n")
+		nodesSb.WriteString(pack.GraphNoNodes)
 	}
 
 	// 构建对话内容
 	for i, round := range rounds {
-		dialogSb.WriteString(fmt.Sprintf("第%d轮：\n问：%s\n答：%s\n\n", i+1, round.Question, round.Answer))
+		dialogSb.WriteString(fmt.Sprintf(pack.GraphRound, i+1, round.Question, round.Answer))
 	}
 
-	return fmt.Sprintf(prompts.GraphSummarizePromptTemplate, nodesSb.String(), dialogSb.String())
+	vars := prompts.VarsFromConfig(cfg)
+	vars.Nodes = nodesSb.String()
+	vars.Dialog = dialogSb.String()
+	prompt := g.promptLibrary.RenderActive(prompts.KindGraph, cfg.PromptTemplates, vars)
+	return prompts.WithAnswerLanguage(prompt, cfg)
 }
 
 // parseResponse 解析模型响应
@@ -5,9 +5,9 @@ import (
 	"Q-Solver/pkg/config"
 	"Q-Solver/pkg/llm"
 	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
 	"Q-Solver/pkg/screen"
 	"context"
-	"encoding/base64"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,9 +16,11 @@ import (
 
 // 重连相关常量
 const (
-	maxReconnectAttempts = 3                // 最大重连尝试次数
-	reconnectDelay       = time.Second      // 重连间隔
+	maxReconnectAttempts = 3               // 最大重连尝试次数
+	reconnectDelay       = time.Second     // 重连间隔
 	connectTimeout       = 3 * time.Second // 连接超时时间
+	speakerWindow        = 2 * time.Second // 转写到达时回看多久的音量来判断说话人
+	audioStatsInterval   = time.Second     // live:audio-stats 事件的发送间隔
 )
 
 // SessionState 会话状态类型
@@ -38,14 +40,27 @@ type LiveSessionManager struct {
 	llmService    *llm.Service
 	configManager *config.ConfigManager
 	screenService *screen.Service
+	captureSource screen.CaptureSource // 为空时使用 screenService 默认的截图来源
+	sourceMu      sync.Mutex
+	promptLibrary *prompts.Library
+	recordings    *RecordingStore
+	tools         *ToolRegistry
+	confirmations toolConfirmations
 	emitEvent     func(string, ...any)
 
 	// Live Session 状态 (使用 atomic.Pointer 实现无锁访问)
 	session atomic.Pointer[llm.LiveSession]
 
 	// 音频采集
-	audioCapture *audio.LoopbackCapture
+	audioCapture audio.Capture
 	mu           sync.Mutex
+	lastSpeaker  string // 最近一段转写的说话人（仅在 receiveLoop 中访问）
+
+	// 音频发送统计（live:audio-stats）
+	sendStats sendStats
+
+	// 会话录音（未开启时为空）
+	recording atomic.Pointer[Recording]
 
 	// 问题导图处理器
 	graph *Graph
@@ -72,15 +87,39 @@ func NewLiveSessionManager(
 	llmService *llm.Service,
 	configManager *config.ConfigManager,
 	screenService *screen.Service,
+	promptLibrary *prompts.Library,
+	recordings *RecordingStore,
 	emitEvent func(string, ...any),
 ) *LiveSessionManager {
-	return &LiveSessionManager{
+	m := &LiveSessionManager{
 		ctx:           ctx,
 		llmService:    llmService,
 		configManager: configManager,
 		screenService: screenService,
+		promptLibrary: promptLibrary,
+		recordings:    recordings,
+		tools:         NewToolRegistry(),
 		emitEvent:     emitEvent,
 	}
+	m.registerBuiltinTools()
+	return m
+}
+
+// Tools 返回工具注册表，可注册额外的工具（下次开始会话时生效）
+func (m *LiveSessionManager) Tools() *ToolRegistry {
+	return m.tools
+}
+
+// ConfirmToolCall 答复 live:tool-confirm 事件中的确认请求
+func (m *LiveSessionManager) ConfirmToolCall(id string, approved bool) error {
+	return m.confirmations.resolve(id, approved)
+}
+
+// SetCaptureSource 替换模型请求截图时使用的截图来源（如图片文件），为空恢复默认
+func (m *LiveSessionManager) SetCaptureSource(source screen.CaptureSource) {
+	m.sourceMu.Lock()
+	defer m.sourceMu.Unlock()
+	m.captureSource = source
 }
 
 // Start 启动 Live API 会话
@@ -91,16 +130,15 @@ func (m *LiveSessionManager) Start() error {
 	cfg := m.configManager.Get()
 
 	// 检查 Provider 是否支持 Live
-	provider := m.llmService.GetProvider()
-	liveProvider, ok := provider.(llm.LiveProvider)
-	if !ok {
-		return &liveError{"当前模型不支持 Live API"}
+	liveProvider, err := m.liveProvider(cfg)
+	if err != nil {
+		return err
 	}
 
 	m.emitEvent("live:status", "connecting")
 
 	// 连接 Live Session
-	liveCfg := llm.GetLiveConfig(cfg)
+	liveCfg := m.liveConfig(cfg)
 	session, err := liveProvider.ConnectLive(m.ctx, liveCfg)
 	if err != nil {
 		logger.Println("[Start] liveApi连接服务器失败", err)
@@ -115,7 +153,7 @@ func (m *LiveSessionManager) Start() error {
 	m.state.Store(int32(StateNormal))
 
 	// 初始化音频采集
-	m.audioCapture, err = audio.NewLoopbackCapture(nil)
+	m.audioCapture, err = newAudioCapture(cfg)
 	if err != nil {
 		logger.Printf("[Start] 音频采集初始化失败: %v", err)
 		session.Close()
@@ -124,6 +162,14 @@ func (m *LiveSessionManager) Start() error {
 		return &liveError{"音频采集初始化失败: " + err.Error()}
 	}
 
+	// 采集端按当前服务需要的采样率输出
+	m.audioCapture.SetSampleRate(llm.InputSampleRate(session))
+
+	// 设备打开、断开、切换时通知前端
+	m.audioCapture.SetDeviceListener(func(event audio.DeviceEvent) {
+		m.emitEvent("live:audio-device", event)
+	})
+
 	if err := m.audioCapture.Start(); err != nil {
 		logger.Printf("[Start] 音频采集启动失败: %v", err)
 		m.audioCapture.Close()
@@ -134,12 +180,24 @@ func (m *LiveSessionManager) Start() error {
 		return &liveError{"音频采集启动失败: " + err.Error()}
 	}
 
+	m.lastSpeaker = ""
+
+	// 开始录音，失败不影响会话
+	if cfg.RecordLive {
+		recording, err := m.recordings.Begin(llm.InputSampleRate(session), cfg.Model, cfg.RecordOpus)
+		if err != nil {
+			logger.Printf("[Start] 创建录音失败: %v", err)
+		} else {
+			m.recording.Store(recording)
+		}
+	}
+
 	// 初始化 context 和 errorChan
 	m.cancelCtx, m.cancelFunc = context.WithCancel(m.ctx)
 	m.errorChan = make(chan error, 4)
 
 	// 初始化并启动问题导图处理器（每3轮触发一次总结，使用 cancelCtx 统一控制）
-	m.graph = NewGraph(m.cancelCtx, m.configManager, m.llmService, m.emitEvent, 3)
+	m.graph = NewGraph(m.cancelCtx, m.configManager, m.llmService, m.promptLibrary, m.emitEvent, 3)
 	m.graph.Start()
 
 	// 启动错误监听协程
@@ -148,15 +206,103 @@ func (m *LiveSessionManager) Start() error {
 
 	// 启动音频发送协程 (不再传入 session，而是动态获取)
 	m.wg.Add(1)
-	go m.audioSender(m.audioCapture.GetAudioChannel())
+	go m.audioSender(m.audioCapture.GetAudioChannel(), newVAD(cfg))
 
 	// 启动接收协程
 	m.wg.Add(1)
 	go m.receiveLoop()
 
+	// 启动音频统计协程
+	m.sendStats.take()
+	m.wg.Add(1)
+	go m.statsLoop(m.audioCapture)
+
 	return nil
 }
 
+// newAudioCapture 根据配置创建音频采集器：仅系统声音，或系统声音 + 麦克风
+func newAudioCapture(cfg config.Config) (audio.Capture, error) {
+	if cfg.LiveMicrophone {
+		capture, err := audio.NewDualCapture(cfg.AudioDeviceID, cfg.LiveMicGain)
+		if err != nil {
+			return nil, err
+		}
+		return capture, nil
+	}
+	capture, err := audio.NewLoopbackCaptureForDevice(cfg.AudioDeviceID)
+	if err != nil {
+		return nil, err
+	}
+	return capture, nil
+}
+
+// liveProvider 获取实时会话的 Provider
+// 开启转写管线时使用语音转写 + 当前文本模型，否则要求当前 Provider 支持 Live API
+func (m *LiveSessionManager) liveProvider(cfg config.Config) (llm.LiveProvider, error) {
+	provider := m.llmService.GetProvider()
+	if cfg.LivePipeline {
+		transcriber := llm.NewTranscriber(cfg.TranscribeURL, cfg.TranscribeAPIKey, cfg.TranscribeModel)
+		return NewPipelineProvider(provider, transcriber, vadConfig(cfg), cfg.TranscribeLanguage), nil
+	}
+	liveProvider, ok := provider.(llm.LiveProvider)
+	if !ok {
+		return nil, &liveError{"当前模型不支持 Live API，可在设置中开启语音转写管线"}
+	}
+	return liveProvider, nil
+}
+
+// newVAD 根据配置创建本地语音检测器，未开启时返回 nil
+func newVAD(cfg config.Config) *audio.VAD {
+	if cfg.LiveVADMode == config.VADModeOff {
+		return nil
+	}
+	return audio.NewVAD(vadConfig(cfg))
+}
+
+// vadConfig 从配置读取语音检测参数
+func vadConfig(cfg config.Config) audio.VADConfig {
+	return audio.VADConfig{
+		Threshold:  cfg.VADThreshold,
+		MaxZCR:     cfg.VADMaxZCR,
+		HangoverMs: cfg.VADHangoverMs,
+		PreRollMs:  cfg.VADPreRollMs,
+	}
+}
+
+// transcriptSpeaker 根据最近的音量推测转写文本的说话人（见 audio.DualCapture.Speaker）
+// 只采集系统声音时都视为对方；双方都静音时沿用上一段的说话人
+func (m *LiveSessionManager) transcriptSpeaker() string {
+	m.mu.Lock()
+	detector, ok := m.audioCapture.(audio.SpeakerDetector)
+	m.mu.Unlock()
+	if !ok {
+		return audio.SpeakerOther
+	}
+	if speaker := detector.Speaker(speakerWindow); speaker != "" {
+		m.lastSpeaker = speaker
+	}
+	if m.lastSpeaker == "" {
+		return audio.SpeakerOther
+	}
+	return m.lastSpeaker
+}
+
+// liveConfig 根据配置生成 LiveConfig，系统指令使用 live 模板渲染并附加回答语言要求
+func (m *LiveSessionManager) liveConfig(cfg config.Config) *llm.LiveConfig {
+	liveCfg := llm.GetLiveConfig(cfg)
+	instruction := m.promptLibrary.RenderActive(prompts.KindLive, cfg.PromptTemplates, prompts.VarsFromConfig(cfg))
+	liveCfg.SystemInstruction = prompts.WithAnswerLanguage(instruction, cfg)
+	// 转写管线直接调用文本模型回答，不处理工具调用
+	if cfg.LivePipeline {
+		if tools := m.tools.Declarations(cfg); len(tools) > 0 {
+			logger.Printf("[Pipeline] 转写管线不支持工具调用，忽略 %d 个工具", len(tools))
+		}
+		return liveCfg
+	}
+	liveCfg.Tools = m.tools.Declarations(cfg)
+	return liveCfg
+}
+
 // Stop 停止 Live API 会话（外部调用）
 func (m *LiveSessionManager) Stop() {
 	// 设置停止状态
@@ -204,6 +350,11 @@ func (m *LiveSessionManager) cleanup() {
 		m.audioCapture = nil
 	}
 
+	// 保存录音
+	if recording := m.recording.Swap(nil); recording != nil {
+		go m.finishRecording(recording)
+	}
+
 	// 关闭会话
 	if session := m.session.Load(); session != nil {
 		logger.Println("[cleanup] Live: 关闭会话")
@@ -212,6 +363,16 @@ func (m *LiveSessionManager) cleanup() {
 	}
 }
 
+// finishRecording 保存录音并通知前端
+func (m *LiveSessionManager) finishRecording(recording *Recording) {
+	info, err := recording.Close()
+	if err != nil {
+		logger.Printf("[cleanup] Live: 保存录音失败: %v", err)
+		return
+	}
+	m.emitEvent("live:recording-saved", info)
+}
+
 // errorWatcher 监听错误通道，统一处理所有不可恢复的错误
 // 这是唯一的错误处理入口，所有运行时错误都通过 errorChan 发送到这里
 func (m *LiveSessionManager) errorWatcher() {
@@ -251,11 +412,13 @@ func (m *LiveSessionManager) IsActive() bool {
 }
 
 // audioSender 从音频 channel 读取数据并发送给 Live Session
-func (m *LiveSessionManager) audioSender(audioChan <-chan []byte) {
+// vad 不为空时只发送有人说话的音频，并在说话开始/结束时通知前端和会话
+func (m *LiveSessionManager) audioSender(audioChan <-chan []byte, vad *audio.VAD) {
 	defer m.wg.Done()
 
 	logger.Println("[audioSender] Live: 音频发送协程已启动")
 	failCount := 0
+	var lastSession *llm.LiveSession // 重连后需要给新会话补发说话开始信号
 
 	for {
 		select {
@@ -269,6 +432,9 @@ func (m *LiveSessionManager) audioSender(audioChan <-chan []byte) {
 				return
 			}
 
+			// 录音保存完整的采集音频（不经过 VAD）
+			m.recording.Load().WriteAudio(audioData)
+
 			// 如果正在重连，等待重连完成
 			for m.state.Load() == int32(StateReconnecting) {
 				failCount = 0 // 重连时重置失败计数
@@ -286,7 +452,39 @@ func (m *LiveSessionManager) audioSender(audioChan <-chan []byte) {
 				continue
 			}
 
-			if err := (*session).SendAudio(audioData); err != nil {
+			if vad != nil && lastSession != nil && session != lastSession && vad.Speaking() {
+				if as, ok := (*session).(llm.ActivitySession); ok {
+					_ = as.SendActivityStart()
+				}
+			}
+			lastSession = session
+
+			packets, event := [][]byte{audioData}, audio.VADNone
+			if vad != nil {
+				packets, event = vad.Process(audioData)
+			}
+			if event == audio.VADSpeechStart {
+				m.signalActivity(*session, true)
+			}
+
+			var err error
+			for _, packet := range packets {
+				sendStart := time.Now()
+				if err = (*session).SendAudio(packet); err != nil {
+					m.sendStats.fail()
+					break
+				}
+				m.sendStats.sent(time.Since(sendStart))
+			}
+			if len(packets) == 0 {
+				m.sendStats.skip()
+			}
+
+			if event == audio.VADSpeechEnd {
+				m.signalActivity(*session, false)
+			}
+
+			if err != nil {
 				failCount++
 				logger.Printf("[audioSender] Live: 发送音频失败 (%d/3): %v", failCount, err)
 
@@ -308,6 +506,29 @@ func (m *LiveSessionManager) audioSender(audioChan <-chan []byte) {
 	}
 }
 
+// signalActivity 通知前端本地检测到说话开始/结束，会话支持时同时通知服务端
+func (m *LiveSessionManager) signalActivity(session llm.LiveSession, start bool) {
+	status := "end"
+	if start {
+		status = "start"
+	}
+	m.emitEvent("live:vad", status)
+
+	as, ok := session.(llm.ActivitySession)
+	if !ok {
+		return
+	}
+	var err error
+	if start {
+		err = as.SendActivityStart()
+	} else {
+		err = as.SendActivityEnd()
+	}
+	if err != nil {
+		logger.Printf("[audioSender] Live: 发送说话信号 (%s) 失败: %v", status, err)
+	}
+}
+
 // receiveLoop 接收 Live 消息的循环
 func (m *LiveSessionManager) receiveLoop() {
 	defer m.wg.Done()
@@ -382,6 +603,7 @@ func (m *LiveSessionManager) receiveLoop() {
 		case llm.LiveInterrupted:
 			logger.Println("[receiveLoop] 检测到打断")
 			m.emitEvent("live:Interrupted", msg.Text)
+			m.recording.Load().AddEvent(RecordInterrupted, "", "")
 			// 打断时，清空当前轮次缓存
 			m.roundMu.Lock()
 			m.currentQuestion.Reset()
@@ -389,11 +611,15 @@ func (m *LiveSessionManager) receiveLoop() {
 			m.roundMu.Unlock()
 
 		case llm.LiveMsgTranscript:
-			m.emitEvent("live:transcript", msg.Text)
-			// 累积问题文本
-			m.roundMu.Lock()
-			m.currentQuestion.WriteString(msg.Text)
-			m.roundMu.Unlock()
+			speaker := m.transcriptSpeaker()
+			m.emitEvent("live:transcript", msg.Text, speaker)
+			m.recording.Load().AddEvent(RecordTranscript, speaker, msg.Text)
+			// 累积问题文本（自己说的话不算问题）
+			if speaker == audio.SpeakerOther {
+				m.roundMu.Lock()
+				m.currentQuestion.WriteString(msg.Text)
+				m.roundMu.Unlock()
+			}
 
 		case llm.LiveMsgInterviewerDone:
 			logger.Println("[receiveLoop] Live: 面试官说话结束")
@@ -401,20 +627,25 @@ func (m *LiveSessionManager) receiveLoop() {
 
 		case llm.LiveMsgAIText:
 			m.emitEvent("live:ai-text", msg.Text)
+			m.recording.Load().AddEvent(RecordAnswer, "", msg.Text)
 			// 累积回答文本
 			m.roundMu.Lock()
 			m.currentAnswer.WriteString(msg.Text)
 			m.roundMu.Unlock()
 
 		case llm.LiveMsgToolCall:
-			logger.Printf("[receiveLoop] Live: 工具调用 %s (ID=%s)", msg.ToolName, msg.ToolID)
-			if msg.ToolName == "get_screenshot" {
-				m.handleScreenshot(*session, msg.ToolID)
-			}
+			logger.Printf("[receiveLoop] Live: 工具调用 %s (ID=%s) %s", msg.ToolName, msg.ToolID, msg.ToolArgs)
+			// 并发执行，慢的工具不阻塞接收和同一轮的其他调用
+			m.wg.Add(1)
+			go func(session llm.LiveSession, call *llm.LiveMessage) {
+				defer m.wg.Done()
+				m.tools.Dispatch(m.cancelCtx, session, m.configManager.Get(), call)
+			}(*session, msg)
 
 		case llm.LiveMsgDone:
 			logger.Println("[receiveLoop] Live: 对话轮完成")
 			m.emitEvent("live:done")
+			m.recording.Load().AddEvent(RecordDone, "", "")
 			// 一轮对话完成，推送给 Graph
 			m.roundMu.Lock()
 			question := m.currentQuestion.String()
@@ -468,16 +699,14 @@ func (m *LiveSessionManager) handleGoAway(oldSession llm.LiveSession) {
 	oldSession.Close()
 
 	// 获取 provider
-	provider := m.llmService.GetProvider()
-	liveProvider, ok := provider.(llm.LiveProvider)
-	if !ok {
-		m.errorChan <- &liveError{"Provider 不支持 Live API"}
+	liveProvider, err := m.liveProvider(m.configManager.Get())
+	if err != nil {
+		m.errorChan <- err
 		return
 	}
 
 	// 重连尝试
 	var newSession llm.LiveSession
-	var err error
 
 	for attempt := 1; attempt <= maxReconnectAttempts; attempt++ {
 		// 每次循环都检查是否已取消
@@ -491,7 +720,7 @@ func (m *LiveSessionManager) handleGoAway(oldSession llm.LiveSession) {
 		logger.Printf("[handleGoAway] Live: 重连尝试 %d/%d", attempt, maxReconnectAttempts)
 
 		cfg := m.configManager.Get()
-		liveCfg := llm.GetLiveConfig(cfg)
+		liveCfg := m.liveConfig(cfg)
 		liveCfg.ResumeToken = resumeToken // 使用恢复令牌
 
 		newSession, err = liveProvider.ConnectLive(m.ctx, liveCfg)
@@ -546,46 +775,3 @@ type liveError struct {
 func (e *liveError) Error() string {
 	return e.msg
 }
-
-// handleScreenshot 处理 Live API 的截图请求
-func (m *LiveSessionManager) handleScreenshot(session llm.LiveSession, toolID string) {
-	cfg := m.configManager.Get()
-
-	preview, err := m.screenService.CapturePreview(
-		cfg.CompressionQuality,
-		cfg.Sharpening,
-		cfg.Grayscale,
-		cfg.NoCompression,
-		cfg.ScreenshotMode,
-	)
-	if err != nil {
-		logger.Printf("[handleScreenshot] Live 截图失败: %v", err)
-		_ = session.SendToolResponse(toolID, "截图失败: "+err.Error())
-		return
-	}
-
-	// 解析 data URL 格式: data:image/jpeg;base64,xxxxx
-	base64Str := preview.Base64
-	mimeType := "image/jpeg" // 默认
-
-	if strings.HasPrefix(base64Str, "data:") {
-		if idx := strings.Index(base64Str, ";base64,"); idx > 5 {
-			mimeType = base64Str[5:idx]
-			base64Str = base64Str[idx+8:]
-		}
-	}
-
-	imageData, err := base64.StdEncoding.DecodeString(base64Str)
-	if err != nil {
-		logger.Printf("[handleScreenshot] Live Base64解码失败: %v", err)
-		_ = session.SendToolResponse(toolID, "图片解码失败")
-		return
-	}
-
-	err = session.SendToolResponseWithImage(toolID, imageData, mimeType)
-	if err != nil {
-		logger.Printf("[handleScreenshot] Live 发送截图失败: %v", err)
-	} else {
-		logger.Printf("[handleScreenshot] Live: 已发送屏幕截图给模型 (%d bytes, %s)", len(imageData), mimeType)
-	}
-}
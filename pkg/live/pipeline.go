@@ -0,0 +1,292 @@
+package live
+
+import (
+	"Q-Solver/pkg/audio"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// 转写管线参数
+const (
+	pipelineSampleRate   = 16000 // Whisper 的输入采样率
+	pipelineMinSegmentMs = 400   // 短于该时长的语音段不转写（咳嗽、按键声）
+	pipelineMaxSegmentMs = 30000 // 一直有人说话时最长多久强制转写一次
+	pipelineMaxHistory   = 10    // 保留最近多少轮问答作为上下文
+	pipelineMaxFailures  = 3     // 连续失败多少次后结束会话
+)
+
+var errPipelineClosed = errors.New("会话已关闭")
+
+// PipelineProvider 用语音转写 + 普通文本模型实现 LiveProvider
+// 音频经本地语音检测切分成语音段，交给 Whisper 等转写服务，转写结果再通过 GenerateContentStream 流式回答
+// 输出与 Live API 相同的 LiveMessage，因此任何文本模型都可以驱动实时面板
+type PipelineProvider struct {
+	provider    llm.Provider
+	transcriber *llm.Transcriber
+	vadConfig   audio.VADConfig
+	language    string
+}
+
+// NewPipelineProvider 创建转写管线
+func NewPipelineProvider(provider llm.Provider, transcriber *llm.Transcriber, vadConfig audio.VADConfig, language string) *PipelineProvider {
+	return &PipelineProvider{
+		provider:    provider,
+		transcriber: transcriber,
+		vadConfig:   vadConfig,
+		language:    language,
+	}
+}
+
+// ConnectLive 创建管线会话（不需要建立连接，也不支持会话恢复）
+func (p *PipelineProvider) ConnectLive(ctx context.Context, cfg *llm.LiveConfig) (llm.LiveSession, error) {
+	if p.transcriber.URL() == "" {
+		return nil, &liveError{"语音转写管线需要先在设置中填写转写接口地址"}
+	}
+	sessionCtx, cancel := context.WithCancel(ctx)
+	s := &pipelineSession{
+		provider:    p,
+		instruction: cfg.SystemInstruction,
+		ctx:         sessionCtx,
+		cancel:      cancel,
+		vad:         audio.NewVAD(p.vadConfig),
+		segments:    make(chan []byte, 4),
+		messages:    make(chan *llm.LiveMessage, 64),
+	}
+	go s.worker()
+	logger.Println("[Pipeline] 转写管线会话已创建")
+	return s, nil
+}
+
+// pipelineSession 转写管线会话
+type pipelineSession struct {
+	provider    *PipelineProvider
+	instruction string
+	ctx         context.Context
+	cancel      context.CancelFunc
+
+	// 语音分段（SendAudio 调用方）
+	mu      sync.Mutex
+	vad     *audio.VAD
+	segment []byte
+
+	// 回答（worker 协程）
+	answerMu     sync.Mutex
+	answerCancel context.CancelFunc
+	history      []llm.Message
+	pending      string // 被打断或尚未回答的问题，与下一段转写合并后回答
+	failures     int
+
+	segments chan []byte
+	messages chan *llm.LiveMessage
+}
+
+// SendAudio 输入 16kHz 单声道 PCM，检测到说话结束时提交语音段
+func (s *pipelineSession) SendAudio(data []byte) error {
+	if s.ctx.Err() != nil {
+		return errPipelineClosed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	packets, event := s.vad.Process(data)
+	if event == audio.VADSpeechStart {
+		// 对方又开始说话，正在生成的回答作废
+		s.interrupt()
+	}
+	for _, packet := range packets {
+		s.segment = append(s.segment, packet...)
+	}
+	if event == audio.VADSpeechEnd || segmentMs(s.segment) >= pipelineMaxSegmentMs {
+		s.flush()
+	}
+	return nil
+}
+
+// SendActivityStart 客户端检测到开始说话（管线自己做语音检测，只需打断当前回答）
+func (s *pipelineSession) SendActivityStart() error {
+	s.interrupt()
+	return nil
+}
+
+// SendActivityEnd 客户端检测到说话结束，立即提交当前语音段
+// 开启本地语音检测的 gate 模式时，静音期间不会收到音频，只能依赖这个信号结束语音段
+func (s *pipelineSession) SendActivityEnd() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush()
+	return nil
+}
+
+// InputSampleRate 转写服务使用 16kHz 音频
+func (s *pipelineSession) InputSampleRate() int {
+	return pipelineSampleRate
+}
+
+// Receive 接收转写和回答消息
+func (s *pipelineSession) Receive() (*llm.LiveMessage, error) {
+	select {
+	case msg := <-s.messages:
+		return msg, nil
+	case <-s.ctx.Done():
+		return nil, errPipelineClosed
+	}
+}
+
+// SendToolResponse 管线不声明工具（见 liveConfig），不会收到工具调用
+func (s *pipelineSession) SendToolResponse(toolID string, result string) error {
+	return nil
+}
+
+// SendToolResponseWithImage 管线不声明工具，不会收到工具调用
+func (s *pipelineSession) SendToolResponseWithImage(toolID string, imageData []byte, mimeType string) error {
+	return nil
+}
+
+// Close 关闭会话，正在进行的转写和回答随之取消
+func (s *pipelineSession) Close() error {
+	s.cancel()
+	return nil
+}
+
+// flush 提交当前语音段（调用方持有 mu）
+func (s *pipelineSession) flush() {
+	segment := s.segment
+	s.segment = nil
+	if segmentMs(segment) < pipelineMinSegmentMs {
+		return
+	}
+	select {
+	case s.segments <- segment:
+	default:
+		logger.Println("[Pipeline] 转写队列已满，丢弃语音段")
+	}
+}
+
+// interrupt 取消正在生成的回答
+func (s *pipelineSession) interrupt() {
+	s.answerMu.Lock()
+	defer s.answerMu.Unlock()
+	if s.answerCancel != nil {
+		s.answerCancel()
+	}
+}
+
+// speaking 是否还有人在说话或有待转写的语音段
+func (s *pipelineSession) speaking() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.vad.Speaking() || len(s.segments) > 0
+}
+
+// worker 依次转写语音段并回答
+func (s *pipelineSession) worker() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case segment := <-s.segments:
+			s.handleSegment(segment)
+		}
+	}
+}
+
+// handleSegment 转写一个语音段，对方说完后生成回答
+func (s *pipelineSession) handleSegment(segment []byte) {
+	wav := audio.EncodeWAV(segment, pipelineSampleRate, 1)
+	text, err := s.provider.transcriber.Transcribe(s.ctx, wav, s.provider.language)
+	if err != nil {
+		s.fail("转写失败", err)
+		return
+	}
+	s.failures = 0
+	if text == "" {
+		return
+	}
+
+	s.push(&llm.LiveMessage{Type: llm.LiveMsgTranscript, Text: text})
+	s.pending = strings.TrimSpace(s.pending + " " + text)
+
+	// 问题可能被停顿分成几段，等对方说完再回答
+	if s.speaking() {
+		return
+	}
+	s.push(&llm.LiveMessage{Type: llm.LiveMsgInterviewerDone})
+	s.answer()
+}
+
+// answer 流式回答 pending 中的问题，被打断时保留问题等待与下一段合并
+func (s *pipelineSession) answer() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.answerMu.Lock()
+	s.answerCancel = cancel
+	s.answerMu.Unlock()
+	defer func() {
+		s.answerMu.Lock()
+		s.answerCancel = nil
+		s.answerMu.Unlock()
+		cancel()
+	}()
+
+	question := s.pending
+	messages := make([]llm.Message, 0, len(s.history)+2)
+	if s.instruction != "" {
+		messages = append(messages, llm.NewSystemMessage(s.instruction))
+	}
+	messages = append(messages, s.history...)
+	messages = append(messages, llm.NewUserMessage(question))
+
+	reply, err := s.provider.provider.GenerateContentStream(ctx, messages, func(chunk llm.StreamChunk) {
+		if chunk.Type == llm.ChunkContent && chunk.Content != "" {
+			s.push(&llm.LiveMessage{Type: llm.LiveMsgAIText, Text: chunk.Content})
+		}
+	})
+	if s.ctx.Err() != nil {
+		return
+	}
+	if ctx.Err() != nil {
+		logger.Println("[Pipeline] 回答被打断")
+		s.push(&llm.LiveMessage{Type: llm.LiveInterrupted})
+		return
+	}
+	if err != nil {
+		s.fail("生成回答失败", err)
+		return
+	}
+
+	s.failures = 0
+	s.pending = ""
+	s.history = append(s.history, llm.NewUserMessage(question), llm.NewAssistantMessage(reply.Content))
+	if len(s.history) > pipelineMaxHistory*2 {
+		s.history = s.history[len(s.history)-pipelineMaxHistory*2:]
+	}
+	s.push(&llm.LiveMessage{Type: llm.LiveMsgDone})
+}
+
+// fail 记录失败，连续失败过多时以错误结束会话
+func (s *pipelineSession) fail(action string, err error) {
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.failures++
+	logger.Printf("[Pipeline] %s (%d/%d): %v", action, s.failures, pipelineMaxFailures, err)
+	if s.failures >= pipelineMaxFailures {
+		s.push(&llm.LiveMessage{Type: llm.LiveMsgError, Text: action + ": " + err.Error()})
+	}
+}
+
+// push 发送消息给 Receive
+func (s *pipelineSession) push(msg *llm.LiveMessage) {
+	select {
+	case s.messages <- msg:
+	case <-s.ctx.Done():
+	}
+}
+
+// segmentMs 语音段时长（毫秒）
+func segmentMs(segment []byte) int {
+	return len(segment) / audio.BytesPerSample * 1000 / pipelineSampleRate
+}
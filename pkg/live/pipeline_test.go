@@ -0,0 +1,38 @@
+package live
+
+import (
+	"Q-Solver/pkg/audio"
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/prompts"
+	"context"
+	"testing"
+)
+
+func TestPipelineConnectRequiresTranscribeURL(t *testing.T) {
+	provider := NewPipelineProvider(nil, llm.NewTranscriber("", "", ""), audio.VADConfig{}, "")
+	if _, err := provider.ConnectLive(context.Background(), &llm.LiveConfig{}); err == nil {
+		t.Fatal("未配置转写接口地址时应拒绝创建会话")
+	}
+
+	provider = NewPipelineProvider(nil, llm.NewTranscriber("http://127.0.0.1:8080/inference", "", ""), audio.VADConfig{}, "")
+	session, err := provider.ConnectLive(context.Background(), &llm.LiveConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Close()
+}
+
+func TestLiveConfigSkipsToolsInPipelineMode(t *testing.T) {
+	m := NewLiveSessionManager(context.Background(), nil, nil, nil, prompts.NewLibrary(t.TempDir()), nil, func(string, ...any) {})
+	cfg := config.NewDefaultConfig()
+	cfg.NotesDir = t.TempDir()
+	if len(m.liveConfig(cfg).Tools) == 0 {
+		t.Fatal("Live API 模式下应声明工具")
+	}
+
+	cfg.LivePipeline = true
+	if tools := m.liveConfig(cfg).Tools; len(tools) != 0 {
+		t.Errorf("转写管线模式下声明了 %d 个工具", len(tools))
+	}
+}
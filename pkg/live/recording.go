@@ -0,0 +1,322 @@
+package live
+
+import (
+	"Q-Solver/pkg/audio"
+	"Q-Solver/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 录音事件类型
+const (
+	RecordTranscript  = "transcript"  // 语音转写
+	RecordAnswer      = "answer"      // AI 回答
+	RecordDone        = "done"        // 一轮对话完成
+	RecordInterrupted = "interrupted" // 回答被打断
+)
+
+const (
+	recordingExt = ".json"
+	idTimeLayout = "20060102-150405"
+)
+
+// recordingFileExts 一个录音可能包含的所有文件
+var recordingFileExts = []string{recordingExt, ".wav", ".ogg"}
+
+var ErrRecordingNotFound = errors.New("录音不存在")
+
+// RecordedEvent 录音期间的一条事件，Offset 为相对录音开始的毫秒数（与音频文件位置对齐）
+type RecordedEvent struct {
+	OffsetMs int64  `json:"offsetMs"`
+	Type     string `json:"type"`
+	Speaker  string `json:"speaker,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+// RecordingInfo 录音概要（会话列表）
+type RecordingInfo struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Model      string    `json:"model,omitempty"`
+	SampleRate int       `json:"sampleRate"`
+	Audio      string    `json:"audio"`          // WAV 文件完整路径
+	Opus       string    `json:"opus,omitempty"` // Opus/Ogg 文件完整路径（转换成功时）
+	Rounds     int       `json:"rounds"`
+}
+
+// RecordingDetail 录音详情（JSON 附属文件的内容）
+type RecordingDetail struct {
+	RecordingInfo
+	Events []RecordedEvent `json:"events"`
+}
+
+// RecordingStore 磁盘上的 Live 会话录音
+// 目录结构: <dir>/<id>.wav 音频，<id>.json 转写和回答事件，<id>.ogg 可选的 Opus 压缩版本
+type RecordingStore struct {
+	dir string
+}
+
+// NewRecordingStore 创建录音存储
+func NewRecordingStore(dir string) *RecordingStore {
+	return &RecordingStore{dir: dir}
+}
+
+// List 列出所有录音，最新的排在前面
+func (s *RecordingStore) List() ([]RecordingInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []RecordingInfo{}, nil
+		}
+		return nil, err
+	}
+
+	result := []RecordingInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), recordingExt) {
+			continue
+		}
+		detail, err := s.Get(strings.TrimSuffix(entry.Name(), recordingExt))
+		if err != nil {
+			logger.Printf("读取录音 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		result = append(result, detail.RecordingInfo)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+	return result, nil
+}
+
+// Get 读取录音详情
+func (s *RecordingStore) Get(id string) (RecordingDetail, error) {
+	var detail RecordingDetail
+	path, err := s.path(id, recordingExt)
+	if err != nil {
+		return detail, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return detail, ErrRecordingNotFound
+		}
+		return detail, err
+	}
+	if err := json.Unmarshal(data, &detail); err != nil {
+		return detail, err
+	}
+	return detail, nil
+}
+
+// Delete 删除录音的所有文件
+func (s *RecordingStore) Delete(id string) error {
+	found := false
+	for _, ext := range recordingFileExts {
+		path, err := s.path(id, ext)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(path)
+		if err == nil {
+			found = true
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if !found {
+		return ErrRecordingNotFound
+	}
+	return nil
+}
+
+// path 录音文件路径，拒绝包含路径分隔符的 ID
+func (s *RecordingStore) path(id, ext string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("无效的录音 ID: %s", id)
+	}
+	return filepath.Join(s.dir, id+ext), nil
+}
+
+// newID 按开始时间生成录音 ID，同一秒内已有录音时追加序号（如 20240101-120000-2）
+func (s *RecordingStore) newID(start time.Time) string {
+	base := start.Format(idTimeLayout)
+	for n := 1; ; n++ {
+		id := base
+		if n > 1 {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		if !s.exists(id) {
+			return id
+		}
+	}
+}
+
+// exists 录音的任一文件是否已存在
+func (s *RecordingStore) exists(id string) bool {
+	for _, ext := range recordingFileExts {
+		path, err := s.path(id, ext)
+		if err != nil {
+			return true
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Begin 开始录制一个会话
+func (s *RecordingStore) Begin(sampleRate int, model string, opus bool) (*Recording, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	id := s.newID(start)
+	wavPath, err := s.path(id, ".wav")
+	if err != nil {
+		return nil, err
+	}
+	wav, err := audio.NewWAVWriter(wavPath, sampleRate, 1)
+	if err != nil {
+		return nil, err
+	}
+	jsonPath, _ := s.path(id, recordingExt)
+	return &Recording{
+		wav:      wav,
+		jsonPath: jsonPath,
+		opus:     opus,
+		detail: RecordingDetail{
+			RecordingInfo: RecordingInfo{
+				ID:         id,
+				StartedAt:  start,
+				Model:      model,
+				SampleRate: sampleRate,
+				Audio:      wavPath,
+			},
+			Events: []RecordedEvent{},
+		},
+		packetMs: audio.PacketDurationMs,
+	}, nil
+}
+
+// Recording 正在录制的会话，写入采集到的音频并记录转写和回答事件
+// 所有方法都可以在 nil 上调用（未开启录音时）
+type Recording struct {
+	mu       sync.Mutex
+	wav      *audio.WAVWriter
+	jsonPath string
+	opus     bool
+	detail   RecordingDetail
+	packetMs int64
+	closed   bool
+}
+
+// WriteAudio 写入一个采集到的数据包
+// 采集设备在没有声音时可能不输出数据（如 WASAPI Loopback），此时按实际经过的时间补齐静音，保证事件时间与音频对齐
+func (r *Recording) WriteAudio(packet []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	elapsed := time.Since(r.detail.StartedAt).Milliseconds()
+	written := r.wav.DurationMs()
+	if gap := elapsed - written - r.packetMs; gap > 2*r.packetMs {
+		bytesPerMs := int64(r.detail.SampleRate*audio.BytesPerSample) / 1000
+		_, _ = r.wav.Write(make([]byte, gap*bytesPerMs))
+	}
+	if _, err := r.wav.Write(packet); err != nil {
+		logger.Printf("[Recording] 写入音频失败: %v", err)
+	}
+}
+
+// AddEvent 记录一条事件，同类型同说话人的连续文本合并为一条
+func (r *Recording) AddEvent(eventType, speaker, text string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	events := r.detail.Events
+	if n := len(events); n > 0 && text != "" && events[n-1].Type == eventType && events[n-1].Speaker == speaker {
+		events[n-1].Text += text
+		return
+	}
+	if eventType == RecordDone {
+		r.detail.Rounds++
+	}
+	r.detail.Events = append(events, RecordedEvent{
+		OffsetMs: time.Since(r.detail.StartedAt).Milliseconds(),
+		Type:     eventType,
+		Speaker:  speaker,
+		Text:     text,
+	})
+}
+
+// Close 结束录制：关闭 WAV 文件、需要时转换为 Opus，最后写入 JSON 附属文件
+// 转换 Opus 可能耗时较长，调用方应在后台执行
+func (r *Recording) Close() (RecordingInfo, error) {
+	if r == nil {
+		return RecordingInfo{}, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return r.detail.RecordingInfo, nil
+	}
+	r.closed = true
+
+	r.detail.DurationMs = r.wav.DurationMs()
+	if err := r.wav.Close(); err != nil {
+		return r.detail.RecordingInfo, err
+	}
+	if r.opus {
+		if path, err := encodeOpus(r.detail.Audio); err != nil {
+			logger.Printf("[Recording] 转换 Opus 失败，仅保留 WAV: %v", err)
+		} else {
+			r.detail.Opus = path
+		}
+	}
+
+	data, err := json.MarshalIndent(r.detail, "", "  ")
+	if err != nil {
+		return r.detail.RecordingInfo, err
+	}
+	if err := os.WriteFile(r.jsonPath, data, 0644); err != nil {
+		return r.detail.RecordingInfo, err
+	}
+	logger.Printf("[Recording] 录音已保存: %s (%d 秒, %d 轮)", r.detail.ID, r.detail.DurationMs/1000, r.detail.Rounds)
+	return r.detail.RecordingInfo, nil
+}
+
+// encodeOpus 使用 ffmpeg 把 WAV 转换为 Opus/Ogg，未安装 ffmpeg 时返回错误
+func encodeOpus(wavPath string) (string, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("未找到 ffmpeg")
+	}
+	oggPath := strings.TrimSuffix(wavPath, filepath.Ext(wavPath)) + ".ogg"
+	cmd := exec.Command(ffmpeg, "-y", "-loglevel", "error", "-i", wavPath, "-c:a", "libopus", "-b:a", "24k", oggPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return oggPath, nil
+}
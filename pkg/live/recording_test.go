@@ -0,0 +1,62 @@
+package live
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecordingIDsAreUnique(t *testing.T) {
+	store := NewRecordingStore(t.TempDir())
+
+	// 同一秒内开始的多个会话不能互相覆盖
+	ids := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		recording, err := store.Begin(16000, "test-model", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recording.WriteAudio(make([]byte, 320))
+		recording.AddEvent(RecordTranscript, "interviewer", "你好")
+		recording.AddEvent(RecordDone, "", "")
+		info, err := recording.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids[info.ID] {
+			t.Fatalf("录音 ID 重复: %s", info.ID)
+		}
+		ids[info.ID] = true
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(ids) {
+		t.Fatalf("List 返回 %d 条录音, 期望 %d 条", len(list), len(ids))
+	}
+	for _, info := range list {
+		detail, err := store.Get(info.ID)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", info.ID, err)
+		}
+		if detail.Rounds != 1 || len(detail.Events) != 2 || detail.Events[0].Text != "你好" {
+			t.Errorf("录音 %s 内容 = %+v", info.ID, detail)
+		}
+	}
+}
+
+func TestRecordingStoreRejectsInvalidID(t *testing.T) {
+	store := NewRecordingStore(t.TempDir())
+	for _, id := range []string{"", "../config", `a\b`, "a/b"} {
+		if _, err := store.Get(id); err == nil {
+			t.Errorf("Get(%q) 应返回错误", id)
+		}
+		if err := store.Delete(id); err == nil {
+			t.Errorf("Delete(%q) 应返回错误", id)
+		}
+	}
+	if err := store.Delete("20240101-120000"); !errors.Is(err, ErrRecordingNotFound) {
+		t.Errorf("删除不存在的录音: %v, 期望 ErrRecordingNotFound", err)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package live
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// processGroup 解释器所在的进程组，结束时连同代码启动的子进程一起结束
+type processGroup struct {
+	pid int
+}
+
+// newProcessGroup 让解释器在新的进程组中启动，取消时向整个进程组发送 SIGKILL
+func newProcessGroup(cmd *exec.Cmd) (*processGroup, error) {
+	g := &processGroup{}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return g, nil
+}
+
+// attach 记录已启动的解释器（进程组在启动时已经建立）
+func (g *processGroup) attach(process *os.Process) error {
+	g.pid = process.Pid
+	return nil
+}
+
+// Close 结束进程组中剩余的进程（代码放到后台的子进程）
+func (g *processGroup) Close() {
+	if g.pid > 0 {
+		syscall.Kill(-g.pid, syscall.SIGKILL)
+	}
+}
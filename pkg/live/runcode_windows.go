@@ -0,0 +1,68 @@
+//go:build windows
+
+package live
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// NtResumeProcess 恢复以 CREATE_SUSPENDED 启动的进程（exec.Cmd 不提供主线程句柄）
+var procNtResumeProcess = windows.NewLazySystemDLL("ntdll.dll").NewProc("NtResumeProcess")
+
+// processGroup 解释器所在的作业对象，结束时连同代码启动的子进程一起结束
+type processGroup struct {
+	job windows.Handle
+}
+
+// newProcessGroup 创建关闭句柄时结束所有进程的作业对象，取消时结束整个作业
+func newProcessGroup(cmd *exec.Cmd) (*processGroup, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建作业对象失败: %v", err)
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	_, err = windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("设置作业对象失败: %v", err)
+	}
+
+	g := &processGroup{job: job}
+	// 挂起启动，加入作业后再恢复，避免代码在加入作业前创建子进程
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_SUSPENDED}
+	cmd.Cancel = func() error {
+		return windows.TerminateJobObject(job, 1)
+	}
+	return g, nil
+}
+
+// attach 把挂起的解释器加入作业后恢复运行，之后它创建的子进程自动属于同一作业
+func (g *processGroup) attach(process *os.Process) error {
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE|windows.PROCESS_SUSPEND_RESUME, false, uint32(process.Pid))
+	if err != nil {
+		return fmt.Errorf("打开进程失败: %v", err)
+	}
+	defer windows.CloseHandle(handle)
+	if err := windows.AssignProcessToJobObject(g.job, handle); err != nil {
+		return fmt.Errorf("加入作业对象失败: %v", err)
+	}
+	if status, _, _ := procNtResumeProcess.Call(uintptr(handle)); status != 0 {
+		return fmt.Errorf("恢复进程失败: NTSTATUS 0x%x", status)
+	}
+	return nil
+}
+
+// Close 关闭作业句柄，作业中剩余的进程随之结束
+func (g *processGroup) Close() {
+	windows.CloseHandle(g.job)
+}
@@ -0,0 +1,84 @@
+package live
+
+import (
+	"Q-Solver/pkg/audio"
+	"sync"
+	"time"
+)
+
+// AudioStats live:audio-stats 事件内容，用于排查“模型听不到声音”
+type AudioStats struct {
+	Sources          []audio.CaptureStats `json:"sources"`          // 各路采集的音量、丢包和缓冲情况
+	SentPackets      int                  `json:"sentPackets"`      // 区间内发送的数据包
+	SkippedPackets   int                  `json:"skippedPackets"`   // 区间内被语音检测判为静音未发送的数据包
+	SendErrors       int                  `json:"sendErrors"`       // 区间内发送失败次数
+	AvgSendLatencyMs float64              `json:"avgSendLatencyMs"` // SendAudio 平均耗时
+	MaxSendLatencyMs float64              `json:"maxSendLatencyMs"` // SendAudio 最大耗时
+}
+
+// sendStats 音频发送统计，audioSender 写入，statsLoop 定期取出
+type sendStats struct {
+	mu         sync.Mutex
+	sentCount  int
+	skipped    int
+	errors     int
+	totalDelay time.Duration
+	maxDelay   time.Duration
+}
+
+func (s *sendStats) sent(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sentCount++
+	s.totalDelay += delay
+	s.maxDelay = max(s.maxDelay, delay)
+}
+
+func (s *sendStats) skip() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped++
+}
+
+func (s *sendStats) fail() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors++
+}
+
+// take 取出区间统计并重置
+func (s *sendStats) take() AudioStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := AudioStats{
+		SentPackets:      s.sentCount,
+		SkippedPackets:   s.skipped,
+		SendErrors:       s.errors,
+		MaxSendLatencyMs: float64(s.maxDelay) / float64(time.Millisecond),
+	}
+	if s.sentCount > 0 {
+		stats.AvgSendLatencyMs = float64(s.totalDelay) / float64(s.sentCount) / float64(time.Millisecond)
+	}
+	s.sentCount, s.skipped, s.errors, s.totalDelay, s.maxDelay = 0, 0, 0, 0, 0
+	return stats
+}
+
+// statsLoop 定期发送 live:audio-stats 事件
+func (m *LiveSessionManager) statsLoop(capture audio.Capture) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(audioStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.cancelCtx.Done():
+			return
+		case <-ticker.C:
+			stats := m.sendStats.take()
+			stats.Sources = capture.Stats()
+			m.emitEvent("live:audio-stats", stats)
+		}
+	}
+}
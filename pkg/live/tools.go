@@ -0,0 +1,216 @@
+package live
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultToolTimeout 工具未指定超时时间时使用的默认值
+const defaultToolTimeout = 10 * time.Second
+
+// ToolResult 工具执行结果，Image 不为空时作为图片返回给模型
+type ToolResult struct {
+	Text     string
+	Image    []byte
+	MimeType string
+}
+
+// ToolHandler 工具的执行函数，args 为模型传入的 JSON 参数（可能为空）
+type ToolHandler func(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error)
+
+// Tool 可供 Live 模型调用的工具
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any               // 参数的 JSON Schema，为空表示无参数
+	Timeout     time.Duration                // 为 0 时使用 defaultToolTimeout
+	Enabled     func(cfg config.Config) bool // 为空表示始终可用，否则仅在返回 true 时声明给模型
+	Handler     ToolHandler
+}
+
+// ToolRegistry Live 会话可用的工具，声明给任意 LiveProvider 并负责执行模型的调用
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+	order []string // 按注册顺序声明
+}
+
+// NewToolRegistry 创建空的工具注册表
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]Tool)}
+}
+
+// Register 注册工具，同名工具会被替换
+func (r *ToolRegistry) Register(tool Tool) error {
+	if tool.Name == "" || tool.Handler == nil {
+		return fmt.Errorf("工具名称和执行函数不能为空")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[tool.Name]; !ok {
+		r.order = append(r.order, tool.Name)
+	}
+	r.tools[tool.Name] = tool
+	return nil
+}
+
+// Declarations 当前配置下可用的工具声明
+func (r *ToolRegistry) Declarations(cfg config.Config) []llm.ToolDeclaration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	declarations := make([]llm.ToolDeclaration, 0, len(r.order))
+	for _, name := range r.order {
+		tool := r.tools[name]
+		if tool.Enabled != nil && !tool.Enabled(cfg) {
+			continue
+		}
+		declarations = append(declarations, llm.ToolDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  tool.Parameters,
+		})
+	}
+	return declarations
+}
+
+// lookup 查找当前配置下可用的工具
+func (r *ToolRegistry) lookup(name string, cfg config.Config) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	if !ok || (tool.Enabled != nil && !tool.Enabled(cfg)) {
+		return Tool{}, false
+	}
+	return tool, true
+}
+
+// Dispatch 执行一次工具调用并把结果发送给会话（阻塞直到发送完成，调用方应在单独的协程中执行）
+// 超时、出错或工具不存在时以文本告知模型，避免模型一直等待结果
+func (r *ToolRegistry) Dispatch(ctx context.Context, session llm.LiveSession, cfg config.Config, call *llm.LiveMessage) {
+	result, err := r.run(ctx, cfg, call)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		logger.Printf("[Tool] %s (ID=%s) 执行失败: %v", call.ToolName, call.ToolID, err)
+		result = ToolResult{Text: "执行失败: " + err.Error()}
+	}
+
+	if len(result.Image) > 0 {
+		err = session.SendToolResponseWithImage(call.ToolID, result.Image, result.MimeType)
+	} else {
+		err = session.SendToolResponse(call.ToolID, result.Text)
+	}
+	if err != nil {
+		logger.Printf("[Tool] %s (ID=%s) 发送结果失败: %v", call.ToolName, call.ToolID, err)
+		return
+	}
+	logger.Printf("[Tool] %s (ID=%s) 已返回结果", call.ToolName, call.ToolID)
+}
+
+// run 在超时时间内执行工具
+func (r *ToolRegistry) run(ctx context.Context, cfg config.Config, call *llm.LiveMessage) (ToolResult, error) {
+	tool, ok := r.lookup(call.ToolName, cfg)
+	if !ok {
+		return ToolResult{}, fmt.Errorf("未知工具 %s", call.ToolName)
+	}
+	timeout := tool.Timeout
+	if timeout <= 0 {
+		timeout = defaultToolTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result ToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := tool.Handler(ctx, cfg, json.RawMessage(call.ToolArgs))
+		done <- outcome{result, err}
+	}()
+
+	// 执行函数不响应 ctx 时也按时返回，结果丢弃
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return ToolResult{}, fmt.Errorf("超过 %v 未完成", timeout)
+	}
+}
+
+// ToolConfirmRequest 需要用户确认后才能执行的工具调用（live:tool-confirm 事件）
+type ToolConfirmRequest struct {
+	ID     string `json:"id"`
+	Tool   string `json:"tool"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"` // 将要执行的内容，如代码
+}
+
+// toolConfirmations 等待前端确认的工具调用
+type toolConfirmations struct {
+	mu      sync.Mutex
+	seq     atomic.Int64
+	pending map[string]chan bool
+}
+
+// request 发出确认请求并等待用户答复，超时或会话结束视为拒绝
+func (c *toolConfirmations) request(ctx context.Context, emitEvent func(string, ...any), req ToolConfirmRequest) bool {
+	req.ID = fmt.Sprintf("confirm-%d", c.seq.Add(1))
+	reply := make(chan bool, 1)
+	c.mu.Lock()
+	if c.pending == nil {
+		c.pending = make(map[string]chan bool)
+	}
+	c.pending[req.ID] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	emitEvent("live:tool-confirm", req)
+	select {
+	case approved := <-reply:
+		return approved
+	case <-ctx.Done():
+		// 通知前端收起已失效的确认框
+		emitEvent("live:tool-confirm-expired", req.ID)
+		return false
+	}
+}
+
+// resolve 用户答复确认请求
+func (c *toolConfirmations) resolve(id string, approved bool) error {
+	c.mu.Lock()
+	reply, ok := c.pending[id]
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("确认请求不存在或已过期")
+	}
+	select {
+	case reply <- approved:
+	default:
+	}
+	return nil
+}
+
+// decodeArgs 解析工具参数，参数为空时保持零值
+func decodeArgs(args json.RawMessage, v any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("参数格式错误: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,467 @@
+package live
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/screen"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxToolTextRunes      = 4000             // 返回给模型的文本上限
+	maxNoteFileBytes      = 1 << 20          // 跳过超过 1MB 的笔记文件
+	maxNoteHits           = 8                // 笔记搜索最多返回的片段数
+	noteContextLines      = 2                // 命中行前后保留的行数
+	runCodeTimeout        = 20 * time.Second // 代码运行时间上限
+	runCodeConfirmTimeout = 60 * time.Second // 等待用户确认运行代码的时间
+	runCodeWaitDelay      = 2 * time.Second  // 解释器退出或超时后最多再等多久输出管道关闭
+	maxRunOutputBytes     = 64 << 10         // 代码输出最多保留的字节数
+	screenshotTimeout     = 15 * time.Second
+	noteSearchTimeout     = 5 * time.Second
+	clipboardTimeout      = 5 * time.Second
+)
+
+// 笔记文件扩展名
+var noteExtensions = map[string]bool{".md": true, ".markdown": true, ".txt": true}
+
+// registerBuiltinTools 注册内置工具
+func (m *LiveSessionManager) registerBuiltinTools() {
+	tools := []Tool{
+		{
+			Name:        "get_screenshot",
+			Description: "获取用户当前屏幕截图，用于查看题目或屏幕上内容",
+			Timeout:     screenshotTimeout,
+			Handler:     m.toolScreenshot,
+		},
+		{
+			Name:        "get_screenshot_region",
+			Description: "截取屏幕上指定区域（屏幕像素坐标），用于放大查看题目的某一部分",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"x":      map[string]any{"type": "integer", "description": "区域左上角 X 坐标"},
+					"y":      map[string]any{"type": "integer", "description": "区域左上角 Y 坐标"},
+					"width":  map[string]any{"type": "integer", "description": "区域宽度"},
+					"height": map[string]any{"type": "integer", "description": "区域高度"},
+				},
+				"required": []string{"x", "y", "width", "height"},
+			},
+			Timeout: screenshotTimeout,
+			Handler: m.toolScreenshotRegion,
+		},
+		{
+			Name:        "read_clipboard",
+			Description: "读取用户剪贴板中的文字或图片，用户可能把题目或代码复制到了剪贴板",
+			Timeout:     clipboardTimeout,
+			Handler:     m.toolReadClipboard,
+		},
+		{
+			Name:        "search_notes",
+			Description: "在用户的本地笔记中按关键词搜索，返回匹配的片段",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{"type": "string", "description": "搜索关键词，多个词用空格分隔"},
+				},
+				"required": []string{"query"},
+			},
+			Timeout: noteSearchTimeout,
+			Enabled: func(cfg config.Config) bool { return cfg.NotesDir != "" },
+			Handler: toolSearchNotes,
+		},
+		{
+			Name:        "get_resume_section",
+			Description: "查看用户简历中的某个章节（如 项目经历、工作经历），不传章节名时返回章节列表",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"section": map[string]any{"type": "string", "description": "章节标题或其中的关键词"},
+				},
+			},
+			Enabled: func(cfg config.Config) bool { return cfg.ResumeContent != "" },
+			Handler: toolResumeSection,
+		},
+		{
+			Name:        "run_code",
+			Description: "在用户电脑上运行一段 Python 或 JavaScript 代码并返回输出，用于验证算法题的解法（每次运行前需用户确认）",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"language": map[string]any{"type": "string", "enum": []string{"python", "javascript"}},
+					"code":     map[string]any{"type": "string", "description": "完整可运行的代码，结果输出到标准输出"},
+				},
+				"required": []string{"language", "code"},
+			},
+			Timeout: runCodeConfirmTimeout + runCodeTimeout,
+			Enabled: func(cfg config.Config) bool { return cfg.LiveRunCode },
+			Handler: m.toolRunCode,
+		},
+	}
+	for _, tool := range tools {
+		_ = m.tools.Register(tool)
+	}
+}
+
+// toolScreenshot 按当前截图设置截图
+func (m *LiveSessionManager) toolScreenshot(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+	return m.captureScreenshot(screen.OptionsFromConfig(cfg))
+}
+
+// toolScreenshotRegion 截取指定区域
+func (m *LiveSessionManager) toolScreenshotRegion(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+	var region config.Region
+	if err := decodeArgs(args, &region); err != nil {
+		return ToolResult{}, err
+	}
+	if region.Empty() {
+		return ToolResult{}, fmt.Errorf("区域宽高必须大于 0")
+	}
+	opts := screen.OptionsFromConfig(cfg)
+	opts.Mode = config.ScreenshotModeRegion
+	opts.Region = region
+	return m.captureScreenshot(opts)
+}
+
+// captureScreenshot 截图并解码为图片数据，使用 SetCaptureSource 设置的截图来源
+func (m *LiveSessionManager) captureScreenshot(opts screen.CaptureOptions) (ToolResult, error) {
+	m.sourceMu.Lock()
+	source := m.captureSource
+	m.sourceMu.Unlock()
+
+	var preview screen.PreviewResult
+	var err error
+	if source != nil {
+		preview, err = m.screenService.CapturePreviewFrom(source, opts)
+	} else {
+		preview, err = m.screenService.CapturePreview(opts)
+	}
+	if err != nil {
+		return ToolResult{}, fmt.Errorf("截图失败: %v", err)
+	}
+	return decodePreview(preview)
+}
+
+// decodePreview 把 data URL 格式的截图解码为图片数据
+func decodePreview(preview screen.PreviewResult) (ToolResult, error) {
+	mimeType, data := llm.ParseBase64DataURL(preview.Base64)
+	if data == "" {
+		mimeType, data = "image/jpeg", preview.Base64
+	}
+	imageData, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return ToolResult{}, fmt.Errorf("图片解码失败: %v", err)
+	}
+	return ToolResult{Image: imageData, MimeType: mimeType}, nil
+}
+
+// toolReadClipboard 读取剪贴板，图片按截图设置压缩
+func (m *LiveSessionManager) toolReadClipboard(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+	input, err := m.screenService.ReadClipboard()
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if input.Image == nil {
+		return ToolResult{Text: truncateRunes(input.Text, maxToolTextRunes)}, nil
+	}
+	preview, err := screen.EncodeImage(input.Image, screen.OptionsFromConfig(cfg))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	return decodePreview(preview)
+}
+
+// noteHit 笔记搜索命中的一行
+type noteHit struct {
+	path  string
+	line  int
+	score int
+	text  string
+}
+
+// toolSearchNotes 在笔记目录中搜索包含关键词的行，按命中关键词数排序
+func toolSearchNotes(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+	var params struct {
+		Query string `json:"query"`
+	}
+	if err := decodeArgs(args, &params); err != nil {
+		return ToolResult{}, err
+	}
+	terms := strings.Fields(strings.ToLower(params.Query))
+	if len(terms) == 0 {
+		return ToolResult{}, fmt.Errorf("搜索关键词不能为空")
+	}
+
+	var hits []noteHit
+	err := filepath.WalkDir(cfg.NotesDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || !noteExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxNoteFileBytes {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			lower := strings.ToLower(line)
+			score := 0
+			for _, term := range terms {
+				if strings.Contains(lower, term) {
+					score++
+				}
+			}
+			if score == 0 {
+				continue
+			}
+			start, end := max(0, i-noteContextLines), min(len(lines), i+noteContextLines+1)
+			rel, _ := filepath.Rel(cfg.NotesDir, path)
+			hits = append(hits, noteHit{path: rel, line: i + 1, score: score, text: strings.Join(lines[start:end], "\n")})
+		}
+		return nil
+	})
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if len(hits) == 0 {
+		return ToolResult{Text: "笔记中没有找到相关内容"}, nil
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	var b strings.Builder
+	for i, hit := range hits {
+		if i == maxNoteHits {
+			break
+		}
+		fmt.Fprintf(&b, "[%s:%d]\n%s\n\n", hit.path, hit.line, strings.TrimSpace(hit.text))
+	}
+	return ToolResult{Text: truncateRunes(b.String(), maxToolTextRunes)}, nil
+}
+
+// toolResumeSection 按 Markdown 标题查找简历章节
+func toolResumeSection(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+	var params struct {
+		Section string `json:"section"`
+	}
+	if err := decodeArgs(args, &params); err != nil {
+		return ToolResult{}, err
+	}
+
+	type section struct {
+		level int
+		title string
+		start int
+	}
+	lines := strings.Split(cfg.ResumeContent, "\n")
+	var sections []section
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		if level > 0 && level <= 6 {
+			sections = append(sections, section{level: level, title: strings.TrimSpace(trimmed[level:]), start: i})
+		}
+	}
+	if len(sections) == 0 {
+		return ToolResult{Text: truncateRunes(cfg.ResumeContent, maxToolTextRunes)}, nil
+	}
+
+	query := strings.ToLower(strings.TrimSpace(params.Section))
+	if query != "" {
+		for i, s := range sections {
+			if !strings.Contains(strings.ToLower(s.title), query) {
+				continue
+			}
+			// 章节一直到下一个同级或更高级的标题
+			end := len(lines)
+			for _, next := range sections[i+1:] {
+				if next.level <= s.level {
+					end = next.start
+					break
+				}
+			}
+			return ToolResult{Text: truncateRunes(strings.Join(lines[s.start:end], "\n"), maxToolTextRunes)}, nil
+		}
+	}
+
+	titles := make([]string, 0, len(sections))
+	for _, s := range sections {
+		titles = append(titles, strings.Repeat("  ", s.level-1)+s.title)
+	}
+	prefix := "简历包含以下章节:\n"
+	if query != "" {
+		prefix = fmt.Sprintf("没有找到章节 %q，简历包含以下章节:\n", params.Section)
+	}
+	return ToolResult{Text: prefix + strings.Join(titles, "\n")}, nil
+}
+
+// 代码运行使用的解释器，按顺序查找
+var codeInterpreters = map[string]struct {
+	commands []string
+	flags    []string
+	ext      string
+}{
+	"python":     {[]string{"python3", "python"}, []string{"-I"}, ".py"}, // -I 忽略 PYTHON* 环境变量和用户 site-packages
+	"javascript": {[]string{"node"}, nil, ".js"},
+}
+
+// toolRunCode 经用户确认后在临时目录中运行代码，非 0 退出码也把输出返回给模型
+// 模型的输入包含对方说的话和屏幕内容，可能被诱导生成恶意代码，因此每次运行前都要用户在界面上确认
+func (m *LiveSessionManager) toolRunCode(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+	var params struct {
+		Language string `json:"language"`
+		Code     string `json:"code"`
+	}
+	if err := decodeArgs(args, &params); err != nil {
+		return ToolResult{}, err
+	}
+	language := strings.ToLower(params.Language)
+	interpreter, ok := codeInterpreters[language]
+	if !ok {
+		return ToolResult{}, fmt.Errorf("不支持的语言 %s", params.Language)
+	}
+	var command string
+	for _, name := range interpreter.commands {
+		if path, err := exec.LookPath(name); err == nil {
+			command = path
+			break
+		}
+	}
+	if command == "" {
+		return ToolResult{}, fmt.Errorf("未找到 %s 解释器", params.Language)
+	}
+
+	confirmCtx, cancelConfirm := context.WithTimeout(ctx, runCodeConfirmTimeout)
+	approved := m.confirmations.request(confirmCtx, m.emitEvent, ToolConfirmRequest{
+		Tool:   "run_code",
+		Title:  "运行 " + language + " 代码",
+		Detail: params.Code,
+	})
+	cancelConfirm()
+	if !approved {
+		return ToolResult{Text: "用户拒绝运行这段代码"}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "qsolver-run-")
+	if err != nil {
+		return ToolResult{}, err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "main"+interpreter.ext)
+	if err := os.WriteFile(file, []byte(params.Code), 0600); err != nil {
+		return ToolResult{}, err
+	}
+
+	text, err := runInterpreter(ctx, runCodeTimeout, command, append(interpreter.flags, file), dir)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	return ToolResult{Text: truncateRunes(text, maxToolTextRunes)}, nil
+}
+
+// runInterpreter 在 dir 中运行解释器并收集输出，非 0 退出码也返回输出
+// 解释器在独立的进程组（Windows 上为作业对象）中运行，超时或结束后连同代码启动的子进程一起结束
+func runInterpreter(ctx context.Context, timeout time.Duration, command string, args []string, dir string) (string, error) {
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, command, args...)
+	cmd.Dir = dir
+	cmd.Env = minimalEnv(dir)
+	output := &limitedBuffer{limit: maxRunOutputBytes}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	// 后台子进程会一直占着输出管道，不设置 WaitDelay 时 Wait 要等它们退出
+	cmd.WaitDelay = runCodeWaitDelay
+
+	group, err := newProcessGroup(cmd)
+	if err != nil {
+		return "", err
+	}
+	defer group.Close()
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	if err := group.attach(cmd.Process); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return "", err
+	}
+
+	err = cmd.Wait()
+	text := output.String()
+	if runCtx.Err() != nil {
+		return "", fmt.Errorf("运行超时")
+	}
+	switch {
+	case errors.Is(err, exec.ErrWaitDelay):
+		text += "\n[代码启动的后台进程已被结束]"
+	case err != nil:
+		text += "\n[退出: " + err.Error() + "]"
+	}
+	if strings.TrimSpace(text) == "" {
+		text = "(没有输出)"
+	}
+	return text, nil
+}
+
+// minimalEnv 代码运行使用的最小环境变量：不继承用户的环境（API Key、代理等），HOME 和临时目录指向运行目录
+// 这不是沙箱，代码仍能访问用户的文件和网络，运行前的用户确认是唯一的防护
+func minimalEnv(dir string) []string {
+	env := []string{"HOME=" + dir, "TMPDIR=" + dir, "TEMP=" + dir, "TMP=" + dir, "USERPROFILE=" + dir}
+	if runtime.GOOS == "windows" {
+		// 缺少 SYSTEMROOT 时 Python 无法初始化随机数和网络模块
+		return append(env, "SYSTEMROOT="+os.Getenv("SYSTEMROOT"), "PATH="+os.Getenv("SYSTEMROOT")+`\System32`)
+	}
+	return append(env, "PATH=/usr/local/bin:/usr/bin:/bin", "LANG=C.UTF-8")
+}
+
+// limitedBuffer 只保留前 limit 字节的输出，防止代码无限打印占满内存
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - b.buf.Len(); remain < len(p) {
+		b.buf.Write(p[:max(remain, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n...(输出过长已截断)"
+	}
+	return b.buf.String()
+}
+
+// truncateRunes 截断过长的文本
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "\n...(已截断)"
+}
@@ -0,0 +1,276 @@
+package live
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/prompts"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeToolSession 依次返回预设的消息，之后阻塞到 ctx 结束；记录收到的工具结果
+type fakeToolSession struct {
+	ctx      context.Context
+	messages chan *llm.LiveMessage
+
+	mu        sync.Mutex
+	responses map[string]string
+	done      chan struct{} // 每收到一个工具结果写入一次
+}
+
+func newFakeToolSession(ctx context.Context, messages ...*llm.LiveMessage) *fakeToolSession {
+	s := &fakeToolSession{
+		ctx:       ctx,
+		messages:  make(chan *llm.LiveMessage, len(messages)),
+		responses: make(map[string]string),
+		done:      make(chan struct{}, 16),
+	}
+	for _, msg := range messages {
+		s.messages <- msg
+	}
+	return s
+}
+
+func (s *fakeToolSession) SendAudio(data []byte) error { return nil }
+
+func (s *fakeToolSession) Receive() (*llm.LiveMessage, error) {
+	select {
+	case msg := <-s.messages:
+		return msg, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *fakeToolSession) SendToolResponse(toolID string, result string) error {
+	s.mu.Lock()
+	s.responses[toolID] = result
+	s.mu.Unlock()
+	s.done <- struct{}{}
+	return nil
+}
+
+func (s *fakeToolSession) SendToolResponseWithImage(toolID string, imageData []byte, mimeType string) error {
+	return s.SendToolResponse(toolID, "image:"+mimeType)
+}
+
+func (s *fakeToolSession) Close() error { return nil }
+
+// wait 等待 n 个工具结果
+func (s *fakeToolSession) wait(t *testing.T, n int) map[string]string {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("只收到 %d 个工具结果，期望 %d 个", i, n)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.responses
+}
+
+func toolCall(id, name string) *llm.LiveMessage {
+	return &llm.LiveMessage{Type: llm.LiveMsgToolCall, ToolID: id, ToolName: name}
+}
+
+func textHandler(text string) ToolHandler {
+	return func(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+		return ToolResult{Text: text}, nil
+	}
+}
+
+func TestToolDispatchUnknownTool(t *testing.T) {
+	registry := NewToolRegistry()
+	session := newFakeToolSession(context.Background())
+
+	registry.Dispatch(context.Background(), session, config.NewDefaultConfig(), toolCall("1", "missing"))
+	got := session.wait(t, 1)["1"]
+	if !strings.Contains(got, "未知工具 missing") {
+		t.Errorf("结果 %q, 期望告知模型工具不存在", got)
+	}
+}
+
+func TestToolDispatchTimeoutIgnoresHandler(t *testing.T) {
+	registry := NewToolRegistry()
+	release := make(chan struct{})
+	defer close(release)
+	registry.Register(Tool{
+		Name:    "stuck",
+		Timeout: 50 * time.Millisecond,
+		// 不响应 ctx 的执行函数
+		Handler: func(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+			<-release
+			return ToolResult{Text: "太晚了"}, nil
+		},
+	})
+	session := newFakeToolSession(context.Background())
+
+	start := time.Now()
+	registry.Dispatch(context.Background(), session, config.NewDefaultConfig(), toolCall("1", "stuck"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Dispatch 耗时 %v，未按工具超时返回", elapsed)
+	}
+	if got := session.wait(t, 1)["1"]; !strings.Contains(got, "执行失败") {
+		t.Errorf("结果 %q, 期望超时失败", got)
+	}
+}
+
+func TestToolDeclarationsFilterEnabled(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(Tool{Name: "always", Handler: textHandler("ok")})
+	registry.Register(Tool{
+		Name:    "notes",
+		Enabled: func(cfg config.Config) bool { return cfg.NotesDir != "" },
+		Handler: textHandler("ok"),
+	})
+
+	cfg := config.NewDefaultConfig()
+	names := func() []string {
+		var names []string
+		for _, d := range registry.Declarations(cfg) {
+			names = append(names, d.Name)
+		}
+		return names
+	}
+	if got := names(); len(got) != 1 || got[0] != "always" {
+		t.Errorf("未开启时声明 %v, 期望 [always]", got)
+	}
+	cfg.NotesDir = t.TempDir()
+	if got := names(); len(got) != 2 || got[1] != "notes" {
+		t.Errorf("开启后声明 %v, 期望 [always notes]", got)
+	}
+
+	// 未声明的工具也不能被调用
+	cfg.NotesDir = ""
+	session := newFakeToolSession(context.Background())
+	registry.Dispatch(context.Background(), session, cfg, toolCall("1", "notes"))
+	if got := session.wait(t, 1)["1"]; !strings.Contains(got, "未知工具") {
+		t.Errorf("结果 %q, 期望拒绝未开启的工具", got)
+	}
+}
+
+func TestToolConfirmations(t *testing.T) {
+	var confirmations toolConfirmations
+	events := make(chan ToolConfirmRequest, 4)
+	var expired []string
+	emit := func(name string, data ...any) {
+		switch name {
+		case "live:tool-confirm":
+			events <- data[0].(ToolConfirmRequest)
+		case "live:tool-confirm-expired":
+			expired = append(expired, data[0].(string))
+		}
+	}
+
+	// 超时视为拒绝，并通知前端收起确认框
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if confirmations.request(ctx, emit, ToolConfirmRequest{Tool: "run_code"}) {
+		t.Error("超时的确认请求应视为拒绝")
+	}
+	req := <-events
+	if len(expired) != 1 || expired[0] != req.ID {
+		t.Errorf("过期通知 %v, 期望 [%s]", expired, req.ID)
+	}
+	if err := confirmations.resolve(req.ID, true); err == nil {
+		t.Error("过期的确认请求不应再被答复")
+	}
+
+	// 用户拒绝和同意
+	for _, approve := range []bool{false, true} {
+		result := make(chan bool)
+		go func() {
+			result <- confirmations.request(context.Background(), emit, ToolConfirmRequest{Tool: "run_code"})
+		}()
+		req := <-events
+		if err := confirmations.resolve(req.ID, approve); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-result; got != approve {
+			t.Errorf("答复 %v, 请求返回 %v", approve, got)
+		}
+	}
+}
+
+func TestReceiveLoopDispatchesToolCallsConcurrently(t *testing.T) {
+	m := NewLiveSessionManager(context.Background(), nil, config.NewConfigManager(), nil, prompts.NewLibrary(t.TempDir()), nil, func(string, ...any) {})
+
+	// 两个调用都开始执行后才能返回：串行执行时第一个调用会超时
+	var started sync.WaitGroup
+	started.Add(2)
+	both := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(both)
+	}()
+	m.Tools().Register(Tool{
+		Name:    "barrier",
+		Timeout: 2 * time.Second,
+		Handler: func(ctx context.Context, cfg config.Config, args json.RawMessage) (ToolResult, error) {
+			started.Done()
+			select {
+			case <-both:
+				return ToolResult{Text: "ok"}, nil
+			case <-ctx.Done():
+				return ToolResult{}, ctx.Err()
+			}
+		},
+	})
+
+	m.cancelCtx, m.cancelFunc = context.WithCancel(context.Background())
+	session := newFakeToolSession(m.cancelCtx, toolCall("a", "barrier"), toolCall("b", "barrier"))
+	var liveSession llm.LiveSession = session
+	m.session.Store(&liveSession)
+	m.wg.Add(1)
+	go m.receiveLoop()
+
+	responses := session.wait(t, 2)
+	m.cancelFunc()
+	m.wg.Wait()
+	for _, id := range []string{"a", "b"} {
+		if responses[id] != "ok" {
+			t.Errorf("调用 %s 的结果 %q, 期望 ok", id, responses[id])
+		}
+	}
+}
+
+func TestRunInterpreterKillsBackgroundChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("需要 sh")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("未找到 sh")
+	}
+
+	// 后台子进程一直占着输出管道
+	start := time.Now()
+	text, err := runInterpreter(context.Background(), 5*time.Second, sh, []string{"-c", "sleep 30 & echo started"}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > runCodeWaitDelay+2*time.Second {
+		t.Errorf("耗时 %v，后台子进程阻塞了 run_code", elapsed)
+	}
+	if !strings.Contains(text, "started") {
+		t.Errorf("输出 %q", text)
+	}
+
+	// 超时时结束整个进程组
+	start = time.Now()
+	_, err = runInterpreter(context.Background(), 100*time.Millisecond, sh, []string{"-c", "sleep 30 & sleep 30"}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "超时") {
+		t.Errorf("错误 %v, 期望运行超时", err)
+	}
+	if elapsed := time.Since(start); elapsed > runCodeWaitDelay+2*time.Second {
+		t.Errorf("超时后耗时 %v 才返回", elapsed)
+	}
+}
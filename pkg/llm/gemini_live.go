@@ -3,6 +3,9 @@ package llm
 import (
 	"Q-Solver/pkg/logger"
 	"context"
+	"encoding/json"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"google.golang.org/genai"
@@ -11,10 +14,22 @@ import (
 // GeminiLiveSession 封装 Gemini SDK 的 Live 会话
 type GeminiLiveSession struct {
 	session *genai.Session
+	writeMu sync.Mutex // WebSocket 不支持并发写，音频发送和工具响应在不同协程
 
 	// 会话恢复相关 (使用 atomic 避免锁竞争)
 	resumeToken atomic.Pointer[string] // 当前的 session handle
 	resumable   atomic.Bool            // 是否支持恢复
+
+	// 服务端语音检测已关闭，说话开始和结束由客户端通知
+	manualActivity bool
+
+	// 一条服务端消息可能转换出多条 LiveMessage，多出的在后续 Receive 中返回（仅在 Receive 协程中访问）
+	queue []*LiveMessage
+}
+
+// nativeAudioModel 是否为只能输出音频的原生音频模型
+func nativeAudioModel(model string) bool {
+	return strings.Contains(model, "native-audio")
 }
 
 // ConnectLive 实现 LiveProvider 接口
@@ -25,27 +40,15 @@ func (a *GeminiAdapter) ConnectLive(ctx context.Context, cfg *LiveConfig) (LiveS
 		model = a.config.Model
 	}
 	// model="gemini-2.5-flash-native-audio-preview-12-2025"
-	// 定义截图工具
-	screenshotTool := &genai.Tool{
-		FunctionDeclarations: []*genai.FunctionDeclaration{
-			{
-				Name:        "get_screenshot",
-				Description: "获取用户当前屏幕截图，用于查看题目或屏幕上内容",
-			},
-		},
-	}
 
 	// 连接配置，使用 LiveConfig 中的参数
 	connectCfg := &genai.LiveConnectConfig{
-		Tools:                    []*genai.Tool{screenshotTool},
-		ResponseModalities:       []genai.Modality{genai.ModalityAudio},
-		MaxOutputTokens:          int32(cfg.MaxTokens),
-		Temperature:              toFloat32Ptr(cfg.Temperature),
-		TopP:                     toFloat32Ptr(cfg.TopP),
-		TopK:                     intToFloat32Ptr(cfg.TopK),
-		InputAudioTranscription:  &genai.AudioTranscriptionConfig{},
-		SpeechConfig:             &genai.SpeechConfig{},
-		OutputAudioTranscription: &genai.AudioTranscriptionConfig{},
+		Tools:                   geminiTools(cfg.Tools),
+		MaxOutputTokens:         int32(cfg.MaxTokens),
+		Temperature:             toFloat32Ptr(cfg.Temperature),
+		TopP:                    toFloat32Ptr(cfg.TopP),
+		TopK:                    intToFloat32Ptr(cfg.TopK),
+		InputAudioTranscription: &genai.AudioTranscriptionConfig{},
 		RealtimeInputConfig: &genai.RealtimeInputConfig{
 			ActivityHandling: genai.ActivityHandlingNoInterruption,
 		},
@@ -54,18 +57,93 @@ func (a *GeminiAdapter) ConnectLive(ctx context.Context, cfg *LiveConfig) (LiveS
 		},
 	}
 
+	if cfg.ClientVAD {
+		connectCfg.RealtimeInputConfig.AutomaticActivityDetection = &genai.AutomaticActivityDetection{Disabled: true}
+	}
+
 	instructionText := cfg.SystemInstruction
 
 	connectCfg.SystemInstruction = &genai.Content{
 		Parts: []*genai.Part{{Text: instructionText}},
 	}
 
+	// 文本输出直接返回 ModelTurn 中的文字，省去音频生成和转写；原生音频模型只能输出音频
+	if cfg.TextModality && !nativeAudioModel(model) {
+		setResponseModality(connectCfg, genai.ModalityText)
+		session, err := a.client.Live.Connect(ctx, model, connectCfg)
+		if err == nil {
+			// Connect 不等待 setupComplete，不支持文本输出的模型在首条消息时才断开连接
+			if _, err = session.Receive(); err == nil {
+				return &GeminiLiveSession{session: session, manualActivity: cfg.ClientVAD}, nil
+			}
+			session.Close()
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		logger.Printf("LiveAPI: 模型 %s 不支持文本输出，改用音频 + 转写: %v", model, err)
+	}
+
+	setResponseModality(connectCfg, genai.ModalityAudio)
 	session, err := a.client.Live.Connect(ctx, model, connectCfg)
 	if err != nil {
 		logger.Printf("LiveAPI: 连接到模型 %s 发生错误", err)
 		return nil, err
 	}
-	return &GeminiLiveSession{session: session}, nil
+	return &GeminiLiveSession{session: session, manualActivity: cfg.ClientVAD}, nil
+}
+
+// geminiTools 转换工具声明
+func geminiTools(tools []ToolDeclaration) []*genai.Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+	declarations := make([]*genai.FunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		declaration := &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+		}
+		if len(tool.Parameters) > 0 {
+			declaration.ParametersJsonSchema = tool.Parameters
+		}
+		declarations = append(declarations, declaration)
+	}
+	return []*genai.Tool{{FunctionDeclarations: declarations}}
+}
+
+// setResponseModality 设置输出模态，音频输出时通过输出转写获取文字
+func setResponseModality(connectCfg *genai.LiveConnectConfig, modality genai.Modality) {
+	connectCfg.ResponseModalities = []genai.Modality{modality}
+	if modality == genai.ModalityAudio {
+		connectCfg.SpeechConfig = &genai.SpeechConfig{}
+		connectCfg.OutputAudioTranscription = &genai.AudioTranscriptionConfig{}
+	} else {
+		connectCfg.SpeechConfig = nil
+		connectCfg.OutputAudioTranscription = nil
+	}
+}
+
+// InputSampleRate Gemini Live 接收 16kHz 的音频
+func (s *GeminiLiveSession) InputSampleRate() int {
+	return 16000
+}
+
+// SendActivityStart 服务端语音检测关闭时发送 activityStart，否则无需处理
+func (s *GeminiLiveSession) SendActivityStart() error {
+	if !s.manualActivity {
+		return nil
+	}
+	return s.sendRealtimeInput(genai.LiveRealtimeInput{ActivityStart: &genai.ActivityStart{}})
+}
+
+// SendActivityEnd 服务端语音检测关闭时发送 activityEnd
+// 否则发送 audioStreamEnd，让服务端在音频暂停后立即处理缓存的语音
+func (s *GeminiLiveSession) SendActivityEnd() error {
+	if s.manualActivity {
+		return s.sendRealtimeInput(genai.LiveRealtimeInput{ActivityEnd: &genai.ActivityEnd{}})
+	}
+	return s.sendRealtimeInput(genai.LiveRealtimeInput{AudioStreamEnd: true})
 }
 
 // SendAudio 发送音频数据 (16kHz, 16-bit, mono PCM)
@@ -73,7 +151,7 @@ func (s *GeminiLiveSession) SendAudio(data []byte) error {
 	if len(data) == 0 {
 		return nil
 	}
-	return s.session.SendRealtimeInput(genai.LiveRealtimeInput{
+	return s.sendRealtimeInput(genai.LiveRealtimeInput{
 		Media: &genai.Blob{
 			MIMEType: "audio/pcm;rate=16000",
 			Data:     data,
@@ -81,8 +159,28 @@ func (s *GeminiLiveSession) SendAudio(data []byte) error {
 	})
 }
 
+// sendRealtimeInput 串行发送实时输入
+func (s *GeminiLiveSession) sendRealtimeInput(input genai.LiveRealtimeInput) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.session.SendRealtimeInput(input)
+}
+
+// sendToolResponse 串行发送工具响应
+func (s *GeminiLiveSession) sendToolResponse(input genai.LiveToolResponseInput) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.session.SendToolResponse(input)
+}
+
 // Receive 接收消息 (阻塞)
 func (s *GeminiLiveSession) Receive() (*LiveMessage, error) {
+	if len(s.queue) > 0 {
+		msg := s.queue[0]
+		s.queue = s.queue[1:]
+		return msg, nil
+	}
+
 	msg, err := s.session.Receive()
 	if err != nil {
 		return &LiveMessage{Type: LiveMsgError, Text: err.Error()}, err
@@ -98,66 +196,76 @@ func (s *GeminiLiveSession) Receive() (*LiveMessage, error) {
 	}
 		// GoAway 消息 - 服务器要求断开，需重连
 	if msg.GoAway != nil {
-		logger.Println("LiveAPI: 收到 GoAway 消息，需要重连.还有 %v秒断开", msg.GoAway.TimeLeft)
+		logger.Printf("LiveAPI: 收到 GoAway 消息，需要重连.还有 %v秒断开", msg.GoAway.TimeLeft)
 		return &LiveMessage{Type: LiveMsgGoAway},nil
 	}
-	return s.convertMessage(msg), nil
+	messages := s.convertMessage(msg)
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	s.queue = messages[1:]
+	return messages[0], nil
 }
 
-// convertMessage 转换 SDK 消息为统一格式
-func (s *GeminiLiveSession) convertMessage(msg *genai.LiveServerMessage) *LiveMessage {
+// convertMessage 转换 SDK 消息为统一格式，按 转写 -> 回答 -> 工具调用 -> 完成 的顺序返回
+func (s *GeminiLiveSession) convertMessage(msg *genai.LiveServerMessage) []*LiveMessage {
 	if msg == nil {
 		return nil
 	}
+	var messages []*LiveMessage
 
-	// 输入音频转录 (面试官说话的文字)
-	if msg.ServerContent != nil && msg.ServerContent.InputTranscription != nil {
-		text := msg.ServerContent.InputTranscription.Text
-		if text != "" {
-			return &LiveMessage{Type: LiveMsgTranscript, Text: text}
+	if content := msg.ServerContent; content != nil {
+		// 输入音频转录 (面试官说话的文字)
+		if content.InputTranscription != nil && content.InputTranscription.Text != "" {
+			messages = append(messages, &LiveMessage{Type: LiveMsgTranscript, Text: content.InputTranscription.Text})
 		}
-	}
 
-	if msg.ServerContent != nil && msg.ServerContent.OutputTranscription != nil {
-		text := msg.ServerContent.OutputTranscription.Text
-		if text != "" {
-			return &LiveMessage{Type: LiveMsgAIText, Text: text}
+		// 音频输出时的回答转写
+		if content.OutputTranscription != nil && content.OutputTranscription.Text != "" {
+			messages = append(messages, &LiveMessage{Type: LiveMsgAIText, Text: content.OutputTranscription.Text})
 		}
-	}
 
-	// 工具调用
-	if msg.ToolCall != nil && len(msg.ToolCall.FunctionCalls) > 0 {
-		fc := msg.ToolCall.FunctionCalls[0]
-		return &LiveMessage{
-			Type:     LiveMsgToolCall,
-			ToolName: fc.Name,
-			ToolID:   fc.ID,
+		// 文本输出时 ModelTurn 中的文字（跳过思考过程）
+		if content.ModelTurn != nil {
+			for _, part := range content.ModelTurn.Parts {
+				if part != nil && part.Text != "" && !part.Thought {
+					messages = append(messages, &LiveMessage{Type: LiveMsgAIText, Text: part.Text})
+				}
+			}
 		}
 	}
 
-	// 服务端消息
-	if msg.ServerContent != nil {
-		// 是否完成
-		if msg.ServerContent.TurnComplete {
-			return &LiveMessage{Type: LiveMsgDone}
-		}
-
-		// 检查 ModelTurn 中的文本 (当 ResponseModalities 为 Text 时)
-		if msg.ServerContent.ModelTurn != nil {
-			for _, part := range msg.ServerContent.ModelTurn.Parts {
-				if part != nil && part.Text != "" {
-					return &LiveMessage{Type: LiveMsgAIText, Text: part.Text}
-				}
+	// 工具调用（一条消息可能包含多个调用）
+	if msg.ToolCall != nil {
+		for _, fc := range msg.ToolCall.FunctionCalls {
+			if fc == nil {
+				continue
 			}
+			var args string
+			if len(fc.Args) > 0 {
+				data, _ := json.Marshal(fc.Args)
+				args = string(data)
+			}
+			messages = append(messages, &LiveMessage{
+				Type:     LiveMsgToolCall,
+				ToolName: fc.Name,
+				ToolID:   fc.ID,
+				ToolArgs: args,
+			})
 		}
 	}
 
-	return nil
+	// 是否完成
+	if msg.ServerContent != nil && msg.ServerContent.TurnComplete {
+		messages = append(messages, &LiveMessage{Type: LiveMsgDone})
+	}
+
+	return messages
 }
 
 // SendToolResponse 发送工具调用结果 (文本)
 func (s *GeminiLiveSession) SendToolResponse(toolID string, result string) error {
-	return s.session.SendToolResponse(genai.LiveToolResponseInput{
+	return s.sendToolResponse(genai.LiveToolResponseInput{
 		FunctionResponses: []*genai.FunctionResponse{
 			{
 				ID:       toolID,
@@ -170,7 +278,7 @@ func (s *GeminiLiveSession) SendToolResponse(toolID string, result string) error
 // SendToolResponseWithImage 发送图片作为工具调用结果
 func (s *GeminiLiveSession) SendToolResponseWithImage(toolID string, imageData []byte, mimeType string) error {
 	logger.Printf("LiveAPI: 发送图片工具响应 ID=%s, size=%d, mime=%s", toolID, len(imageData), mimeType)
-	return s.session.SendToolResponse(genai.LiveToolResponseInput{
+	return s.sendToolResponse(genai.LiveToolResponseInput{
 		FunctionResponses: []*genai.FunctionResponse{
 			{
 				ID: toolID,
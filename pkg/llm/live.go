@@ -27,6 +27,14 @@ type LiveMessage struct {
 	Text     string          `json:"text,omitempty"`
 	ToolName string          `json:"toolName,omitempty"` // 工具名称 (如 get_screenshot)
 	ToolID   string          `json:"toolId,omitempty"`   // 工具调用 ID
+	ToolArgs string          `json:"toolArgs,omitempty"` // 工具参数 (JSON 对象)
+}
+
+// ToolDeclaration 声明给模型的工具
+type ToolDeclaration struct {
+	Name        string
+	Description string
+	Parameters  map[string]any // 参数的 JSON Schema，为空表示无参数
 }
 
 // LiveConfig 实时会话配置
@@ -40,6 +48,15 @@ type LiveConfig struct {
 	TopK        int
 	// 会话恢复令牌（用于 goaway 后重连）
 	ResumeToken string
+	// 关闭服务端语音检测，由客户端通过 ActivitySession 通知说话开始和结束
+	ClientVAD bool
+	// 声明给模型的工具
+	Tools []ToolDeclaration
+	// 优先请求文本输出（模型不支持时回退为音频 + 输出转写）
+	TextModality bool
+	// 输入音频转写（服务端需要单独指定转写模型时使用，如 OpenAI Realtime）
+	TranscribeModel    string
+	TranscribeLanguage string
 }
 
 // LiveSession 实时会话接口
@@ -65,6 +82,37 @@ type ResumableSession interface {
 	IsResumable() bool
 }
 
+// AudioFormatSession 声明输入音频采样率的扩展接口（可选实现）
+// 音频统一为 16-bit 单声道 PCM，采集端会重采样到该采样率
+type AudioFormatSession interface {
+	LiveSession
+
+	// InputSampleRate SendAudio 期望的采样率，如 Gemini 16000、OpenAI Realtime 24000
+	InputSampleRate() int
+}
+
+// ActivitySession 接收客户端说话开始/结束信号的扩展接口（可选实现）
+// 开启本地语音检测时，静音期间不发送音频，服务端需要这些信号才能及时结束一轮对话
+type ActivitySession interface {
+	LiveSession
+
+	// SendActivityStart 客户端检测到开始说话
+	SendActivityStart() error
+	// SendActivityEnd 客户端检测到说话结束
+	SendActivityEnd() error
+}
+
+// DefaultInputSampleRate 未实现 AudioFormatSession 时使用的采样率
+const DefaultInputSampleRate = 16000
+
+// InputSampleRate 获取会话需要的输入采样率
+func InputSampleRate(session LiveSession) int {
+	if fs, ok := session.(AudioFormatSession); ok && fs.InputSampleRate() > 0 {
+		return fs.InputSampleRate()
+	}
+	return DefaultInputSampleRate
+}
+
 // LiveProvider 支持实时对话的 Provider 接口
 type LiveProvider interface {
 	// ConnectLive 建立新连接
@@ -75,11 +123,15 @@ type LiveProvider interface {
 // GetLiveConfig 从配置创建 LiveConfig
 func GetLiveConfig(cfg config.Config) *LiveConfig {
 	return &LiveConfig{
-		Model:             cfg.Model,
-		SystemInstruction: cfg.Prompt,
-		MaxTokens:         cfg.MaxTokens,
-		Temperature:       cfg.Temperature,
-		TopP:              cfg.TopP,
-		TopK:              cfg.TopK,
+		Model:              cfg.Model,
+		SystemInstruction:  cfg.Prompt,
+		MaxTokens:          cfg.MaxTokens,
+		Temperature:        cfg.Temperature,
+		TopP:               cfg.TopP,
+		TopK:               cfg.TopK,
+		ClientVAD:          cfg.LiveVADMode == config.VADModeClient,
+		TextModality:       cfg.LiveTextModality,
+		TranscribeModel:    cfg.TranscribeModel,
+		TranscribeLanguage: cfg.TranscribeLanguage,
 	}
 }
@@ -0,0 +1,402 @@
+package llm
+
+import (
+	"Q-Solver/pkg/logger"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// OpenAI Realtime 默认参数
+const (
+	openAIRealtimeURL             = "wss://api.openai.com/v1/realtime"
+	openAIRealtimeModel           = "gpt-realtime"
+	openAIRealtimeTranscribeModel = "gpt-4o-mini-transcribe"
+	openAIRealtimeSampleRate      = 24000
+)
+
+// 可以忽略的 Realtime 错误码（不影响会话继续）
+var openAIIgnorableErrors = map[string]bool{
+	"input_audio_buffer_commit_empty": true, // 客户端语音检测提交了空的音频缓冲
+	"response_cancel_not_active":      true,
+}
+
+// OpenAILiveSession 基于 Realtime WebSocket 协议的 Live 会话
+type OpenAILiveSession struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex // WebSocket 不支持并发写，音频发送和工具响应在不同协程
+
+	// 服务端语音检测已关闭，说话结束时由客户端提交音频并请求回答
+	manualActivity bool
+
+	// 工具调用：一次回答的所有调用都返回结果、且该回答已结束后，才请求继续生成
+	toolMu          sync.Mutex
+	pendingCalls    map[string]bool // 尚未返回结果的调用
+	responseActive  bool            // 当前回答还在生成（可能还有新的调用）
+	toolOutputsSent bool            // 已发送工具结果、尚未请求继续生成
+
+	// 以下仅在 Receive 协程中访问
+	transcribed map[string]bool // 已收到增量转写的输入项，completed 事件不再重复输出
+}
+
+// realtimeEvent 服务端事件中用到的字段（不同事件共用）
+type realtimeEvent struct {
+	Type       string `json:"type"`
+	Delta      string `json:"delta"`
+	Transcript string `json:"transcript"`
+	ItemID     string `json:"item_id"`
+	CallID     string `json:"call_id"`
+	Name       string `json:"name"`
+	Arguments  string `json:"arguments"`
+	Error      *struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Response *struct {
+		Status string `json:"status"`
+		Output []struct {
+			Type string `json:"type"`
+		} `json:"output"`
+	} `json:"response"`
+}
+
+// ConnectLive 实现 LiveProvider 接口
+func (a *OpenAIAdapter) ConnectLive(ctx context.Context, cfg *LiveConfig) (LiveSession, error) {
+	model := cfg.Model
+	if model == "" {
+		model = openAIRealtimeModel
+	}
+	endpoint, err := realtimeURL(a.config.BaseURL, model)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+a.config.APIKey)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, endpoint, header)
+	if err != nil {
+		if resp != nil {
+			logger.Printf("Realtime: 连接到模型 %s 失败 (HTTP %d): %v", model, resp.StatusCode, err)
+			return nil, fmt.Errorf("连接 Realtime 失败 (HTTP %d): %w", resp.StatusCode, err)
+		}
+		logger.Printf("Realtime: 连接到模型 %s 失败: %v", model, err)
+		return nil, err
+	}
+
+	s := &OpenAILiveSession{
+		conn:           conn,
+		manualActivity: cfg.ClientVAD,
+		transcribed:    make(map[string]bool),
+		pendingCalls:   make(map[string]bool),
+	}
+	if err := s.send(sessionUpdate(cfg)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// realtimeURL 根据 Base URL 生成 Realtime WebSocket 地址
+func realtimeURL(baseURL, model string) (string, error) {
+	endpoint := openAIRealtimeURL
+	if baseURL != "" {
+		u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+		if err != nil {
+			return "", fmt.Errorf("无效的 Base URL: %w", err)
+		}
+		switch u.Scheme {
+		case "https":
+			u.Scheme = "wss"
+		case "http":
+			u.Scheme = "ws"
+		}
+		u.Path += "/realtime"
+		endpoint = u.String()
+	}
+	return endpoint + "?model=" + url.QueryEscape(model), nil
+}
+
+// sessionUpdate 生成 session.update 事件：文本输出、输入音频转写、语音检测和截图工具
+func sessionUpdate(cfg *LiveConfig) map[string]any {
+	transcribeModel := cfg.TranscribeModel
+	if transcribeModel == "" {
+		transcribeModel = openAIRealtimeTranscribeModel
+	}
+	transcription := map[string]any{"model": transcribeModel}
+	if cfg.TranscribeLanguage != "" {
+		transcription["language"] = cfg.TranscribeLanguage
+	}
+
+	// 与 Gemini 一致：对方说话不打断正在生成的回答
+	var turnDetection any = map[string]any{
+		"type":               "server_vad",
+		"create_response":    true,
+		"interrupt_response": false,
+	}
+	if cfg.ClientVAD {
+		turnDetection = nil
+	}
+
+	session := map[string]any{
+		"type":              "realtime",
+		"instructions":      cfg.SystemInstruction,
+		"output_modalities": []string{"text"},
+		"audio": map[string]any{
+			"input": map[string]any{
+				"format":         map[string]any{"type": "audio/pcm", "rate": openAIRealtimeSampleRate},
+				"transcription":  transcription,
+				"turn_detection": turnDetection,
+			},
+		},
+		"tools":       realtimeTools(cfg.Tools),
+		"tool_choice": "auto",
+	}
+	if cfg.MaxTokens > 0 {
+		session["max_output_tokens"] = cfg.MaxTokens
+	}
+	return map[string]any{"type": "session.update", "session": session}
+}
+
+// realtimeTools 转换工具声明
+func realtimeTools(tools []ToolDeclaration) []map[string]any {
+	result := make([]map[string]any, 0, len(tools))
+	for _, tool := range tools {
+		parameters := tool.Parameters
+		if len(parameters) == 0 {
+			parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		result = append(result, map[string]any{
+			"type":        "function",
+			"name":        tool.Name,
+			"description": tool.Description,
+			"parameters":  parameters,
+		})
+	}
+	return result
+}
+
+// InputSampleRate OpenAI Realtime 接收 24kHz 的音频
+func (s *OpenAILiveSession) InputSampleRate() int {
+	return openAIRealtimeSampleRate
+}
+
+// SendActivityStart 服务端语音检测关闭时清空之前残留的音频，否则无需处理
+func (s *OpenAILiveSession) SendActivityStart() error {
+	if !s.manualActivity {
+		return nil
+	}
+	return s.send(map[string]any{"type": "input_audio_buffer.clear"})
+}
+
+// SendActivityEnd 服务端语音检测关闭时提交音频缓冲并请求回答，否则由服务端自行判断
+func (s *OpenAILiveSession) SendActivityEnd() error {
+	if !s.manualActivity {
+		return nil
+	}
+	if err := s.send(map[string]any{"type": "input_audio_buffer.commit"}); err != nil {
+		return err
+	}
+	return s.send(map[string]any{"type": "response.create"})
+}
+
+// SendAudio 发送音频数据 (24kHz, 16-bit, mono PCM)
+func (s *OpenAILiveSession) SendAudio(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return s.send(map[string]any{
+		"type":  "input_audio_buffer.append",
+		"audio": base64.StdEncoding.EncodeToString(data),
+	})
+}
+
+// Receive 接收消息 (阻塞)，不需要处理的事件返回 nil
+func (s *OpenAILiveSession) Receive() (*LiveMessage, error) {
+	_, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return &LiveMessage{Type: LiveMsgError, Text: err.Error()}, err
+	}
+	var event realtimeEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		logger.Printf("Realtime: 解析事件失败: %v", err)
+		return nil, nil
+	}
+	return s.convertEvent(&event), nil
+}
+
+// convertEvent 转换 Realtime 服务端事件为统一格式
+// 同时兼容正式版和 beta 版的事件名
+func (s *OpenAILiveSession) convertEvent(event *realtimeEvent) *LiveMessage {
+	switch event.Type {
+	// 输入音频转写 (面试官说话的文字)
+	case "conversation.item.input_audio_transcription.delta":
+		s.transcribed[event.ItemID] = true
+		if event.Delta != "" {
+			return &LiveMessage{Type: LiveMsgTranscript, Text: event.Delta}
+		}
+
+	case "conversation.item.input_audio_transcription.completed":
+		// whisper-1 等模型只返回完整结果
+		if s.transcribed[event.ItemID] {
+			delete(s.transcribed, event.ItemID)
+			return nil
+		}
+		if event.Transcript != "" {
+			return &LiveMessage{Type: LiveMsgTranscript, Text: event.Transcript}
+		}
+
+	case "input_audio_buffer.speech_stopped":
+		return &LiveMessage{Type: LiveMsgInterviewerDone}
+
+	// AI 回答
+	case "response.output_text.delta", "response.text.delta",
+		"response.output_audio_transcript.delta", "response.audio_transcript.delta":
+		if event.Delta != "" {
+			return &LiveMessage{Type: LiveMsgAIText, Text: event.Delta}
+		}
+
+	// 工具调用
+	case "response.created":
+		s.toolMu.Lock()
+		s.responseActive = true
+		s.toolMu.Unlock()
+
+	case "response.function_call_arguments.done":
+		s.toolMu.Lock()
+		s.pendingCalls[event.CallID] = true
+		s.toolMu.Unlock()
+		return &LiveMessage{
+			Type:     LiveMsgToolCall,
+			ToolName: event.Name,
+			ToolID:   event.CallID,
+			ToolArgs: event.Arguments,
+		}
+
+	case "response.done":
+		s.toolMu.Lock()
+		s.responseActive = false
+		s.toolMu.Unlock()
+		if err := s.continueAfterTools(); err != nil {
+			logger.Printf("Realtime: 请求继续生成失败: %v", err)
+		}
+
+		if event.Response == nil {
+			return &LiveMessage{Type: LiveMsgDone}
+		}
+		if event.Response.Status == "cancelled" {
+			return &LiveMessage{Type: LiveInterrupted}
+		}
+		// 只包含工具调用的回答还没有结束，工具结果返回后会继续生成
+		toolOnly := len(event.Response.Output) > 0
+		for _, item := range event.Response.Output {
+			toolOnly = toolOnly && item.Type == "function_call"
+		}
+		if !toolOnly {
+			return &LiveMessage{Type: LiveMsgDone}
+		}
+
+	case "error":
+		if event.Error == nil {
+			return nil
+		}
+		if openAIIgnorableErrors[event.Error.Code] {
+			logger.Printf("Realtime: 忽略错误 %s: %s", event.Error.Code, event.Error.Message)
+			return nil
+		}
+		return &LiveMessage{Type: LiveMsgError, Text: event.Error.Message}
+	}
+	return nil
+}
+
+// SendToolResponse 发送工具调用结果 (文本)，所有调用都有结果后继续生成回答
+func (s *OpenAILiveSession) SendToolResponse(toolID string, result string) error {
+	if err := s.sendFunctionOutput(toolID, result); err != nil {
+		return err
+	}
+	return s.toolDone(toolID)
+}
+
+// SendToolResponseWithImage 发送图片作为工具调用结果
+// Realtime 的工具结果只能是文本，图片作为紧随其后的用户消息发送
+func (s *OpenAILiveSession) SendToolResponseWithImage(toolID string, imageData []byte, mimeType string) error {
+	logger.Printf("Realtime: 发送图片工具响应 ID=%s, size=%d, mime=%s", toolID, len(imageData), mimeType)
+	if err := s.sendFunctionOutput(toolID, "截图已作为下一条消息发送"); err != nil {
+		return err
+	}
+	err := s.send(map[string]any{
+		"type": "conversation.item.create",
+		"item": map[string]any{
+			"type": "message",
+			"role": "user",
+			"content": []map[string]any{
+				{
+					"type":      "input_image",
+					"image_url": "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(imageData),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return s.toolDone(toolID)
+}
+
+// toolDone 记录一个调用已返回结果
+func (s *OpenAILiveSession) toolDone(callID string) error {
+	s.toolMu.Lock()
+	delete(s.pendingCalls, callID)
+	s.toolOutputsSent = true
+	s.toolMu.Unlock()
+	return s.continueAfterTools()
+}
+
+// continueAfterTools 回答已结束且所有调用都有结果时，请求模型根据工具结果继续生成
+// 回答还在生成时请求 response.create 会被服务端拒绝
+func (s *OpenAILiveSession) continueAfterTools() error {
+	s.toolMu.Lock()
+	ready := s.toolOutputsSent && !s.responseActive && len(s.pendingCalls) == 0
+	if ready {
+		s.toolOutputsSent = false
+	}
+	s.toolMu.Unlock()
+	if !ready {
+		return nil
+	}
+	return s.send(map[string]any{"type": "response.create"})
+}
+
+// sendFunctionOutput 发送 function_call_output 对话项
+func (s *OpenAILiveSession) sendFunctionOutput(callID, output string) error {
+	return s.send(map[string]any{
+		"type": "conversation.item.create",
+		"item": map[string]any{
+			"type":    "function_call_output",
+			"call_id": callID,
+			"output":  output,
+		},
+	})
+}
+
+// send 发送客户端事件
+func (s *OpenAILiveSession) send(event map[string]any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(event)
+}
+
+// Close 关闭会话
+func (s *OpenAILiveSession) Close() error {
+	s.writeMu.Lock()
+	_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	s.writeMu.Unlock()
+	return s.conn.Close()
+}
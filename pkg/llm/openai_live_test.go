@@ -0,0 +1,276 @@
+package llm
+
+import (
+	"Q-Solver/pkg/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeRealtime 模拟 Realtime 服务端：记录客户端事件，由测试主动推送服务端事件
+type fakeRealtime struct {
+	srv      *httptest.Server
+	request  chan *http.Request
+	conn     chan *websocket.Conn
+	received chan map[string]any
+}
+
+func newFakeRealtime(t *testing.T) *fakeRealtime {
+	f := &fakeRealtime{
+		request:  make(chan *http.Request, 1),
+		conn:     make(chan *websocket.Conn, 1),
+		received: make(chan map[string]any, 64),
+	}
+	upgrader := websocket.Upgrader{}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		f.request <- r
+		f.conn <- c
+		for {
+			var event map[string]any
+			if err := c.ReadJSON(&event); err != nil {
+				return
+			}
+			f.received <- event
+		}
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// connect 建立会话，返回客户端会话和服务端连接
+func (f *fakeRealtime) connect(t *testing.T, cfg *LiveConfig) (*OpenAILiveSession, *websocket.Conn) {
+	adapter := NewOpenAIAdapter(&config.Config{APIKey: "test-key", BaseURL: f.srv.URL + "/v1"})
+	session, err := adapter.ConnectLive(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("ConnectLive: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session.(*OpenAILiveSession), <-f.conn
+}
+
+// next 读取客户端发送的下一个事件
+func (f *fakeRealtime) next(t *testing.T) map[string]any {
+	t.Helper()
+	select {
+	case event := <-f.received:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待客户端事件超时")
+		return nil
+	}
+}
+
+// expectType 读取下一个事件并检查类型
+func (f *fakeRealtime) expectType(t *testing.T, want string) map[string]any {
+	t.Helper()
+	event := f.next(t)
+	if event["type"] != want {
+		t.Fatalf("客户端事件类型 %v, 期望 %s", event["type"], want)
+	}
+	return event
+}
+
+// push 服务端推送事件，并由客户端接收转换
+func push(t *testing.T, server *websocket.Conn, session *OpenAILiveSession, event map[string]any) *LiveMessage {
+	t.Helper()
+	if err := server.WriteJSON(event); err != nil {
+		t.Fatalf("推送服务端事件: %v", err)
+	}
+	msg, err := session.Receive()
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	return msg
+}
+
+// field 按路径读取嵌套的 JSON 字段
+func field(event map[string]any, path ...string) any {
+	var v any = event
+	for _, key := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func TestOpenAILiveSessionUpdate(t *testing.T) {
+	f := newFakeRealtime(t)
+	f.connect(t, &LiveConfig{
+		Model:              "gpt-realtime-test",
+		SystemInstruction:  "你是面试助手",
+		MaxTokens:          512,
+		Tools:              []ToolDeclaration{{Name: "take_screenshot", Description: "截图"}},
+		TranscribeLanguage: "zh",
+	})
+
+	r := <-f.request
+	if r.URL.Path != "/v1/realtime" || r.URL.Query().Get("model") != "gpt-realtime-test" {
+		t.Errorf("连接地址 %s, 期望 /v1/realtime?model=gpt-realtime-test", r.URL)
+	}
+	if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("Authorization %q", got)
+	}
+
+	event := f.expectType(t, "session.update")
+	checks := []struct {
+		path []string
+		want any
+	}{
+		{[]string{"session", "type"}, "realtime"},
+		{[]string{"session", "instructions"}, "你是面试助手"},
+		{[]string{"session", "max_output_tokens"}, float64(512)},
+		{[]string{"session", "audio", "input", "format", "rate"}, float64(openAIRealtimeSampleRate)},
+		{[]string{"session", "audio", "input", "transcription", "model"}, openAIRealtimeTranscribeModel},
+		{[]string{"session", "audio", "input", "transcription", "language"}, "zh"},
+		{[]string{"session", "audio", "input", "turn_detection", "type"}, "server_vad"},
+		{[]string{"session", "audio", "input", "turn_detection", "interrupt_response"}, false},
+	}
+	for _, c := range checks {
+		if got := field(event, c.path...); got != c.want {
+			t.Errorf("%v = %v, 期望 %v", c.path, got, c.want)
+		}
+	}
+
+	modalities, _ := json.Marshal(field(event, "session", "output_modalities"))
+	if string(modalities) != `["text"]` {
+		t.Errorf("output_modalities = %s", modalities)
+	}
+	tools, _ := field(event, "session", "tools").([]any)
+	if len(tools) != 1 {
+		t.Fatalf("tools = %v", tools)
+	}
+	tool := tools[0].(map[string]any)
+	if tool["name"] != "take_screenshot" || field(tool, "parameters", "type") != "object" {
+		t.Errorf("工具声明 = %v", tool)
+	}
+}
+
+func TestOpenAILiveSessionUpdateClientVAD(t *testing.T) {
+	f := newFakeRealtime(t)
+	session, _ := f.connect(t, &LiveConfig{ClientVAD: true})
+
+	event := f.expectType(t, "session.update")
+	input, _ := field(event, "session", "audio", "input").(map[string]any)
+	if v, ok := input["turn_detection"]; !ok || v != nil {
+		t.Errorf("客户端语音检测时 turn_detection 应为 null, 实际 %v", v)
+	}
+
+	// 说话结束时提交音频并请求回答
+	if err := session.SendActivityEnd(); err != nil {
+		t.Fatal(err)
+	}
+	f.expectType(t, "input_audio_buffer.commit")
+	f.expectType(t, "response.create")
+}
+
+func TestOpenAILiveTranscriptDedup(t *testing.T) {
+	f := newFakeRealtime(t)
+	session, server := f.connect(t, &LiveConfig{})
+
+	events := []struct {
+		event map[string]any
+		want  string // 为空表示不应输出
+	}{
+		{map[string]any{"type": "conversation.item.input_audio_transcription.delta", "item_id": "item1", "delta": "你好"}, "你好"},
+		{map[string]any{"type": "conversation.item.input_audio_transcription.delta", "item_id": "item1", "delta": "世界"}, "世界"},
+		// 已经输出过增量的输入项，完整结果不再重复输出
+		{map[string]any{"type": "conversation.item.input_audio_transcription.completed", "item_id": "item1", "transcript": "你好世界"}, ""},
+		// 只返回完整结果的转写模型
+		{map[string]any{"type": "conversation.item.input_audio_transcription.completed", "item_id": "item2", "transcript": "请介绍一下自己"}, "请介绍一下自己"},
+	}
+	for i, e := range events {
+		msg := push(t, server, session, e.event)
+		switch {
+		case e.want == "" && msg != nil:
+			t.Errorf("事件 %d: 不应输出, 实际 %+v", i, msg)
+		case e.want != "" && (msg == nil || msg.Type != LiveMsgTranscript || msg.Text != e.want):
+			t.Errorf("事件 %d: 期望转写 %q, 实际 %+v", i, e.want, msg)
+		}
+	}
+}
+
+func TestOpenAILiveToolContinuation(t *testing.T) {
+	f := newFakeRealtime(t)
+	session, server := f.connect(t, &LiveConfig{})
+	f.expectType(t, "session.update")
+
+	push(t, server, session, map[string]any{"type": "response.created"})
+	for _, id := range []string{"call1", "call2"} {
+		msg := push(t, server, session, map[string]any{
+			"type": "response.function_call_arguments.done", "call_id": id, "name": "take_screenshot", "arguments": "{}",
+		})
+		if msg == nil || msg.Type != LiveMsgToolCall || msg.ToolID != id {
+			t.Fatalf("期望工具调用 %s, 实际 %+v", id, msg)
+		}
+	}
+
+	// 回答还在生成：工具结果全部返回后也不能请求继续生成
+	// 客户端事件按顺序到达，下一个事件是第二个结果说明没有多发 response.create
+	if err := session.SendToolResponse("call1", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	f.expectType(t, "conversation.item.create")
+	if err := session.SendToolResponse("call2", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	f.expectType(t, "conversation.item.create")
+
+	// 只包含工具调用的回答结束：不输出 Done，并请求继续生成
+	msg := push(t, server, session, map[string]any{
+		"type":     "response.done",
+		"response": map[string]any{"status": "completed", "output": []map[string]any{{"type": "function_call"}, {"type": "function_call"}}},
+	})
+	if msg != nil {
+		t.Errorf("只包含工具调用的 response.done 不应输出消息, 实际 %+v", msg)
+	}
+	f.expectType(t, "response.create")
+
+	// 根据工具结果生成的回答结束后输出 Done，且不再请求继续生成
+	push(t, server, session, map[string]any{"type": "response.created"})
+	msg = push(t, server, session, map[string]any{
+		"type":     "response.done",
+		"response": map[string]any{"status": "completed", "output": []map[string]any{{"type": "message"}}},
+	})
+	if msg == nil || msg.Type != LiveMsgDone {
+		t.Errorf("期望 LiveMsgDone, 实际 %+v", msg)
+	}
+	if err := session.SendAudio([]byte{0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	f.expectType(t, "input_audio_buffer.append")
+}
+
+func TestOpenAILiveToolResultAfterResponseDone(t *testing.T) {
+	f := newFakeRealtime(t)
+	session, server := f.connect(t, &LiveConfig{})
+	f.expectType(t, "session.update")
+
+	push(t, server, session, map[string]any{"type": "response.created"})
+	push(t, server, session, map[string]any{"type": "response.function_call_arguments.done", "call_id": "call1", "name": "take_screenshot"})
+	msg := push(t, server, session, map[string]any{
+		"type":     "response.done",
+		"response": map[string]any{"status": "completed", "output": []map[string]any{{"type": "function_call"}}},
+	})
+	if msg != nil {
+		t.Errorf("只包含工具调用的 response.done 不应输出消息, 实际 %+v", msg)
+	}
+
+	// 回答已结束、工具结果后到：发送结果后立即请求继续生成
+	if err := session.SendToolResponse("call1", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	f.expectType(t, "conversation.item.create")
+	f.expectType(t, "response.create")
+}
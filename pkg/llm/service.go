@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	imageutil "Q-Solver/pkg/ImageUtil"
 	"Q-Solver/pkg/config"
 	"Q-Solver/pkg/logger"
 )
@@ -54,6 +55,62 @@ func (s *Service) GetProvider() Provider {
 	return s.provider
 }
 
+// ProviderForModel 获取指定模型的 Provider
+// 模型与当前配置一致时直接复用当前 Provider，否则基于配置副本创建临时 Provider
+func (s *Service) ProviderForModel(model string) Provider {
+	if model == "" || model == s.config.Model {
+		return s.provider
+	}
+	tempConfig := s.config
+	tempConfig.Model = model
+
+	providerType := DetectProviderType(s.config.Provider)
+	return CreateProvider(providerType, &tempConfig)
+}
+
+// maxImageBase64Bytes 各提供商单张图片的大小上限（Base64 编码后），留有余量
+var maxImageBase64Bytes = map[ProviderType]int{
+	ProviderOpenAI: 20 * 1024 * 1024,
+	ProviderGemini: 15 * 1024 * 1024, // 内联数据整个请求不超过 20MB
+	ProviderClaude: 5 * 1024 * 1024,
+	ProviderCustom: 5 * 1024 * 1024, // 中转可能转发给任意模型，按最严格的限制
+}
+
+// ImageTargetBytes 截图编码的目标大小：配置了目标时取配置值，且不超过提供商上限
+func ImageTargetBytes(cfg config.Config) int {
+	providerType := DetectProviderType(cfg.Provider)
+	limit := maxImageBase64Bytes[providerType]
+	if target := cfg.ImageTargetBytes[string(providerType)]; target > 0 && (limit == 0 || target < limit) {
+		return target
+	}
+	return limit
+}
+
+// VisionPricing 当前模型的图片计费方式（中转服务按模型名称判断）
+func VisionPricing(cfg config.Config) string {
+	providerType := DetectProviderType(cfg.Provider)
+	if providerType == ProviderCustom {
+		model := strings.ToLower(cfg.Model)
+		switch {
+		case strings.HasPrefix(model, "gemini"):
+			providerType = ProviderGemini
+		case strings.HasPrefix(model, "claude"):
+			providerType = ProviderClaude
+		default:
+			providerType = ProviderOpenAI
+		}
+	}
+
+	switch providerType {
+	case ProviderGemini:
+		return imageutil.PricingGemini
+	case ProviderClaude:
+		return imageutil.PricingClaude
+	default:
+		return imageutil.PricingOpenAI
+	}
+}
+
 // DetectProviderType 根据 baseURL 或 model 名称自动识别提供商
 func DetectProviderType(Provider string) ProviderType {
 	switch {
@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTranscribeModel 未配置转写模型时使用的模型名
+const DefaultTranscribeModel = "whisper-1"
+
+// Transcriber 语音转写客户端
+// 兼容 OpenAI 的 /audio/transcriptions 接口和 whisper.cpp server 的 /inference 接口（两者都接收 multipart 上传，返回 {"text": "..."}）
+type Transcriber struct {
+	url        string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewTranscriber 创建转写客户端，url 为完整的接口地址
+func NewTranscriber(url, apiKey, model string) *Transcriber {
+	if model == "" {
+		model = DefaultTranscribeModel
+	}
+	return &Transcriber{
+		url:        url,
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// URL 转写接口地址
+func (t *Transcriber) URL() string {
+	return t.url
+}
+
+// Transcribe 转写一段 WAV 音频，language 为空时由服务端自动识别
+func (t *Transcriber) Transcribe(ctx context.Context, wav []byte, language string) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "audio.wav")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(wav); err != nil {
+		return "", err
+	}
+	fields := map[string]string{
+		"model":           t.model,
+		"response_format": "json",
+		"language":        language,
+	}
+	for key, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(key, value); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("转写请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("转写服务返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("解析转写结果失败: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
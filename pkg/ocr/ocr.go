@@ -0,0 +1,102 @@
+package ocr
+
+import (
+	"Q-Solver/pkg/config"
+	"context"
+	"image"
+	"strings"
+)
+
+// Line 识别出的一行文字
+type Line struct {
+	Text       string  `json:"text"`
+	Indent     int     `json:"indent"`     // 按字符宽度估算的缩进
+	Confidence float64 `json:"confidence"` // 0-1
+}
+
+// Result OCR 识别结果
+type Result struct {
+	Text       string  `json:"text"`       // 保留大致排版的全文
+	Lines      []Line  `json:"lines"`      // 逐行结果
+	Confidence float64 `json:"confidence"` // 平均置信度 0-1
+	Engine     string  `json:"engine"`
+}
+
+// Empty 是否没有识别出任何文字
+func (r Result) Empty() bool {
+	return strings.TrimSpace(r.Text) == ""
+}
+
+// Engine OCR 引擎
+type Engine interface {
+	Name() string
+	Recognize(ctx context.Context, img image.Image) (Result, error)
+}
+
+// StaticEngine 返回固定结果的纯 Go 引擎，用于测试和没有安装 Tesseract 时调试流程
+type StaticEngine struct {
+	Result Result
+}
+
+func (e *StaticEngine) Name() string {
+	return "static"
+}
+
+func (e *StaticEngine) Recognize(ctx context.Context, img image.Image) (Result, error) {
+	result := e.Result
+	result.Engine = e.Name()
+	if result.Text == "" && len(result.Lines) > 0 {
+		result.Text = joinLines(result.Lines)
+	}
+	return result, nil
+}
+
+// joinLines 按缩进拼接各行
+func joinLines(lines []Line) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(strings.Repeat(" ", line.Indent))
+		sb.WriteString(line.Text)
+	}
+	return sb.String()
+}
+
+// NewEngine 根据配置创建 OCR 引擎
+func NewEngine(cfg config.Config) (Engine, error) {
+	engine, err := NewTesseractEngine(cfg.TesseractPath, cfg.OCRLanguages)
+	if err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// RecognizeAll 依次识别多张截图（多屏模式），合并为一个结果
+// 合并后的置信度按各张图识别出的行数加权
+func RecognizeAll(ctx context.Context, engine Engine, images []image.Image) (Result, error) {
+	merged := Result{Engine: engine.Name()}
+	var texts []string
+	var weightedConf float64
+	var weight int
+	for _, img := range images {
+		result, err := engine.Recognize(ctx, img)
+		if err != nil {
+			return Result{}, err
+		}
+		if result.Empty() {
+			continue
+		}
+		texts = append(texts, result.Text)
+		merged.Lines = append(merged.Lines, result.Lines...)
+		n := max(len(result.Lines), 1)
+		weightedConf += result.Confidence * float64(n)
+		weight += n
+	}
+	merged.Text = strings.Join(texts, "\n\n")
+	if weight > 0 {
+		merged.Confidence = weightedConf / float64(weight)
+	}
+	return merged, nil
+}
@@ -0,0 +1,143 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguages Tesseract 默认识别语言
+const DefaultLanguages = "chi_sim+eng"
+
+// TesseractEngine 调用本地安装的 tesseract 命令识别文字
+type TesseractEngine struct {
+	path      string
+	languages string
+}
+
+// NewTesseractEngine 创建 Tesseract 引擎，path 为空时从 PATH 中查找
+func NewTesseractEngine(path, languages string) (*TesseractEngine, error) {
+	if path == "" {
+		path = "tesseract"
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("未找到 tesseract: %w", err)
+	}
+	if languages == "" {
+		languages = DefaultLanguages
+	}
+	return &TesseractEngine{path: resolved, languages: languages}, nil
+}
+
+func (e *TesseractEngine) Name() string {
+	return "tesseract"
+}
+
+// Recognize 以 TSV 格式输出识别结果，按行还原排版
+func (e *TesseractEngine) Recognize(ctx context.Context, img image.Image) (Result, error) {
+	var input bytes.Buffer
+	if err := png.Encode(&input, img); err != nil {
+		return Result{}, fmt.Errorf("图片编码失败: %w", err)
+	}
+
+	// --psm 6: 视为统一的文本块，对代码和题面的排版保留较好
+	cmd := exec.CommandContext(ctx, e.path, "stdin", "stdout", "-l", e.languages, "--psm", "6", "tsv")
+	cmd.Stdin = &input
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return Result{}, fmt.Errorf("tesseract 执行失败: %v (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	result := parseTSV(stdout.Bytes())
+	result.Engine = e.Name()
+	return result, nil
+}
+
+// tsvWord TSV 中的一个单词
+type tsvWord struct {
+	block, par, line int
+	left, width      int
+	conf             float64
+	text             string
+}
+
+// parseTSV 解析 tesseract 的 TSV 输出
+// 列: level page_num block_num par_num line_num word_num left top width height conf text
+func parseTSV(data []byte) Result {
+	var words []tsvWord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for first := true; scanner.Scan(); first = false {
+		if first {
+			continue // 表头
+		}
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 12 || fields[0] != "5" {
+			continue // 只处理单词级别
+		}
+		text := strings.TrimSpace(fields[11])
+		conf, err := strconv.ParseFloat(fields[10], 64)
+		if text == "" || err != nil || conf < 0 {
+			continue
+		}
+		w := tsvWord{conf: conf, text: text}
+		w.block, _ = strconv.Atoi(fields[2])
+		w.par, _ = strconv.Atoi(fields[3])
+		w.line, _ = strconv.Atoi(fields[4])
+		w.left, _ = strconv.Atoi(fields[6])
+		w.width, _ = strconv.Atoi(fields[8])
+		words = append(words, w)
+	}
+	if len(words) == 0 {
+		return Result{}
+	}
+
+	// 估算字符宽度和左边界，用于还原缩进
+	minLeft := words[0].left
+	var totalWidth, totalChars int
+	for _, w := range words {
+		minLeft = min(minLeft, w.left)
+		totalWidth += w.width
+		totalChars += len([]rune(w.text))
+	}
+	charWidth := max(float64(totalWidth)/float64(max(totalChars, 1)), 1)
+
+	var lines []Line
+	var totalConf float64
+	for i := 0; i < len(words); {
+		start := words[i]
+		if len(lines) > 0 && (start.block != words[i-1].block || start.par != words[i-1].par) {
+			lines = append(lines, Line{}) // 段落之间空一行
+		}
+
+		var texts []string
+		var lineConf float64
+		j := i
+		for ; j < len(words) && words[j].block == start.block && words[j].par == start.par && words[j].line == start.line; j++ {
+			texts = append(texts, words[j].text)
+			lineConf += words[j].conf
+			totalConf += words[j].conf
+		}
+		lines = append(lines, Line{
+			Text:       strings.Join(texts, " "),
+			Indent:     int(float64(start.left-minLeft)/charWidth + 0.5),
+			Confidence: lineConf / float64(j-i) / 100,
+		})
+		i = j
+	}
+
+	return Result{
+		Text:       joinLines(lines),
+		Lines:      lines,
+		Confidence: totalConf / float64(len(words)) / 100,
+	}
+}
@@ -0,0 +1,66 @@
+package ocr
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// tsvRows 拼接 TSV 输出（含表头）
+func tsvRows(rows ...string) []byte {
+	header := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext"
+	return []byte(strings.Join(append([]string{header}, rows...), "\n") + "\n")
+}
+
+func TestParseTSV(t *testing.T) {
+	// 每个字符宽 10px：第二行缩进 4 个字符，第二段与第一段之间空一行
+	data := tsvRows(
+		"1\t1\t0\t0\t0\t0\t0\t0\t800\t600\t-1\t",
+		"4\t1\t1\t1\t1\t0\t100\t10\t300\t20\t-1\t",
+		"5\t1\t1\t1\t1\t1\t100\t10\t30\t20\t96.5\tfor",
+		"5\t1\t1\t1\t1\t2\t140\t10\t10\t20\t93.5\ti",
+		"5\t1\t1\t1\t2\t1\t140\t40\t60\t20\t90\treturn",
+		"5\t1\t1\t1\t2\t2\t210\t40\t10\t20\t-1\t ",
+		"5\t1\t1\t2\t1\t1\t100\t80\t50\t20\t80\tinput",
+	)
+
+	result := parseTSV(data)
+	wantText := "for i\n    return\n\ninput"
+	if result.Text != wantText {
+		t.Errorf("Text = %q, 期望 %q", result.Text, wantText)
+	}
+
+	wantLines := []Line{
+		{Text: "for i", Indent: 0, Confidence: 0.95},
+		{Text: "return", Indent: 4, Confidence: 0.9},
+		{},
+		{Text: "input", Indent: 0, Confidence: 0.8},
+	}
+	if len(result.Lines) != len(wantLines) {
+		t.Fatalf("Lines = %+v", result.Lines)
+	}
+	for i, want := range wantLines {
+		got := result.Lines[i]
+		if got.Text != want.Text || got.Indent != want.Indent || math.Abs(got.Confidence-want.Confidence) > 1e-9 {
+			t.Errorf("第 %d 行 = %+v, 期望 %+v", i, got, want)
+		}
+	}
+
+	// 平均置信度按单词计算，跳过空白和置信度为 -1 的单词
+	if want := (96.5 + 93.5 + 90 + 80) / 4 / 100; math.Abs(result.Confidence-want) > 1e-9 {
+		t.Errorf("Confidence = %v, 期望 %v", result.Confidence, want)
+	}
+}
+
+func TestParseTSVEmpty(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"空输出":  nil,
+		"只有表头": tsvRows(),
+		"没有单词": tsvRows("1\t1\t0\t0\t0\t0\t0\t0\t800\t600\t-1\t", "5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t-1\t"),
+		"列数不足": tsvRows("5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t95"),
+	} {
+		if result := parseTSV(data); !result.Empty() || len(result.Lines) != 0 {
+			t.Errorf("%s: 期望空结果, 实际 %+v", name, result)
+		}
+	}
+}
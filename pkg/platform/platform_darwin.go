@@ -9,6 +9,7 @@ package platform
 #import <Cocoa/Cocoa.h>
 #import <CoreGraphics/CoreGraphics.h>
 #import <AVFoundation/AVFoundation.h>
+#include <stdlib.h>
 
 // 检查截图权限 (macOS 10.15+)
 bool CheckScreenCaptureAccessC() {
@@ -162,6 +163,39 @@ int CheckMicrophoneAccessC() {
     return 1; // macOS 10.14 以下不需要权限
 }
 
+// 获取鼠标位置（全局坐标，左上角为原点）
+void GetCursorPositionC(int* x, int* y) {
+    CGEventRef event = CGEventCreate(NULL);
+    CGPoint point = CGEventGetLocation(event);
+    CFRelease(event);
+    *x = (int)point.x;
+    *y = (int)point.y;
+}
+
+// 读取剪贴板中的图片（PNG 数据），没有图片时返回 NULL，调用方负责 free
+void* GetClipboardImageC(int* length) {
+    @autoreleasepool {
+        NSPasteboard* pasteboard = [NSPasteboard generalPasteboard];
+        NSData* data = [pasteboard dataForType:NSPasteboardTypePNG];
+        if (data == nil) {
+            // 截图工具通常只提供 TIFF
+            NSData* tiff = [pasteboard dataForType:NSPasteboardTypeTIFF];
+            if (tiff != nil) {
+                NSBitmapImageRep* rep = [NSBitmapImageRep imageRepWithData:tiff];
+                data = [rep representationUsingType:NSBitmapImageFileTypePNG properties:@{}];
+            }
+        }
+        if (data == nil || data.length == 0) {
+            *length = 0;
+            return NULL;
+        }
+        void* buf = malloc(data.length);
+        memcpy(buf, data.bytes, data.length);
+        *length = (int)data.length;
+        return buf;
+    }
+}
+
 // 请求麦克风权限
 void RequestMicrophoneAccessC(void (*callback)(bool granted)) {
     if (@available(macOS 10.14, *)) {
@@ -302,3 +336,21 @@ func RequestMicrophoneAccess() {
 func OpenMicrophoneSettings() {
 	C.OpenMicrophoneSettingsC()
 }
+
+// GetCursorPosition 获取鼠标在屏幕上的位置
+func GetCursorPosition() (int, int, error) {
+	var x, y C.int
+	C.GetCursorPositionC(&x, &y)
+	return int(x), int(y), nil
+}
+
+// GetClipboardImage 读取剪贴板中的图片（PNG 编码），剪贴板中没有图片时返回 nil
+func GetClipboardImage() ([]byte, error) {
+	var length C.int
+	ptr := C.GetClipboardImageC(&length)
+	if ptr == nil {
+		return nil, nil
+	}
+	defer C.free(ptr)
+	return C.GoBytes(ptr, length), nil
+}
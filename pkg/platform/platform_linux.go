@@ -0,0 +1,283 @@
+//go:build linux
+
+package platform
+
+import (
+	"Q-Solver/pkg/logger"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/shape"
+	"github.com/jezek/xgb/xproto"
+)
+
+// WindowHandle 窗口句柄类型（X11 为 Window ID）
+type WindowHandle uintptr
+
+// 窗口级别常量
+const (
+	WindowLevelNormal   = 0 // 正常窗口级别
+	WindowLevelFloating = 3 // 置顶窗口级别
+)
+
+// _NET_WM_STATE 客户端消息的操作类型
+const (
+	netWMStateRemove = 0
+	netWMStateAdd    = 1
+)
+
+// ErrWayland Wayland 会话下无法操作其他窗口和全局热键
+var ErrWayland = errors.New("Wayland 会话不支持该操作，请切换到 X11 会话")
+
+var (
+	x11Once  sync.Once
+	x11Conn  *xgb.Conn
+	x11Root  xproto.Window
+	x11Err   error
+	hasShape bool
+)
+
+// IsWayland 当前是否运行在 Wayland 会话中
+func IsWayland() bool {
+	return os.Getenv("XDG_SESSION_TYPE") == "wayland" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// X11Conn 获取共享的 X11 连接，Wayland 下返回 ErrWayland
+func X11Conn() (*xgb.Conn, xproto.Window, error) {
+	x11Once.Do(func() {
+		if IsWayland() {
+			x11Err = ErrWayland
+			logger.Println("[Linux] 检测到 Wayland 会话，窗口和热键相关功能将不可用")
+			return
+		}
+		x11Conn, x11Err = xgb.NewConn()
+		if x11Err != nil {
+			logger.Printf("[Linux] 连接 X11 失败: %v", x11Err)
+			return
+		}
+		x11Root = xproto.Setup(x11Conn).DefaultScreen(x11Conn).Root
+		if err := shape.Init(x11Conn); err != nil {
+			logger.Printf("[Linux] X11 Shape 扩展不可用，鼠标穿透将不可用: %v", err)
+		} else {
+			hasShape = true
+		}
+	})
+	return x11Conn, x11Root, x11Err
+}
+
+// ==================== 平台接口函数 ====================
+
+// GetWindowHandle 获取当前进程主窗口（按 _NET_WM_PID 查找）
+func GetWindowHandle() (WindowHandle, error) {
+	conn, root, err := X11Conn()
+	if err != nil {
+		return 0, err
+	}
+
+	clients, err := getProperty32(conn, root, "_NET_CLIENT_LIST")
+	if err != nil {
+		return 0, err
+	}
+	pid := uint32(os.Getpid())
+	for _, client := range clients {
+		values, err := getProperty32(conn, xproto.Window(client), "_NET_WM_PID")
+		if err == nil && len(values) > 0 && values[0] == pid {
+			return WindowHandle(client), nil
+		}
+	}
+	return 0, fmt.Errorf("未找到当前进程的窗口")
+}
+
+// ApplyGhostMode 应用幽灵模式（置顶、不显示在任务栏和切换器中）
+// X11 没有防录屏接口，截图软件仍然可以看到窗口
+func ApplyGhostMode(hwnd WindowHandle) error {
+	if err := setNetWMState(hwnd, netWMStateAdd, "_NET_WM_STATE_SKIP_TASKBAR", "_NET_WM_STATE_SKIP_PAGER"); err != nil {
+		return err
+	}
+	return setNetWMState(hwnd, netWMStateAdd, "_NET_WM_STATE_ABOVE", "")
+}
+
+// SetClickThrough 设置鼠标穿透（清空窗口的输入区域）
+func SetClickThrough(hwnd WindowHandle, enabled bool) error {
+	conn, _, err := X11Conn()
+	if err != nil {
+		return err
+	}
+	if !hasShape {
+		return fmt.Errorf("X11 Shape 扩展不可用")
+	}
+	window := xproto.Window(hwnd)
+	if enabled {
+		return shape.RectanglesChecked(conn, shape.SoSet, shape.SkInput, 0, window, 0, 0, nil).Check()
+	}
+	// 恢复默认输入区域
+	return shape.MaskChecked(conn, shape.SoSet, shape.SkInput, window, 0, 0, xproto.PixmapNone).Check()
+}
+
+// SetDisplayAffinity 设置防录屏状态 (X11 不支持，无操作)
+func SetDisplayAffinity(hwnd WindowHandle, hidden bool) error {
+	return nil
+}
+
+// RestoreFocus 恢复焦点（请求窗口管理器激活窗口）
+func RestoreFocus(hwnd WindowHandle) error {
+	conn, root, err := X11Conn()
+	if err != nil {
+		return err
+	}
+	// 数据依次为：来源（2 表示来自用户操作）、时间戳、当前活动窗口
+	return sendClientMessage(conn, root, xproto.Window(hwnd), "_NET_ACTIVE_WINDOW", []uint32{2, xproto.TimeCurrentTime, 0})
+}
+
+// RemoveFocus 移除焦点（交还给鼠标所在的窗口）
+func RemoveFocus(hwnd WindowHandle) error {
+	conn, _, err := X11Conn()
+	if err != nil {
+		return err
+	}
+	return xproto.SetInputFocusChecked(conn, xproto.InputFocusPointerRoot, xproto.InputFocusPointerRoot, xproto.TimeCurrentTime).Check()
+}
+
+// CheckScreenCaptureAccess 检查截图权限（X11 无需授权，Wayland 无法截图）
+func CheckScreenCaptureAccess() bool {
+	return !IsWayland()
+}
+
+// RequestScreenCaptureAccess 请求截图权限（Linux 无授权流程）
+func RequestScreenCaptureAccess() bool {
+	return CheckScreenCaptureAccess()
+}
+
+// OpenScreenCaptureSettings 打开系统设置的屏幕录制权限页面 (Linux 无操作)
+func OpenScreenCaptureSettings() {
+}
+
+// SetWindowLevel 设置窗口层级（_NET_WM_STATE_ABOVE）
+func SetWindowLevel(hwnd WindowHandle, level int) error {
+	action := uint32(netWMStateRemove)
+	if level >= WindowLevelFloating {
+		action = netWMStateAdd
+	}
+	return setNetWMState(hwnd, action, "_NET_WM_STATE_ABOVE", "")
+}
+
+// CheckMicrophoneAccess 检查麦克风权限状态 (Linux 直接返回已授权)
+func CheckMicrophoneAccess() int {
+	return 1
+}
+
+// RequestMicrophoneAccess 请求麦克风权限 (Linux 无操作)
+func RequestMicrophoneAccess() {
+}
+
+// OpenMicrophoneSettings 打开系统设置的麦克风权限页面 (Linux 无操作)
+func OpenMicrophoneSettings() {
+}
+
+// GetCursorPosition 获取鼠标在屏幕上的位置
+func GetCursorPosition() (int, int, error) {
+	conn, root, err := X11Conn()
+	if err != nil {
+		return 0, 0, err
+	}
+	reply, err := xproto.QueryPointer(conn, root).Reply()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(reply.RootX), int(reply.RootY), nil
+}
+
+// GetClipboardImage 读取剪贴板中的图片（PNG 编码），剪贴板中没有图片时返回 nil
+// X11 使用 xclip，Wayland 使用 wl-paste，未安装时视为没有图片
+func GetClipboardImage() ([]byte, error) {
+	name, args := "xclip", []string{"-selection", "clipboard", "-t", "image/png", "-o"}
+	if IsWayland() {
+		name, args = "wl-paste", []string{"--no-newline", "--type", "image/png"}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		logger.Printf("[Linux] 未找到 %s，无法读取剪贴板图片", name)
+		return nil, nil
+	}
+	// 剪贴板中没有 PNG 时命令返回非零，按没有图片处理
+	data, err := exec.Command(path, args...).Output()
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// ==================== X11 辅助函数 ====================
+
+// internAtom 获取原子
+func internAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return reply.Atom, nil
+}
+
+// getProperty32 读取 32 位格式的窗口属性
+func getProperty32(conn *xgb.Conn, window xproto.Window, name string) ([]uint32, error) {
+	atom, err := internAtom(conn, name)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := xproto.GetProperty(conn, false, window, atom, xproto.GetPropertyTypeAny, 0, 1024).Reply()
+	if err != nil {
+		return nil, err
+	}
+	if reply.Format != 32 {
+		return nil, nil
+	}
+	values := make([]uint32, 0, reply.ValueLen)
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		values = append(values, binary.LittleEndian.Uint32(reply.Value[i:]))
+	}
+	return values, nil
+}
+
+// setNetWMState 通过窗口管理器修改 _NET_WM_STATE（最多同时修改两个状态）
+func setNetWMState(hwnd WindowHandle, action uint32, first, second string) error {
+	conn, root, err := X11Conn()
+	if err != nil {
+		return err
+	}
+	firstAtom, err := internAtom(conn, first)
+	if err != nil {
+		return err
+	}
+	var secondAtom xproto.Atom
+	if second != "" {
+		if secondAtom, err = internAtom(conn, second); err != nil {
+			return err
+		}
+	}
+	// 最后一项 1 表示请求来自普通应用
+	return sendClientMessage(conn, root, xproto.Window(hwnd), "_NET_WM_STATE", []uint32{action, uint32(firstAtom), uint32(secondAtom), 1, 0})
+}
+
+// sendClientMessage 向根窗口发送 EWMH 客户端消息
+func sendClientMessage(conn *xgb.Conn, root, window xproto.Window, messageType string, data []uint32) error {
+	atom, err := internAtom(conn, messageType)
+	if err != nil {
+		return err
+	}
+	for len(data) < 5 {
+		data = append(data, 0)
+	}
+	event := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: window,
+		Type:   atom,
+		Data:   xproto.ClientMessageDataUnionData32New(data),
+	}
+	mask := uint32(xproto.EventMaskSubstructureNotify | xproto.EventMaskSubstructureRedirect)
+	return xproto.SendEventChecked(conn, false, root, mask, string(event.Bytes())).Check()
+}
@@ -4,6 +4,11 @@ package platform
 
 import (
 	"Q-Solver/pkg/logger"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"syscall"
 	"unsafe"
@@ -30,6 +35,15 @@ var (
 	procEnumWindows                = user32.NewProc("EnumWindows")
 	procGetWindowThreadProcessId   = user32.NewProc("GetWindowThreadProcessId")
 	procKeybdEvent                 = user32.NewProc("keybd_event")
+	procGetCursorPos               = user32.NewProc("GetCursorPos")
+	procOpenClipboard              = user32.NewProc("OpenClipboard")
+	procCloseClipboard             = user32.NewProc("CloseClipboard")
+	procIsClipboardFormatAvailable = user32.NewProc("IsClipboardFormatAvailable")
+	procGetClipboardData           = user32.NewProc("GetClipboardData")
+	procGlobalLock                 = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock               = kernel32.NewProc("GlobalUnlock")
+	procGlobalSize                 = kernel32.NewProc("GlobalSize")
+	procRtlMoveMemory              = kernel32.NewProc("RtlMoveMemory")
 )
 
 // WindowHandle 窗口句柄类型（Windows 为 HWND）
@@ -120,6 +134,11 @@ const (
 	WS_EX_TOPMOST = 0x00000008
 	//不激活窗口(刷新样式时用)
 	SWP_NOACTIVATE = 0x0010
+
+	// 剪贴板位图格式
+	CF_DIB       = 8
+	BI_RGB       = 0
+	BI_BITFIELDS = 3
 )
 
 // KBDLLHOOKSTRUCT 键盘钩子结构体
@@ -417,6 +436,101 @@ func GetAsyncKeyState(vKey int) uint16 {
 	return uint16(ret)
 }
 
+// GetCursorPosition 获取鼠标在屏幕上的位置
+func GetCursorPosition() (int, int, error) {
+	var pt struct{ X, Y int32 }
+	ret, _, err := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	if ret == 0 {
+		return 0, 0, err
+	}
+	return int(pt.X), int(pt.Y), nil
+}
+
+// GetClipboardImage 读取剪贴板中的图片（PNG 编码），剪贴板中没有图片时返回 nil
+func GetClipboardImage() ([]byte, error) {
+	if ret, _, _ := procIsClipboardFormatAvailable.Call(CF_DIB); ret == 0 {
+		return nil, nil
+	}
+	if ret, _, err := procOpenClipboard.Call(0); ret == 0 {
+		return nil, fmt.Errorf("打开剪贴板失败: %v", err)
+	}
+	defer procCloseClipboard.Call()
+
+	handle, _, err := procGetClipboardData.Call(CF_DIB)
+	if handle == 0 {
+		return nil, fmt.Errorf("读取剪贴板失败: %v", err)
+	}
+	ptr, _, err := procGlobalLock.Call(handle)
+	if ptr == 0 {
+		return nil, fmt.Errorf("读取剪贴板失败: %v", err)
+	}
+	defer procGlobalUnlock.Call(handle)
+	size, _, _ := procGlobalSize.Call(handle)
+	dib := make([]byte, size)
+	if size > 0 {
+		procRtlMoveMemory.Call(uintptr(unsafe.Pointer(&dib[0])), ptr, size)
+	}
+
+	img, err := decodeDIB(dib)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeDIB 解析 CF_DIB 格式的位图（支持 24/32 位未压缩或 BI_BITFIELDS）
+func decodeDIB(dib []byte) (image.Image, error) {
+	if len(dib) < 40 {
+		return nil, fmt.Errorf("剪贴板位图数据不完整")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(dib[0:]))
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:])))
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:])))
+	bitCount := int(binary.LittleEndian.Uint16(dib[14:]))
+	compression := binary.LittleEndian.Uint32(dib[16:])
+	if bitCount != 24 && bitCount != 32 {
+		return nil, fmt.Errorf("不支持的位图格式: %d 位", bitCount)
+	}
+	if compression != BI_RGB && compression != BI_BITFIELDS {
+		return nil, fmt.Errorf("不支持的位图压缩方式: %d", compression)
+	}
+
+	// 高度为负表示自上而下存储
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	offset := headerSize
+	if compression == BI_BITFIELDS && headerSize == 40 {
+		offset += 12 // 紧跟在 BITMAPINFOHEADER 后的三个颜色掩码
+	}
+	stride := (width*bitCount + 31) / 32 * 4
+	if width <= 0 || height <= 0 || offset+stride*height > len(dib) {
+		return nil, fmt.Errorf("剪贴板位图数据不完整")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	bytesPerPixel := bitCount / 8
+	for y := 0; y < height; y++ {
+		srcY := height - 1 - y
+		if topDown {
+			srcY = y
+		}
+		row := dib[offset+srcY*stride:]
+		for x := 0; x < width; x++ {
+			p := row[x*bytesPerPixel:]
+			i := img.PixOffset(x, y)
+			// 剪贴板中的 32 位图片 alpha 通道通常无效，统一视为不透明
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = p[2], p[1], p[0], 0xFF
+		}
+	}
+	return img, nil
+}
+
 // getHwndByPid 根据进程ID获取窗口句柄
 func getHwndByPid(pid uint32) (uintptr, error) {
 	var hwnd uintptr
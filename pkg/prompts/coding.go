@@ -0,0 +1,65 @@
+package prompts
+
+import (
+	"Q-Solver/pkg/config"
+	"fmt"
+	"strings"
+)
+
+// WithCodingProfile 按配置中的编程偏好在系统提示词末尾追加编程要求
+// language 为本次使用的目标语言（可能是快捷键临时切换的语言），为空时使用配置中的语言
+func WithCodingProfile(prompt string, cfg config.Config, language string) string {
+	if language == "" {
+		language = cfg.CodingLanguage
+	}
+
+	var rules []string
+	for _, rule := range cfg.CodingStyleRules {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+
+	if language == "" && cfg.NamingConvention == "" && len(rules) == 0 {
+		return prompt
+	}
+
+	pack := GetPack(cfg.Locale)
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString(pack.CodingHeader)
+
+	if language != "" {
+		// 版本只对配置中的主语言生效，临时切换的语言不附带版本
+		if cfg.LanguageVersion != "" && language == cfg.CodingLanguage {
+			language = language + " (" + cfg.LanguageVersion + ")"
+		}
+		sb.WriteString(fmt.Sprintf(pack.CodingLanguage, language))
+	}
+	if cfg.NamingConvention != "" {
+		sb.WriteString(fmt.Sprintf(pack.CodingNaming, cfg.NamingConvention))
+	}
+	if len(rules) > 0 {
+		sb.WriteString(pack.CodingRules)
+		for _, rule := range rules {
+			sb.WriteString("  - " + rule + "\n")
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// CodingLanguages 快捷键切换编程语言时的候选列表（主语言在前，去重）
+func CodingLanguages(cfg config.Config) []string {
+	seen := make(map[string]bool)
+	var languages []string
+	for _, lang := range append([]string{cfg.CodingLanguage}, cfg.AlternateLanguages...) {
+		lang = strings.TrimSpace(lang)
+		if lang == "" || seen[strings.ToLower(lang)] {
+			continue
+		}
+		seen[strings.ToLower(lang)] = true
+		languages = append(languages, lang)
+	}
+	return languages
+}
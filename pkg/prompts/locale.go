@@ -0,0 +1,110 @@
+package prompts
+
+import (
+	"Q-Solver/pkg/config"
+	"fmt"
+	"strings"
+)
+
+// Pack 一种语言的内置提示词包
+type Pack struct {
+	Solve            string // 解题模板
+	Live             string // Live 模板
+	Graph            string // 问题导图总结模板
+	Resume           string // 简历解析提示词（不是模板，其中的占位符原样发给模型）
+	ConsistencyJudge string // 自洽模式评审模板（%s: 各模型回答）
+	ResumeAttachment string // PDF 简历作为附件时的说明
+	ConsistencyEntry string // 评审时单个回答的格式（%d: 编号, %s: 模型, %s: 回答）
+	GraphNoNodes     string // 导图暂无节点时的占位
+	GraphNodeLine    string // 导图已有节点的格式（%s: ID, %s: 标题, %s: 回答）
+	GraphRound       string // 导图对话轮的格式（%d: 轮次, %s: 问题, %s: 回答）
+	AnswerLanguage   string // 强制回答语言的指令（%s: 语言名称）
+	CodingHeader     string // 编程偏好段落标题
+	CodingLanguage   string // 目标语言（%s: 语言及版本）
+	CodingNaming     string // 命名规范（%s: 规范）
+	CodingRules      string // 代码风格要求列表的标题
+	OCRText          string // 以 OCR 文字代替截图时的说明（%s: 识别出的文字）
+	OCRLowResImage   string // 同时附带低清截图时的说明
+	PastedText       string // 以剪贴板或文件中的文字作为题目时的说明（%s: 文字内容）
+}
+
+var packs = map[string]Pack{
+	config.LocaleZhCN: {
+		Solve:            builtinSolveTemplate,
+		Live:             builtinLiveTemplate,
+		Graph:            GraphSummarizePromptTemplate,
+		Resume:           ResumeParsePrompt,
+		ConsistencyJudge: ConsistencyJudgePromptTemplate,
+		ResumeAttachment: "\n\n# 候选人简历已作为附件发送，请参考简历内容回答。",
+		ConsistencyEntry: "## 回答 %d（%s）\n%s\n\n",
+		GraphNoNodes:     "（暂无已有节点）\n",
+		GraphNodeLine:    "- NodeID: %s NodeTitle: %s NodeAnswer: %s \n",
+		GraphRound:       "第%d轮：\n问：%s\n答：%s\n\n",
+		AnswerLanguage:   "\n\n# 回答语言\n无论题目、简历或对话使用何种语言，都必须使用%s作答，代码中的标识符保持原样。",
+		CodingHeader:     "\n\n# 编程偏好\n",
+		CodingLanguage:   "- 代码必须使用 %s 编写，除非题目明确限定了其他语言\n",
+		CodingNaming:     "- 命名规范：%s\n",
+		CodingRules:      "- 代码风格要求：\n",
+		OCRText:          "以下是屏幕截图经 OCR 识别出的文字，保留了大致排版，可能存在个别识别错误，请结合上下文理解：\n```\n%s\n```",
+		OCRLowResImage:   "附带的低分辨率截图仅用于参考排版和图形，文字以上面的识别结果为准。",
+		PastedText:       "以下是用户粘贴的题目内容：\n```\n%s\n```",
+	},
+	config.LocaleEnUS: {
+		Solve:            builtinSolveTemplateEN,
+		Live:             builtinLiveTemplate,
+		Graph:            GraphSummarizePromptTemplateEN,
+		Resume:           ResumeParsePromptEN,
+		ConsistencyJudge: ConsistencyJudgePromptTemplateEN,
+		ResumeAttachment: "\n\n# The candidate's resume is attached; refer to it when answering.",
+		ConsistencyEntry: "## Answer %d (%s)\n%s\n\n",
+		GraphNoNodes:     "(no existing nodes)\n",
+		GraphNodeLine:    "- NodeID: %s NodeTitle: %s NodeAnswer: %s \n",
+		GraphRound:       "Round %d:\nQ: %s\nA: %s\n\n",
+		AnswerLanguage:   "\n\n# Answer language\nAlways answer in %s, whatever language the question, resume or dialogue uses. Keep code identifiers unchanged.",
+		CodingHeader:     "\n\n# Coding preferences\n",
+		CodingLanguage:   "- Write all code in %s unless the problem explicitly requires another language\n",
+		CodingNaming:     "- Naming convention: %s\n",
+		CodingRules:      "- Style rules:\n",
+		OCRText:          "Below is the text recognized from the screenshot by OCR. Layout is roughly preserved and there may be occasional recognition errors; interpret it in context:\n```\n%s\n```",
+		OCRLowResImage:   "The attached low-resolution screenshot is only for layout and figures; rely on the recognized text above for the wording.",
+		PastedText:       "Below is the problem pasted by the user:\n```\n%s\n```",
+	},
+}
+
+// languageNames 已知语言代码对应的名称，按提示词语言区分
+var languageNames = map[string]map[string]string{
+	config.LocaleZhCN: {
+		config.LocaleZhCN: "简体中文",
+		config.LocaleEnUS: "英文",
+	},
+	config.LocaleEnUS: {
+		config.LocaleZhCN: "Simplified Chinese",
+		config.LocaleEnUS: "English",
+	},
+}
+
+// GetPack 获取指定语言的提示词包，未知语言回退到简体中文
+func GetPack(locale string) Pack {
+	if pack, ok := packs[locale]; ok {
+		return pack
+	}
+	return packs[config.LocaleZhCN]
+}
+
+// WithAnswerLanguage 按配置在系统提示词末尾追加强制回答语言的指令
+// 未设置回答语言时原样返回
+func WithAnswerLanguage(prompt string, cfg config.Config) string {
+	lang := strings.TrimSpace(cfg.AnswerLanguage)
+	if lang == "" {
+		return prompt
+	}
+
+	locale := cfg.Locale
+	if _, ok := packs[locale]; !ok {
+		locale = config.LocaleZhCN
+	}
+	if name, ok := languageNames[locale][lang]; ok {
+		lang = name
+	}
+	return prompt + fmt.Sprintf(GetPack(locale).AnswerLanguage, lang)
+}
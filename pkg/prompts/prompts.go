@@ -1,9 +1,18 @@
 package prompts
 
+// builtinSolveTemplate 内置解题模板：自定义提示词 + Markdown 简历
+const builtinSolveTemplate = `{{.Prompt}}{{if .Resume}}
+
+# 候选人简历内容如下: 
+{{.Resume}}{{end}}`
+
+// builtinLiveTemplate 内置 Live 模板：直接使用自定义提示词
+const builtinLiveTemplate = `{{.Prompt}}`
+
 // GraphSummarizePromptTemplate 问题导图总结提示词模板
-// 占位符说明：
-// - %s: 已有节点列表（可能为空）
-// - %s: 新的对话内容
+// 模板变量说明：
+// - {{.Nodes}}: 已有节点列表（可能为空）
+// - {{.Dialog}}: 新的对话内容
 const GraphSummarizePromptTemplate = `# 角色
 你是面试问题分析助手，负责将面试对话整理成问题导图节点。
 
@@ -37,9 +46,9 @@ const GraphSummarizePromptTemplate = `# 角色
 **默认规则**：无法明确判断时，优先作为"新话题"（pid为null）
 
 # 已有节点
-%s
+{{.Nodes}}
 # 新的对话内容
-%s
+{{.Dialog}}
 # 输出要求
 1. title 不超过10个字，精准概括节点核心
 2. question 包含该节点涉及的所有问题（多个问题用换行分隔）
@@ -110,3 +119,26 @@ const ResumeParsePrompt = `# Role 你是一个**通用型简历重构与解析
 
 # Input Data 
 简历内容见附件。`
+
+// ConsistencyJudgePromptTemplate 自洽模式评审提示词模板
+// 占位符说明：
+// - %s: 各模型的回答（带编号）
+const ConsistencyJudgePromptTemplate = `# 角色
+你是笔试题解答评审员，负责对比多个模型针对同一道题给出的回答。
+
+# 任务
+1. 比较各回答的核心结论（最终答案、算法思路、复杂度）是否一致
+2. 指出一致之处和分歧之处，分歧时说明哪个回答更可信及原因
+3. 选出最值得采用的一个回答
+
+# 各模型的回答
+%s
+# 输出要求
+1. agreement：核心结论全部一致为 true，否则为 false
+2. summary：用 Markdown 简要列出一致点与分歧点，不超过 300 字
+3. recommended：推荐回答的编号（从 1 开始）
+
+# 输出格式
+{"agreement": true, "summary": "评审说明", "recommended": 1}
+
+只输出JSON，不要任何解释。`
@@ -0,0 +1,233 @@
+package prompts
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Kind 提示词模板类型
+type Kind string
+
+const (
+	KindSolve    Kind = "solve"    // 截图解题
+	KindFollowUp Kind = "followup" // 保持上下文时的后续追问
+	KindLive     Kind = "live"     // Live 实时对话
+	KindGraph    Kind = "graph"    // 问题导图总结
+	KindResume   Kind = "resume"   // 简历解析
+)
+
+// Kinds 所有模板类型
+var Kinds = []Kind{KindSolve, KindFollowUp, KindLive, KindGraph, KindResume}
+
+// templateExt 模板文件扩展名
+const templateExt = ".tmpl"
+
+var (
+	ErrInvalidKind = errors.New("未知的模板类型")
+	ErrInvalidName = errors.New("模板名称不合法")
+)
+
+// Vars 模板变量，在模板中通过 {{.Language}} 等方式引用
+type Vars struct {
+	Prompt   string // 设置中填写的自定义提示词
+	Resume   string // Markdown 简历内容（仅在使用 Markdown 简历时有值）
+	Language string // 目标编程语言
+	Date     string // 当前日期 (2006-01-02)
+	Model    string // 当前模型
+	Locale   string // 内置提示词语言
+	Nodes    string // 已有导图节点（graph 模板）
+	Dialog   string // 新的对话内容（graph 模板）
+}
+
+// VarsFromConfig 根据配置生成通用模板变量
+func VarsFromConfig(cfg config.Config) Vars {
+	vars := Vars{
+		Prompt:   cfg.Prompt,
+		Language: cfg.CodingLanguage,
+		Date:     time.Now().Format("2006-01-02"),
+		Model:    cfg.Model,
+		Locale:   cfg.Locale,
+	}
+	if cfg.UseMarkdownResume {
+		vars.Resume = cfg.ResumeContent
+	}
+	return vars
+}
+
+// TemplateInfo 模板信息（发送给前端）
+type TemplateInfo struct {
+	Name    string `json:"name"`
+	Kind    Kind   `json:"kind"`
+	Builtin bool   `json:"builtin"`
+}
+
+// Library 磁盘上的提示词模板库
+// 目录结构: <dir>/<kind>/<name>.tmpl，名称为空表示使用内置模板
+type Library struct {
+	dir string
+}
+
+// NewLibrary 创建模板库
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// Builtin 获取指定语言的内置模板内容
+func Builtin(kind Kind, locale string) string {
+	pack := GetPack(locale)
+	switch kind {
+	case KindSolve:
+		return pack.Solve
+	case KindLive:
+		return pack.Live
+	case KindGraph:
+		return pack.Graph
+	case KindResume:
+		// 简历模板中的 {{姓名}} 等占位符是给模型看的，需要转义
+		return strings.ReplaceAll(pack.Resume, "{{", "{{`{{`}}")
+	}
+	return ""
+}
+
+// List 列出指定类型的模板，内置模板排在第一位
+func (l *Library) List(kind Kind) ([]TemplateInfo, error) {
+	if !isValidKind(kind) {
+		return nil, ErrInvalidKind
+	}
+	result := []TemplateInfo{{Name: "", Kind: kind, Builtin: true}}
+
+	entries, err := os.ReadDir(filepath.Join(l.dir, string(kind)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), templateExt) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), templateExt))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		result = append(result, TemplateInfo{Name: name, Kind: kind})
+	}
+	return result, nil
+}
+
+// Get 读取模板内容，名称为空返回指定语言的内置模板
+func (l *Library) Get(kind Kind, name string, locale string) (string, error) {
+	if name == "" {
+		return Builtin(kind, locale), nil
+	}
+	path, err := l.path(kind, name)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取模板失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// Save 保存模板，保存前会检查模板语法
+func (l *Library) Save(kind Kind, name string, content string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+	path, err := l.path(kind, name)
+	if err != nil {
+		return err
+	}
+	if _, err := template.New(name).Parse(content); err != nil {
+		return fmt.Errorf("模板语法错误: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// Delete 删除模板
+func (l *Library) Delete(kind Kind, name string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+	path, err := l.path(kind, name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Render 渲染指定模板
+func (l *Library) Render(kind Kind, name string, vars Vars) (string, error) {
+	text, err := l.Get(kind, name, vars.Locale)
+	if err != nil {
+		return "", err
+	}
+	return Render(text, vars)
+}
+
+// RenderActive 渲染配置中选中的模板，失败时回退到内置模板
+func (l *Library) RenderActive(kind Kind, active map[string]string, vars Vars) string {
+	name := active[string(kind)]
+	result, err := l.Render(kind, name, vars)
+	if err == nil {
+		return result
+	}
+
+	logger.Printf("渲染模板 %s/%s 失败，使用内置模板: %v", kind, name, err)
+	result, err = Render(Builtin(kind, vars.Locale), vars)
+	if err != nil {
+		logger.Printf("渲染内置模板 %s 失败: %v", kind, err)
+	}
+	return result
+}
+
+// Render 使用变量渲染模板文本
+func Render(text string, vars Vars) (string, error) {
+	tmpl, err := template.New("prompt").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// path 获取模板文件路径，并校验类型和名称
+func (l *Library) path(kind Kind, name string) (string, error) {
+	if !isValidKind(kind) {
+		return "", ErrInvalidKind
+	}
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(l.dir, string(kind), name+templateExt), nil
+}
+
+func isValidKind(kind Kind) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -14,13 +14,15 @@ import (
 )
 
 type Service struct {
-	config       config.Config // 存储配置副本
-	resumeBase64 string        // 缓存的简历 Base64
+	config        config.Config    // 存储配置副本
+	resumeBase64  string           // 缓存的简历 Base64
+	promptLibrary *prompts.Library // 提示词模板库
 }
 
-func NewService(cfg config.Config, cm *config.ConfigManager) *Service {
+func NewService(cfg config.Config, cm *config.ConfigManager, promptLibrary *prompts.Library) *Service {
 	s := &Service{
-		config: cfg,
+		config:        cfg,
+		promptLibrary: promptLibrary,
 	}
 	// 订阅配置变更，同步配置
 	cm.Subscribe(func(NewConfig config.Config, oldConfig config.Config) {
@@ -111,8 +113,10 @@ func (s *Service) ParseResume(ctx context.Context, provider llm.Provider) (strin
 	logger.Println("开始解析简历为 Markdown...")
 
 	// 2. 构建解析简历的消息
+	prompt := s.promptLibrary.RenderActive(prompts.KindResume, s.config.PromptTemplates, prompts.VarsFromConfig(s.config))
+	prompt = prompts.WithAnswerLanguage(prompt, s.config)
 	messages := []llm.Message{
-		llm.NewUserMessage(prompts.ResumeParsePrompt),
+		llm.NewUserMessage(prompt),
 		llm.NewMultiPartMessage(llm.RoleUser, []llm.ContentPart{
 			llm.PDFPart(resumeBase64),
 		}),
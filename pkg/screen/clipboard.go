@@ -0,0 +1,93 @@
+package screen
+
+import (
+	"Q-Solver/pkg/platform"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Input 截图以外的解题输入（剪贴板或拖入的文件），Image 和 Text 只有一个非空
+type Input struct {
+	Image image.Image
+	Text  string
+}
+
+// ReadClipboard 读取剪贴板，优先使用图片，没有图片时使用文字
+func (s *Service) ReadClipboard() (Input, error) {
+	data, err := platform.GetClipboardImage()
+	if err != nil {
+		return Input{}, err
+	}
+	if len(data) > 0 {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Input{}, fmt.Errorf("剪贴板图片解析失败: %v", err)
+		}
+		return Input{Image: img}, nil
+	}
+
+	if s.ctx == nil {
+		return Input{}, fmt.Errorf("context not initialized")
+	}
+	text, err := runtime.ClipboardGetText(s.ctx)
+	if err != nil {
+		return Input{}, fmt.Errorf("读取剪贴板失败: %v", err)
+	}
+	if strings.TrimSpace(text) == "" {
+		return Input{}, fmt.Errorf("剪贴板中没有图片或文字")
+	}
+	return Input{Text: text}, nil
+}
+
+// DecodeDataURL 解析前端拖入文件的 data URL（图片或纯文本）
+func DecodeDataURL(dataURL string) (Input, error) {
+	header, payload, ok := strings.Cut(dataURL, ",")
+	if !ok || !strings.HasPrefix(header, "data:") {
+		return Input{}, fmt.Errorf("无效的文件数据")
+	}
+	data := []byte(payload)
+	if strings.HasSuffix(header, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return Input{}, fmt.Errorf("文件解码失败: %v", err)
+		}
+		data = decoded
+	}
+
+	mimeType := strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Input{}, fmt.Errorf("不支持的图片格式: %s", mimeType)
+		}
+		return Input{Image: img}, nil
+	case mimeType == "" || strings.HasPrefix(mimeType, "text/"):
+		text := string(data)
+		if strings.TrimSpace(text) == "" {
+			return Input{}, fmt.Errorf("文件内容为空")
+		}
+		return Input{Text: text}, nil
+	default:
+		return Input{}, fmt.Errorf("不支持的文件类型: %s", mimeType)
+	}
+}
+
+// EncodeImage 将剪贴板或拖入的图片按截图相同的流程裁剪、处理和编码
+func EncodeImage(img image.Image, opts CaptureOptions) (PreviewResult, error) {
+	result, err := encodePreview(img, opts)
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	// 不参与截图变化检测，总是视为新题目
+	result.ChangedRatio = 1
+	return result, nil
+}
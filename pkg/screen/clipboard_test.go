@@ -0,0 +1,64 @@
+package screen
+
+import (
+	"Q-Solver/pkg/config"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncodeImageUsesPipeline(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(100, 100, 500, 300), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	cases := []struct {
+		name   string
+		opts   CaptureOptions
+		prefix string
+	}{
+		{"配置的编码器", CaptureOptions{AutoCrop: true, Encoder: config.ImageEncoderWebPLossless}, "data:image/webp;base64,"},
+		{"不压缩", CaptureOptions{AutoCrop: true, NoCompression: true, Quality: 80}, "data:image/png;base64,"},
+		{"默认 JPEG", CaptureOptions{AutoCrop: true, Quality: 80}, "data:image/jpeg;base64,"},
+	}
+	for _, c := range cases {
+		result, err := EncodeImage(img, c.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !strings.HasPrefix(result.Base64, c.prefix) {
+			t.Errorf("%s: %.30s, 期望前缀 %s", c.name, result.Base64, c.prefix)
+		}
+		// 裁剪后的图片用于 OCR
+		if got := result.Images[0].Bounds(); got.Dx() >= 800 || got.Dy() >= 600 {
+			t.Errorf("%s: 未裁剪空白边距: %v", c.name, got)
+		}
+		if result.ChangedRatio != 1 {
+			t.Errorf("%s: 变化比例 %v, 期望 1", c.name, result.ChangedRatio)
+		}
+	}
+
+	// 超出提供商大小上限时缩小
+	noisy := image.NewGray(image.Rect(0, 0, 400, 300))
+	rng := rand.New(rand.NewSource(1))
+	for i := range noisy.Pix {
+		noisy.Pix[i] = uint8(rng.Intn(256))
+	}
+	opts := CaptureOptions{Encoder: config.ImageEncoderPNG}
+	full, err := EncodeImage(noisy, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.TargetBytes = len(full.Base64) / 2
+	small, err := EncodeImage(noisy, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base64.StdEncoding.EncodedLen(len(small.ImgBytes)) > opts.TargetBytes {
+		t.Errorf("编码后 %d 字节，超出目标大小 %d", len(small.ImgBytes), opts.TargetBytes)
+	}
+}
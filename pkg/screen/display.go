@@ -0,0 +1,142 @@
+package screen
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+	"fmt"
+	"image"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// DisplayInfo 屏幕信息
+type DisplayInfo struct {
+	Index       int     `json:"index"`
+	X           int     `json:"x"`
+	Y           int     `json:"y"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	ScaleFactor float64 `json:"scaleFactor"`
+	Primary     bool    `json:"primary"`
+}
+
+// ListDisplays 列出当前所有屏幕
+func (s *Service) ListDisplays() []DisplayInfo {
+	// Wails 提供逻辑尺寸与物理尺寸，但不提供屏幕位置，按尺寸匹配得到缩放比例
+	var screens []runtime.Screen
+	if s.ctx != nil {
+		all, err := runtime.ScreenGetAll(s.ctx)
+		if err != nil {
+			logger.Printf("[截图] 获取屏幕缩放信息失败: %v", err)
+		}
+		screens = all
+	}
+
+	rects := s.source.Displays()
+	displays := make([]DisplayInfo, 0, len(rects))
+	for i, bounds := range rects {
+		displays = append(displays, DisplayInfo{
+			Index:       i,
+			X:           bounds.Min.X,
+			Y:           bounds.Min.Y,
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+			ScaleFactor: scaleFactorFor(bounds, screens),
+			Primary:     i == 0,
+		})
+	}
+	return displays
+}
+
+// scaleFactorFor 根据 Wails 屏幕列表推算某块屏幕的缩放比例，匹配不到时返回 1
+func scaleFactorFor(bounds image.Rectangle, screens []runtime.Screen) float64 {
+	for _, sc := range screens {
+		if sc.Size.Width <= 0 {
+			continue
+		}
+		sameLogical := sc.Size.Width == bounds.Dx() && sc.Size.Height == bounds.Dy()
+		samePhysical := sc.PhysicalSize.Width == bounds.Dx() && sc.PhysicalSize.Height == bounds.Dy()
+		if sameLogical || samePhysical {
+			return float64(sc.PhysicalSize.Width) / float64(sc.Size.Width)
+		}
+	}
+	return 1.0
+}
+
+// selectDisplay 按配置选择全屏模式要截取的屏幕
+func selectDisplay(source CaptureSource, opts CaptureOptions) (image.Rectangle, error) {
+	displays := source.Displays()
+	if len(displays) == 0 {
+		return image.Rectangle{}, fmt.Errorf("未检测到可用屏幕")
+	}
+
+	switch opts.DisplayTarget {
+	case config.DisplayTargetCursor:
+		pt, err := source.CursorPosition()
+		if err != nil {
+			logger.Printf("[截图] 获取鼠标位置失败，使用主屏幕: %v", err)
+			return displays[0], nil
+		}
+		return displayContaining(pt, displays), nil
+	case config.DisplayTargetWindow:
+		window, err := source.WindowBounds()
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		center := image.Pt(window.Min.X+window.Dx()/2, window.Min.Y+window.Dy()/2)
+		return displayContaining(center, displays), nil
+	default:
+		if opts.DisplayIndex >= len(displays) {
+			logger.Printf("[截图] 屏幕 %d 不存在（共 %d 块），使用主屏幕", opts.DisplayIndex, len(displays))
+			return displays[0], nil
+		}
+		return displays[opts.DisplayIndex], nil
+	}
+}
+
+// displayContaining 返回包含指定点的屏幕，都不包含时返回主屏幕
+func displayContaining(pt image.Point, displays []image.Rectangle) image.Rectangle {
+	for _, bounds := range displays {
+		if pt.In(bounds) {
+			return bounds
+		}
+	}
+	return displays[0]
+}
+
+// captureAllDisplays 逐块截取所有屏幕，第一块作为主图，其余放入 Extra
+// 每块屏幕都经过相同的裁剪和编码，Images 中保存裁剪后的图片（用于 OCR）
+func captureAllDisplays(source CaptureSource, opts CaptureOptions) (PreviewResult, error) {
+	displays := source.Displays()
+	if len(displays) == 0 {
+		return PreviewResult{}, fmt.Errorf("未检测到可用屏幕")
+	}
+
+	var result PreviewResult
+	var totalSize int
+	for i, bounds := range displays {
+		img, err := source.Capture(bounds)
+		if err != nil {
+			return PreviewResult{}, fmt.Errorf("截取屏幕 %d 失败: %v", i, err)
+		}
+		part, err := encodePreview(img, opts)
+		if err != nil {
+			return PreviewResult{}, err
+		}
+		totalSize += len(part.Base64)
+		if i == 0 {
+			result = part
+			continue
+		}
+		result.Extra = append(result.Extra, part.Base64)
+		if result.Tokens != nil && part.Tokens != nil {
+			result.Tokens.Tokens += part.Tokens.Tokens
+			result.Tokens.Tiles += part.Tokens.Tiles
+		}
+		result.Images = append(result.Images, part.Images...)
+		result.Signature = append(result.Signature, part.Signature...)
+	}
+
+	result.Size = fmt.Sprintf("%.2f KB", float64(totalSize)/1024.0)
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package screen
+
+import (
+	"Q-Solver/pkg/config"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeScreen 生成白底、中间一块黑色内容的屏幕图片
+func writeScreen(t *testing.T, name string, width, height int, content image.Rectangle) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, content, image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	path := filepath.Join(t.TempDir(), name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCaptureAllDisplaysCropsEveryDisplay(t *testing.T) {
+	source := NewFileSource(
+		writeScreen(t, "primary.png", 800, 600, image.Rect(100, 100, 500, 300)),
+		writeScreen(t, "secondary.png", 640, 480, image.Rect(200, 150, 400, 350)),
+	)
+	opts := CaptureOptions{Mode: config.ScreenshotModeAll, AutoCrop: true, Encoder: config.ImageEncoderPNG, Quality: 80}
+
+	result, err := capture(source, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Extra) != 1 || len(result.Images) != 2 {
+		t.Fatalf("Extra %d 张, Images %d 张, 期望 1 和 2", len(result.Extra), len(result.Images))
+	}
+	// 每块屏幕都去掉了空白边距
+	for i, display := range source.Displays() {
+		got := result.Images[i].Bounds()
+		if got.Dx() >= display.Dx() || got.Dy() >= display.Dy() {
+			t.Errorf("屏幕 %d 未裁剪: %v (屏幕 %v)", i, got, display)
+		}
+	}
+}
+
+func TestSelectDisplay(t *testing.T) {
+	source := NewFileSource(
+		writeScreen(t, "primary.png", 800, 600, image.Rect(0, 0, 10, 10)),
+		writeScreen(t, "secondary.png", 640, 480, image.Rect(0, 0, 10, 10)),
+	)
+	primary := image.Rect(0, 0, 800, 600)
+	secondary := image.Rect(800, 0, 1440, 480)
+
+	cases := []struct {
+		name string
+		opts CaptureOptions
+		want image.Rectangle
+	}{
+		{"指定屏幕", CaptureOptions{DisplayTarget: config.DisplayTargetIndex, DisplayIndex: 1}, secondary},
+		{"屏幕不存在时使用主屏幕", CaptureOptions{DisplayTarget: config.DisplayTargetIndex, DisplayIndex: 5}, primary},
+		{"鼠标所在屏幕", CaptureOptions{DisplayTarget: config.DisplayTargetCursor}, primary},
+	}
+	for _, c := range cases {
+		got, err := selectDisplay(source, c.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: %v, 期望 %v", c.name, got, c.want)
+		}
+	}
+}
@@ -2,75 +2,254 @@ package screen
 
 import (
 	imageutil "Q-Solver/pkg/ImageUtil"
-	"bytes"
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
 	"context"
 	"encoding/base64"
 	"fmt"
-	"image/png"
-
-	"github.com/kbinani/screenshot"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"image"
+	"sync"
 )
 
 type PreviewResult struct {
-	ImgBytes []byte `json:"imgBytes"`
-	Base64   string `json:"base64"`
-	Size     string `json:"size"`
+	ImgBytes []byte                   `json:"imgBytes"`
+	Base64   string                   `json:"base64"`
+	Size     string                   `json:"size"`
+	Hash     uint64                   `json:"-"`                // 原始截图的感知哈希，用于识别重复题目
+	Extra    []string                 `json:"extra,omitempty"`  // all 模式下其余屏幕的截图（Base64）
+	Images   []image.Image            `json:"-"`                // 未压缩的原始截图（用于 OCR），与 Base64、Extra 顺序一致
+	Tokens   *imageutil.TokenEstimate `json:"tokens,omitempty"` // 视觉 Token 估算（多屏时为各屏之和）
+
+	Signature    []uint8 `json:"-"`            // 变化检测用的灰度网格（多屏时依次拼接）
+	ChangedRatio float64 `json:"changedRatio"` // 与上一次解题截图相比发生变化的区域比例（0-1），首次截图为 1
+}
+
+// CaptureOptions 截图参数
+type CaptureOptions struct {
+	Quality       int
+	Sharpen       float64
+	Grayscale     bool
+	NoCompression bool
+	AutoCrop      bool          // 去掉纯色边框和空白边距
+	FocusText     bool          // 裁剪到文字最密集的区域
+	MaxDimension  int           // 长边上限
+	Binarize      bool          // 二值化
+	Steps         []string      // 处理步骤及顺序，设置后忽略上面的开关
+	Encoder       string        // 编码格式
+	TargetBytes   int           // 编码后 Base64 大小上限，0 不限制
+	Pricing       string        // 模型的图片计费方式（用于估算 Token）
+	MinGlyph      float64       // 大于 0 时按计费方式缩放到 Token 最少的尺寸
+	Mode          string        // fullscreen / window / region / all
+	Region        config.Region // region 模式下的截图区域
+	DisplayTarget string        // fullscreen 模式下的屏幕选择方式 (index / cursor / window)
+	DisplayIndex  int           // DisplayTarget 为 index 时的屏幕序号
+	TrackChange   bool          // 记录本次截图作为下次比较变化的基准，只有解题截图设置，预览和工具截图不应覆盖
+}
+
+// OptionsFromConfig 根据配置生成截图参数
+func OptionsFromConfig(cfg config.Config) CaptureOptions {
+	var minGlyph float64
+	if cfg.OptimizeTokens {
+		minGlyph = float64(cfg.MinGlyphHeight)
+	}
+	return CaptureOptions{
+		Quality:       cfg.CompressionQuality,
+		Sharpen:       cfg.Sharpening,
+		Grayscale:     cfg.Grayscale,
+		NoCompression: cfg.NoCompression,
+		AutoCrop:      cfg.AutoCrop,
+		FocusText:     cfg.FocusTextBlock,
+		MaxDimension:  cfg.MaxImageDimension,
+		Binarize:      cfg.Binarize,
+		Steps:         cfg.ImageSteps,
+		Encoder:       cfg.ImageEncoder,
+		TargetBytes:   llm.ImageTargetBytes(cfg),
+		Pricing:       llm.VisionPricing(cfg),
+		MinGlyph:      minGlyph,
+		Mode:          cfg.ScreenshotMode,
+		Region:        cfg.CaptureRegion,
+		DisplayTarget: cfg.DisplayTarget,
+		DisplayIndex:  cfg.DisplayIndex,
+	}
+}
+
+// RegionSelection 框选区域时使用的底图
+type RegionSelection struct {
+	Base64 string `json:"base64"`
+	X      int    `json:"x"` // 底图对应的屏幕区域
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
 }
 
 type Service struct {
-	ctx context.Context
+	ctx    context.Context
+	source CaptureSource // 截图来源
+
+	mu            sync.Mutex
+	lastSignature []uint8 // 上一次解题截图的变化检测网格
 }
 
 func NewService() *Service {
-	return &Service{}
+	return NewServiceWithSource(NewDesktopSource())
+}
+
+// NewServiceWithSource 使用指定的截图来源（如图片文件）创建服务
+func NewServiceWithSource(source CaptureSource) *Service {
+	return &Service{source: source}
 }
 
 func (s *Service) Startup(ctx context.Context) {
 	s.ctx = ctx
+	if starter, ok := s.source.(interface{ Startup(context.Context) }); ok {
+		starter.Startup(ctx)
+	}
 }
 
-// CapturePreview 获取当前截图的预览（Base64）
-func (s *Service) CapturePreview(quality int, sharpen float64, grayscale bool, noCompression bool, mode string) (PreviewResult, error) {
-	var x, y, w, h int
+// CapturePreview 获取当前截图的预览（Base64），并与上一次解题截图比较变化程度
+func (s *Service) CapturePreview(opts CaptureOptions) (PreviewResult, error) {
+	return s.CapturePreviewFrom(s.source, opts)
+}
 
-	if mode == "fullscreen" {
-		// 全屏模式：获取主屏幕尺寸
-		bounds := screenshot.GetDisplayBounds(0)
-		x, y, w, h = bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy()
-	} else {
+// CapturePreviewFrom 从指定来源截图，用于临时替换截图来源
+func (s *Service) CapturePreviewFrom(source CaptureSource, opts CaptureOptions) (PreviewResult, error) {
+	result, err := capture(source, opts)
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	s.trackChange(&result, opts.TrackChange)
+	return result, nil
+}
+
+// trackChange 计算与上一次解题截图的变化比例，record 为 true 时记录本次截图
+func (s *Service) trackChange(result *PreviewResult, record bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result.ChangedRatio = imageutil.ChangedRatio(s.lastSignature, result.Signature)
+	if record {
+		s.lastSignature = result.Signature
+	}
+}
+
+// capture 按截图模式截取并编码
+func capture(source CaptureSource, opts CaptureOptions) (PreviewResult, error) {
+	var rect image.Rectangle
+
+	switch opts.Mode {
+	case config.ScreenshotModeFullscreen:
+		// 全屏模式：按配置选择屏幕
+		bounds, err := selectDisplay(source, opts)
+		if err != nil {
+			return PreviewResult{}, err
+		}
+		rect = bounds
+	case config.ScreenshotModeAll:
+		// 所有屏幕：每块屏幕单独编码
+		return captureAllDisplays(source, opts)
+	case config.ScreenshotModeRegion:
+		// 区域模式：使用保存的框选区域
+		if opts.Region.Empty() {
+			return PreviewResult{}, fmt.Errorf("尚未框选截图区域")
+		}
+		rect = image.Rect(opts.Region.X, opts.Region.Y, opts.Region.X+opts.Region.Width, opts.Region.Y+opts.Region.Height)
+	default:
 		// 窗口模式：获取当前窗口位置和大小
-		if s.ctx == nil {
-			return PreviewResult{}, fmt.Errorf("context not initialized")
+		bounds, err := source.WindowBounds()
+		if err != nil {
+			return PreviewResult{}, err
 		}
-		x, y = runtime.WindowGetPosition(s.ctx)
-		w, h = runtime.WindowGetSize(s.ctx)
+		rect = bounds
 	}
 
 	// 截图
-	img, err := screenshot.Capture(x, y, w, h)
+	img, err := source.Capture(rect)
 	if err != nil {
 		return PreviewResult{}, fmt.Errorf("截图失败: %v", err)
 	}
 
-	// 处理图片
-	var imgBytes []byte
-	var ImageBase64 string
-	if noCompression {
-		var buf bytes.Buffer
-		err = png.Encode(&buf, img)
-		if err != nil {
-			return PreviewResult{}, fmt.Errorf("图片编码失败: %v", err)
+	return encodePreview(img, opts)
+}
+
+// CaptureForRegionSelection 截取主屏幕作为框选区域的底图
+func (s *Service) CaptureForRegionSelection() (RegionSelection, error) {
+	bounds, err := primaryDisplay(s.source)
+	if err != nil {
+		return RegionSelection{}, err
+	}
+	img, err := s.source.Capture(bounds)
+	if err != nil {
+		return RegionSelection{}, fmt.Errorf("截图失败: %v", err)
+	}
+
+	imgBytes, err := imageutil.CompressForOCR(img, 80, 0, false)
+	if err != nil {
+		return RegionSelection{}, fmt.Errorf("图片处理失败: %v", err)
+	}
+
+	return RegionSelection{
+		Base64: fmt.Sprintf("data:image/jpeg;base64,%s", base64.StdEncoding.EncodeToString(imgBytes)),
+		X:      bounds.Min.X,
+		Y:      bounds.Min.Y,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}, nil
+}
+
+// pipelineSteps 处理步骤：未指定时按开关从默认顺序中挑选
+func pipelineSteps(opts CaptureOptions) []string {
+	if len(opts.Steps) > 0 {
+		return opts.Steps
+	}
+	enabled := map[string]bool{
+		imageutil.StepFocusText: opts.FocusText,
+		imageutil.StepAutoCrop:  opts.AutoCrop,
+		imageutil.StepResize:    true,
+		imageutil.StepGrayscale: opts.Grayscale || opts.Binarize, // 二值化时先灰度化再锐化
+		imageutil.StepSharpen:   opts.Sharpen > 0,
+		imageutil.StepBinarize:  opts.Binarize,
+	}
+	var steps []string
+	for _, step := range imageutil.DefaultSteps {
+		if enabled[step] {
+			steps = append(steps, step)
 		}
-		imgBytes = buf.Bytes()
-		ImageBase64 = fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(imgBytes))
+	}
+	return steps
+}
+
+// encodePreview 按参数压缩编码截图
+func encodePreview(img image.Image, opts CaptureOptions) (PreviewResult, error) {
+	// 感知哈希基于完整截图计算，避免裁剪结果的细微变化影响相似题目判断
+	hash := imageutil.PerceptualHash(img)
+	signature := imageutil.ChangeSignature(img)
+
+	pipeline := imageutil.Pipeline{
+		Steps:        pipelineSteps(opts),
+		MaxDimension: opts.MaxDimension,
+		Sharpen:      opts.Sharpen,
+		Encoder:      opts.Encoder,
+		Quality:      opts.Quality,
+		TargetBytes:  opts.TargetBytes,
+
+		Pricing:        opts.Pricing,
+		MinGlyphHeight: opts.MinGlyph,
+	}
+	img = pipeline.Crop(img)
+
+	var encoded imageutil.Encoded
+	var err error
+	if opts.NoCompression {
+		// 不压缩：原图 PNG，仅在超出提供商大小上限时缩小
+		pipeline.Encoder = imageutil.EncoderPNG
+		encoded, err = pipeline.Encode(img)
 	} else {
-		imgBytes, err = imageutil.CompressForOCR(img, quality, sharpen, grayscale)
-		if err != nil {
-			return PreviewResult{}, fmt.Errorf("图片处理失败: %v", err)
-		}
-		ImageBase64 = fmt.Sprintf("data:image/jpeg;base64,%s", base64.StdEncoding.EncodeToString(imgBytes))
+		encoded, err = pipeline.Encode(pipeline.Transform(img))
+	}
+	if err != nil {
+		return PreviewResult{}, fmt.Errorf("图片处理失败: %v", err)
 	}
+	imgBytes := encoded.Data
+	ImageBase64 := encoded.DataURL()
 
 	// 计算大小
 	sizeKB := float64(len(ImageBase64)) / 1024.0
@@ -78,8 +257,12 @@ func (s *Service) CapturePreview(quality int, sharpen float64, grayscale bool, n
 
 	// 转 Base64
 	return PreviewResult{
-		ImgBytes: imgBytes,
-		Base64:   ImageBase64,
-		Size:     sizeStr,
+		ImgBytes:  imgBytes,
+		Base64:    ImageBase64,
+		Size:      sizeStr,
+		Hash:      hash,
+		Tokens:    encoded.Estimate,
+		Images:    []image.Image{img},
+		Signature: signature,
 	}, nil
 }
@@ -0,0 +1,91 @@
+package screen
+
+import (
+	imageutil "Q-Solver/pkg/ImageUtil"
+	"Q-Solver/pkg/config"
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestPipelineSteps(t *testing.T) {
+	cases := []struct {
+		name string
+		opts CaptureOptions
+		want []string
+	}{
+		{"默认只缩放", CaptureOptions{}, []string{imageutil.StepResize}},
+		{
+			"按开关挑选",
+			CaptureOptions{AutoCrop: true, Sharpen: 0.5, Binarize: true},
+			[]string{imageutil.StepAutoCrop, imageutil.StepResize, imageutil.StepGrayscale, imageutil.StepSharpen, imageutil.StepBinarize},
+		},
+		{
+			"指定步骤时忽略开关",
+			CaptureOptions{Grayscale: true, Steps: []string{imageutil.StepBinarize, imageutil.StepAutoCrop}},
+			[]string{imageutil.StepBinarize, imageutil.StepAutoCrop},
+		},
+	}
+	for _, c := range cases {
+		if got := pipelineSteps(c.opts); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: %v, 期望 %v", c.name, got, c.want)
+		}
+	}
+}
+
+// 配置中的步骤和编码名称原样传给 imageutil，两边的取值必须一致
+func TestConfigNamesMatchImageUtil(t *testing.T) {
+	pairs := [][2]string{
+		{config.ImageStepFocusText, imageutil.StepFocusText},
+		{config.ImageStepAutoCrop, imageutil.StepAutoCrop},
+		{config.ImageStepResize, imageutil.StepResize},
+		{config.ImageStepGrayscale, imageutil.StepGrayscale},
+		{config.ImageStepSharpen, imageutil.StepSharpen},
+		{config.ImageStepBinarize, imageutil.StepBinarize},
+		{config.ImageEncoderJPEG, imageutil.EncoderJPEG},
+		{config.ImageEncoderPNG, imageutil.EncoderPNG},
+		{config.ImageEncoderPalettePNG, imageutil.EncoderPalettePNG},
+		{config.ImageEncoderWebPLossless, imageutil.EncoderWebPLossless},
+	}
+	for _, p := range pairs {
+		if p[0] != p[1] {
+			t.Errorf("配置 %q 与 imageutil %q 不一致", p[0], p[1])
+		}
+	}
+	if len(imageutil.DefaultSteps) != 6 {
+		t.Errorf("imageutil 有 %d 个步骤，配置只校验 6 个", len(imageutil.DefaultSteps))
+	}
+}
+
+func TestOnlySolveCapturesTrackChange(t *testing.T) {
+	question := NewFileSource(writeScreen(t, "question.png", 400, 300, image.Rect(50, 50, 350, 250)))
+	other := NewFileSource(writeScreen(t, "other.png", 400, 300, image.Rect(0, 0, 100, 100)))
+	service := NewServiceWithSource(question)
+	opts := CaptureOptions{Mode: config.ScreenshotModeFullscreen, Encoder: config.ImageEncoderPNG, Quality: 80}
+	solve := opts
+	solve.TrackChange = true
+
+	steps := []struct {
+		name    string
+		source  CaptureSource
+		opts    CaptureOptions
+		changed bool
+	}{
+		{"首次解题", question, solve, true},
+		{"设置预览其他画面", other, opts, true},
+		{"工具截图其他画面", other, opts, true},
+		// 预览和工具截图没有覆盖基准，同一道题仍判定为未变化
+		{"再次解题", question, solve, false},
+		{"解题其他画面", other, solve, true},
+		{"再次解题其他画面", other, solve, false},
+	}
+	for _, step := range steps {
+		result, err := service.CapturePreviewFrom(step.source, step.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed := result.ChangedRatio > 0; changed != step.changed {
+			t.Errorf("%s: 变化比例 %v", step.name, result.ChangedRatio)
+		}
+	}
+}
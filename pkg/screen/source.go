@@ -0,0 +1,214 @@
+package screen
+
+import (
+	"Q-Solver/pkg/platform"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"sync"
+
+	"github.com/kbinani/screenshot"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// CaptureSource 截图来源，坐标均为虚拟桌面坐标
+// 除真实屏幕外还可以使用图片文件或合成图片，便于在没有桌面环境时测试解题流程
+type CaptureSource interface {
+	// Displays 各屏幕的位置，第一块为主屏幕
+	Displays() []image.Rectangle
+	// Capture 截取指定区域
+	Capture(rect image.Rectangle) (image.Image, error)
+	// WindowBounds 应用窗口的位置
+	WindowBounds() (image.Rectangle, error)
+	// CursorPosition 鼠标位置
+	CursorPosition() (image.Point, error)
+}
+
+// ==================== 真实屏幕 ====================
+
+// DesktopSource 通过系统接口截取真实屏幕
+type DesktopSource struct {
+	ctx context.Context
+}
+
+func NewDesktopSource() *DesktopSource {
+	return &DesktopSource{}
+}
+
+// Startup 保存 Wails 上下文（获取窗口位置需要）
+func (d *DesktopSource) Startup(ctx context.Context) {
+	d.ctx = ctx
+}
+
+func (d *DesktopSource) Displays() []image.Rectangle {
+	n := screenshot.NumActiveDisplays()
+	displays := make([]image.Rectangle, 0, n)
+	for i := 0; i < n; i++ {
+		displays = append(displays, screenshot.GetDisplayBounds(i))
+	}
+	return displays
+}
+
+func (d *DesktopSource) Capture(rect image.Rectangle) (image.Image, error) {
+	return screenshot.CaptureRect(rect)
+}
+
+func (d *DesktopSource) WindowBounds() (image.Rectangle, error) {
+	if d.ctx == nil {
+		return image.Rectangle{}, fmt.Errorf("context not initialized")
+	}
+	x, y := runtime.WindowGetPosition(d.ctx)
+	w, h := runtime.WindowGetSize(d.ctx)
+	return image.Rect(x, y, x+w, y+h), nil
+}
+
+func (d *DesktopSource) CursorPosition() (image.Point, error) {
+	x, y, err := platform.GetCursorPosition()
+	return image.Pt(x, y), err
+}
+
+// ==================== 图片文件 ====================
+
+// FileSource 把图片文件当作屏幕，多个文件从左到右依次排列
+// 每次截图都会重新读取文件，测试时替换文件即可模拟屏幕变化
+type FileSource struct {
+	paths []string
+}
+
+func NewFileSource(paths ...string) *FileSource {
+	return &FileSource{paths: paths}
+}
+
+func (f *FileSource) Displays() []image.Rectangle {
+	displays := make([]image.Rectangle, 0, len(f.paths))
+	x := 0
+	for _, path := range f.paths {
+		cfg, err := decodeFileConfig(path)
+		if err != nil {
+			continue
+		}
+		displays = append(displays, image.Rect(x, 0, x+cfg.Width, cfg.Height))
+		x += cfg.Width
+	}
+	return displays
+}
+
+func (f *FileSource) Capture(rect image.Rectangle) (image.Image, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	x := 0
+	for _, path := range f.paths {
+		img, err := decodeFile(path)
+		if err != nil {
+			return nil, err
+		}
+		bounds := img.Bounds()
+		display := image.Rect(x, 0, x+bounds.Dx(), bounds.Dy())
+		x += bounds.Dx()
+		if part := display.Intersect(rect); !part.Empty() {
+			src := part.Min.Sub(display.Min).Add(bounds.Min)
+			draw.Draw(dst, part.Sub(rect.Min), img, src, draw.Src)
+		}
+	}
+	return dst, nil
+}
+
+func (f *FileSource) WindowBounds() (image.Rectangle, error) {
+	return primaryDisplay(f)
+}
+
+func (f *FileSource) CursorPosition() (image.Point, error) {
+	bounds, err := primaryDisplay(f)
+	if err != nil {
+		return image.Point{}, err
+	}
+	return image.Pt(bounds.Min.X+bounds.Dx()/2, bounds.Min.Y+bounds.Dy()/2), nil
+}
+
+func decodeFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("解析图片 %s 失败: %v", path, err)
+	}
+	return img, nil
+}
+
+func decodeFileConfig(path string) (image.Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer file.Close()
+	cfg, _, err := image.DecodeConfig(file)
+	return cfg, err
+}
+
+// ==================== 合成图片 ====================
+
+// SyntheticSource 由绘制函数生成的单块屏幕，frame 为第几次截图（从 0 开始）
+type SyntheticSource struct {
+	width, height int
+	draw          func(img *image.RGBA, frame int)
+
+	mu    sync.Mutex
+	frame int
+}
+
+// NewSyntheticSource 创建合成屏幕，drawFunc 为空时绘制白底黑色横线（类似文字行）
+func NewSyntheticSource(width, height int, drawFunc func(img *image.RGBA, frame int)) *SyntheticSource {
+	if drawFunc == nil {
+		drawFunc = drawTextLines
+	}
+	return &SyntheticSource{width: width, height: height, draw: drawFunc}
+}
+
+func (s *SyntheticSource) Displays() []image.Rectangle {
+	return []image.Rectangle{image.Rect(0, 0, s.width, s.height)}
+}
+
+func (s *SyntheticSource) Capture(rect image.Rectangle) (image.Image, error) {
+	s.mu.Lock()
+	frame := s.frame
+	s.frame++
+	s.mu.Unlock()
+
+	full := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
+	s.draw(full, frame)
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), full, rect.Min, draw.Src)
+	return dst, nil
+}
+
+func (s *SyntheticSource) WindowBounds() (image.Rectangle, error) {
+	return image.Rect(0, 0, s.width, s.height), nil
+}
+
+func (s *SyntheticSource) CursorPosition() (image.Point, error) {
+	return image.Pt(s.width/2, s.height/2), nil
+}
+
+// drawTextLines 白底上每 24px 画一行长短不一的黑色横条
+func drawTextLines(img *image.RGBA, frame int) {
+	bounds := img.Bounds()
+	draw.Draw(img, bounds, image.White, image.Point{}, draw.Src)
+	for y, line := 40, 0; y+12 < bounds.Dy(); y, line = y+24, line+1 {
+		width := bounds.Dx() / 2 * (3 + (line+frame)%5) / 7
+		draw.Draw(img, image.Rect(40, y, 40+width, y+12), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+}
+
+// primaryDisplay 主屏幕位置
+func primaryDisplay(source CaptureSource) (image.Rectangle, error) {
+	displays := source.Displays()
+	if len(displays) == 0 {
+		return image.Rectangle{}, fmt.Errorf("未检测到可用屏幕")
+	}
+	return displays[0], nil
+}
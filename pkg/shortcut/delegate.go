@@ -7,5 +7,7 @@ type ServiceDelegate interface {
 	ToggleClickThrough()
 	MoveWindow(dx, dy int)
 	ScrollContent(direction string)
+	CyclePromptTemplate()
+	FlipCodingLanguage()
 	EmitEvent(eventName string, data ...interface{})
 }
@@ -31,6 +31,7 @@ func NewService(delegate ServiceDelegate, initialShortcuts map[string]KeyBinding
 	// 自注册配置变更回调
 	subscribe(func(shortcuts map[string]KeyBinding) {
 		maps.Copy(s.manager.Shortcuts, shortcuts)
+		s.manager.Reload()
 		logger.Println("快捷键配置已更新")
 	})
 
@@ -59,6 +60,10 @@ func (s *Service) StartRecording(action string) {
 		s.delegate.EmitEvent("toast", "macOS 不支持自定义快捷键，请使用预设快捷键")
 		return
 	}
+	if runtime.GOOS == "linux" {
+		s.delegate.EmitEvent("toast", "Linux 暂不支持录制快捷键，请在配置文件中修改")
+		return
+	}
 	s.manager.StartRecording(action)
 }
 
@@ -89,6 +94,12 @@ func (s *Service) handleTrigger(action string) {
 		s.delegate.ScrollContent("up")
 	case "scroll_down":
 		s.delegate.ScrollContent("down")
+	case "cycle_prompt":
+		logger.Println("切换解题模板")
+		s.delegate.CyclePromptTemplate()
+	case "flip_language":
+		logger.Println("切换编程语言并重新生成")
+		s.delegate.FlipCodingLanguage()
 	}
 }
 
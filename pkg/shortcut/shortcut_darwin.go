@@ -26,9 +26,11 @@ var macDefaultShortcuts = map[string]struct {
 	mods []hotkey.Modifier
 	key  hotkey.Key
 }{
-	"solve":        {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key1},
-	"toggle":       {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key2},
-	"clickthrough": {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key3},
+	"solve":         {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key1},
+	"toggle":        {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key2},
+	"clickthrough":  {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key3},
+	"cycle_prompt":  {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key4},
+	"flip_language": {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key5},
 	// 方向键快捷键使用 Command + Option + 方向键
 	"move_up":    {[]hotkey.Modifier{hotkey.ModCmd, hotkey.ModOption}, hotkey.KeyUp},
 	"move_down":  {[]hotkey.Modifier{hotkey.ModCmd, hotkey.ModOption}, hotkey.KeyDown},
@@ -106,6 +108,10 @@ func (m *Manager) Stop() {
 	m.running = false
 }
 
+// Reload 快捷键配置变化后重新注册（macOS 使用固定的预设快捷键，无操作）
+func (m *Manager) Reload() {
+}
+
 // macOS 不支持热键录制，这些方法为空实现
 func (m *Manager) StartRecording(action string) {
 	logger.Println("[macOS] 热键录制不支持")
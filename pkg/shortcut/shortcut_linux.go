@@ -0,0 +1,263 @@
+//go:build linux
+
+package shortcut
+
+import (
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/platform"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// 锁定键会改变按键事件的修饰键状态，注册和匹配时需要忽略
+const lockMask = xproto.ModMaskLock | xproto.ModMask2 // CapsLock / NumLock
+
+// lockVariants 分别注册带 CapsLock / NumLock 的组合，否则锁定键打开时热键失效
+var lockVariants = []uint16{0, xproto.ModMaskLock, xproto.ModMask2, lockMask}
+
+// grabbedKey 已注册的全局热键
+type grabbedKey struct {
+	keycode   xproto.Keycode
+	modifiers uint16
+}
+
+// Manager Linux 全局热键（X11 XGrabKey，Wayland 下不可用）
+// 快捷键沿用 Windows VK 码组合（如 "38+164"），注册时转换为 X11 按键
+type Manager struct {
+	Shortcuts map[string]KeyBinding
+	conn      *xgb.Conn
+	root      xproto.Window
+	grabbed   map[grabbedKey]string
+	mu        sync.Mutex
+	running   bool
+	listening bool // 事件循环只启动一次（X11 连接是共享的，不会关闭）
+
+	// Callbacks
+	OnTrigger           func(action string)
+	OnRecord            func(action string, keyName string, comboID string)
+	OnRecordingComplete func(action string, keyName string, comboID string)
+	OnError             func(msg string)
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		Shortcuts: make(map[string]KeyBinding),
+		grabbed:   make(map[grabbedKey]string),
+	}
+}
+
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return
+	}
+
+	conn, root, err := platform.X11Conn()
+	if err != nil {
+		logger.Printf("[Linux] 全局热键不可用: %v", err)
+		return
+	}
+	m.conn, m.root = conn, root
+	m.running = true
+
+	m.grabAll()
+	if !m.listening {
+		m.listening = true
+		go m.eventLoop()
+	}
+}
+
+// Reload 快捷键配置变化后重新注册
+func (m *Manager) Reload() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		return
+	}
+	m.ungrabAll()
+	m.grabAll()
+}
+
+// grabAll 注册所有快捷键
+func (m *Manager) grabAll() {
+	keycodes := m.keysymTable()
+	for action, binding := range m.Shortcuts {
+		keysym, modifiers, ok := parseCombo(binding.ComboID)
+		if !ok {
+			logger.Printf("[Linux] 无法识别的快捷键 %s: %s", action, binding.ComboID)
+			continue
+		}
+		keycode, ok := keycodes[keysym]
+		if !ok {
+			logger.Printf("[Linux] 键盘布局中没有快捷键 %s 的按键", action)
+			continue
+		}
+
+		if err := m.grabKey(keycode, modifiers); err != nil {
+			logger.Printf("[Linux] 注册热键失败 %s (%s): %v (可能已被其他程序占用)", action, binding.KeyName, err)
+			continue
+		}
+		m.grabbed[grabbedKey{keycode: keycode, modifiers: modifiers}] = action
+	}
+	logger.Printf("[Linux] 全局热键注册完成 (%d 个)", len(m.grabbed))
+}
+
+// grabKey 注册一个快捷键的所有锁定键组合，任一组合失败时撤销已注册的组合
+func (m *Manager) grabKey(keycode xproto.Keycode, modifiers uint16) error {
+	for i, extra := range lockVariants {
+		err := xproto.GrabKeyChecked(m.conn, true, m.root, modifiers|extra, keycode, xproto.GrabModeAsync, xproto.GrabModeAsync).Check()
+		if err != nil {
+			for _, grabbed := range lockVariants[:i] {
+				xproto.UngrabKey(m.conn, keycode, m.root, modifiers|grabbed)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// keysymTable 建立 keysym → keycode 的映射
+func (m *Manager) keysymTable() map[xproto.Keysym]xproto.Keycode {
+	setup := xproto.Setup(m.conn)
+	count := byte(setup.MaxKeycode - setup.MinKeycode + 1)
+	reply, err := xproto.GetKeyboardMapping(m.conn, setup.MinKeycode, count).Reply()
+	if err != nil {
+		logger.Printf("[Linux] 读取键盘映射失败: %v", err)
+		return nil
+	}
+
+	table := make(map[xproto.Keysym]xproto.Keycode)
+	perKeycode := int(reply.KeysymsPerKeycode)
+	for i := 0; i < int(count); i++ {
+		for j := 0; j < perKeycode; j++ {
+			keysym := reply.Keysyms[i*perKeycode+j]
+			if _, exists := table[keysym]; keysym != 0 && !exists {
+				table[keysym] = setup.MinKeycode + xproto.Keycode(i)
+			}
+		}
+	}
+	return table
+}
+
+// eventLoop 接收按键事件，连接关闭后退出
+func (m *Manager) eventLoop() {
+	for {
+		event, err := m.conn.WaitForEvent()
+		if event == nil && err == nil {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		press, ok := event.(xproto.KeyPressEvent)
+		if !ok {
+			continue
+		}
+
+		m.mu.Lock()
+		action, found := m.grabbed[grabbedKey{keycode: press.Detail, modifiers: press.State &^ lockMask}]
+		m.mu.Unlock()
+		if found && m.OnTrigger != nil {
+			m.OnTrigger(action)
+		}
+	}
+}
+
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		return
+	}
+
+	m.ungrabAll()
+	m.running = false
+	logger.Println("[Linux] 全局热键已注销")
+}
+
+// ungrabAll 注销所有快捷键
+func (m *Manager) ungrabAll() {
+	for key := range m.grabbed {
+		for _, extra := range lockVariants {
+			xproto.UngrabKey(m.conn, key.keycode, m.root, key.modifiers|extra)
+		}
+	}
+	m.grabbed = make(map[grabbedKey]string)
+}
+
+// Linux 暂不支持热键录制，快捷键可以在配置文件中修改
+func (m *Manager) StartRecording(action string) {
+	logger.Println("[Linux] 热键录制不支持")
+	if m.OnError != nil {
+		m.OnError("Linux 暂不支持录制快捷键，请在配置文件中修改")
+	}
+}
+
+func (m *Manager) StopRecording() {
+	// 空实现
+}
+
+// parseCombo 将 VK 码组合转换为 X11 keysym 和修饰键
+func parseCombo(comboID string) (xproto.Keysym, uint16, bool) {
+	var keysym xproto.Keysym
+	var modifiers uint16
+	for _, part := range strings.Split(comboID, "+") {
+		vk, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, 0, false
+		}
+		switch vk {
+		case 0x10, 0xA0, 0xA1: // Shift
+			modifiers |= xproto.ModMaskShift
+		case 0x11, 0xA2, 0xA3: // Ctrl
+			modifiers |= xproto.ModMaskControl
+		case 0x12, 0xA4, 0xA5: // Alt
+			modifiers |= xproto.ModMask1
+		case 0x5B, 0x5C: // Win / Super
+			modifiers |= xproto.ModMask4
+		default:
+			sym, ok := vkToKeysym(uint32(vk))
+			if !ok || keysym != 0 {
+				return 0, 0, false // 不支持的按键，或包含多个非修饰键
+			}
+			keysym = sym
+		}
+	}
+	return keysym, modifiers, keysym != 0
+}
+
+// vkToKeysym Windows VK 码到 X11 keysym 的映射
+func vkToKeysym(vk uint32) (xproto.Keysym, bool) {
+	switch {
+	case vk >= 0x70 && vk <= 0x7B: // F1 - F12
+		return xproto.Keysym(0xFFBE + vk - 0x70), true
+	case vk >= 0x30 && vk <= 0x39: // 0 - 9
+		return xproto.Keysym(vk), true
+	case vk >= 0x41 && vk <= 0x5A: // A - Z（X11 使用小写 keysym）
+		return xproto.Keysym(vk + 0x20), true
+	}
+	switch vk {
+	case 0x21: // PageUp
+		return 0xFF55, true
+	case 0x22: // PageDown
+		return 0xFF56, true
+	case 0x25: // ←
+		return 0xFF51, true
+	case 0x26: // ↑
+		return 0xFF52, true
+	case 0x27: // →
+		return 0xFF53, true
+	case 0x28: // ↓
+		return 0xFF54, true
+	case 0x20: // Space
+		return 0x20, true
+	case 0xC0: // `~
+		return 0x60, true
+	}
+	return 0, false
+}
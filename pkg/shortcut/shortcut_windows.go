@@ -61,6 +61,10 @@ func (m *Manager) Stop() {
 	globalManager = nil
 }
 
+// Reload 快捷键配置变化后重新注册（钩子每次按键都读取最新配置，无需操作）
+func (m *Manager) Reload() {
+}
+
 func (m *Manager) StartRecording(action string) {
 	m.recordingKeyFor = action
 	m.maxComboKeys = make(map[uint32]bool)
@@ -0,0 +1,177 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModelAnswer 单个模型的解答结果
+type ModelAnswer struct {
+	Model    string `json:"model"`
+	Content  string `json:"content"`
+	Thinking string `json:"thinking,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ConsistencyResult 自洽模式的评审结果（发送给前端）
+type ConsistencyResult struct {
+	Answers     []ModelAnswer `json:"answers"`
+	Agreement   bool          `json:"agreement"`
+	Summary     string        `json:"summary"`
+	Recommended int           `json:"recommended"` // 推荐答案在 Answers 中的下标
+}
+
+// ProviderFactory 根据模型名称获取 Provider
+type ProviderFactory func(model string) llm.Provider
+
+// solveConsistent 自洽模式：并行请求多个模型，再由辅助模型评审并选出推荐答案
+func (s *Solver) solveConsistent(ctx context.Context, cfg config.Config, messages []llm.Message, cb Callbacks) (llm.Message, error) {
+	models := cfg.ConsistencyModels
+	answers := make([]ModelAnswer, len(models))
+
+	logger.Printf("[自洽] 并行请求 %d 个模型: %v", len(models), models)
+
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+			answers[i] = s.solveWithModel(ctx, model, messages, cb)
+		}(i, model)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return llm.Message{}, ctx.Err()
+	}
+
+	// 收集成功的回答
+	var succeeded []int
+	var firstErr string
+	for i, ans := range answers {
+		if ans.Error == "" && ans.Content != "" {
+			succeeded = append(succeeded, i)
+		} else if firstErr == "" {
+			firstErr = fmt.Sprintf("%s: %s", ans.Model, ans.Error)
+		}
+	}
+	if len(succeeded) == 0 {
+		return llm.Message{}, errors.New("所有模型均未返回有效回答 (" + firstErr + ")")
+	}
+
+	result := ConsistencyResult{
+		Answers:     answers,
+		Agreement:   len(succeeded) == 1,
+		Recommended: succeeded[0],
+	}
+
+	switch {
+	case len(succeeded) == 1:
+		result.Summary = "仅有一个模型返回了有效回答，直接采用"
+	case cfg.AssistantModel == "":
+		result.Summary = "未配置辅助模型，默认采用第一个有效回答"
+	default:
+		s.judge(ctx, cfg, answers, succeeded, &result)
+	}
+
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("solution-consistency", result)
+	}
+
+	ans := answers[result.Recommended]
+	return llm.Message{Role: llm.RoleAssistant, Content: ans.Content, Thinking: ans.Thinking}, nil
+}
+
+// solveWithModel 使用指定模型流式解题
+// 多个模型同时输出，solution-stream-chunk / solution-stream-thinking 额外带上模型名称，前端据此分别显示
+func (s *Solver) solveWithModel(ctx context.Context, model string, messages []llm.Message, cb Callbacks) ModelAnswer {
+	answer := ModelAnswer{Model: model}
+
+	provider := s.providerFactory(model)
+	if provider == nil {
+		answer.Error = "创建 Provider 失败"
+		return answer
+	}
+
+	response, err := provider.GenerateContentStream(ctx, messages, func(chunk llm.StreamChunk) {
+		if cb.EmitEvent != nil {
+			switch chunk.Type {
+			case llm.ChunkThinking:
+				cb.EmitEvent("solution-stream-thinking", chunk.Content, model)
+			case llm.ChunkContent:
+				cb.EmitEvent("solution-stream-chunk", chunk.Content, model)
+			}
+		}
+	})
+	if err != nil {
+		logger.Printf("[自洽] 模型 %s 请求失败: %v", model, err)
+		answer.Error = err.Error()
+		return answer
+	}
+
+	logger.Printf("[自洽] 模型 %s 返回内容长度: %d", model, len(response.Content))
+	answer.Content = response.Content
+	answer.Thinking = response.Thinking
+	return answer
+}
+
+// judge 调用辅助模型评审各回答，结果写入 result
+func (s *Solver) judge(ctx context.Context, cfg config.Config, answers []ModelAnswer, succeeded []int, result *ConsistencyResult) {
+	pack := prompts.GetPack(cfg.Locale)
+	var sb strings.Builder
+	for n, idx := range succeeded {
+		sb.WriteString(fmt.Sprintf(pack.ConsistencyEntry, n+1, answers[idx].Model, answers[idx].Content))
+	}
+	prompt := prompts.WithAnswerLanguage(fmt.Sprintf(pack.ConsistencyJudge, sb.String()), cfg)
+
+	judgeCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	response, err := s.llmProvider.GenerateContent(judgeCtx, cfg.AssistantModel, []llm.Message{
+		llm.NewUserMessage(prompt),
+	})
+	if err != nil {
+		logger.Printf("[自洽] 评审失败: %v", err)
+		result.Summary = "评审失败，默认采用第一个有效回答: " + err.Error()
+		return
+	}
+
+	var verdict struct {
+		Agreement   bool   `json:"agreement"`
+		Summary     string `json:"summary"`
+		Recommended int    `json:"recommended"`
+	}
+	if err := json.Unmarshal([]byte(trimCodeFence(response.Content)), &verdict); err != nil {
+		logger.Printf("[自洽] 解析评审结果失败: %v", err)
+		result.Summary = response.Content
+		return
+	}
+
+	result.Agreement = verdict.Agreement
+	result.Summary = verdict.Summary
+	if verdict.Recommended >= 1 && verdict.Recommended <= len(succeeded) {
+		result.Recommended = succeeded[verdict.Recommended-1]
+	}
+	logger.Printf("[自洽] 评审完成: agreement=%v, recommended=%s", result.Agreement, answers[result.Recommended].Model)
+}
+
+// trimCodeFence 移除模型输出中包裹 JSON 的 markdown 代码块
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```json")
+		s = strings.TrimPrefix(s, "```")
+		s = strings.TrimSuffix(s, "```")
+		s = strings.TrimSpace(s)
+	}
+	return s
+}
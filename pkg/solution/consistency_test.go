@@ -0,0 +1,123 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// failingProvider 的请求总是失败
+type failingProvider struct {
+	fakeProvider
+}
+
+func (p *failingProvider) GenerateContentStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	return llm.Message{}, errors.New("请求失败")
+}
+
+// solveConsistentFor 以自洽模式解题，返回评审结果和最终回答
+func solveConsistentFor(t *testing.T, solver *Solver, models []string, assistant string) (ConsistencyResult, llm.Message) {
+	t.Helper()
+	cfg := config.NewDefaultConfig()
+	cfg.ConsistencyModels = models
+	cfg.AssistantModel = assistant
+	recorder := newEventRecorder()
+	msg, err := solver.solveConsistent(context.Background(), cfg, nil, recorder.callbacks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recorder.events["solution-consistency"][0].(ConsistencyResult), msg
+}
+
+func TestConsistencyRecommendsByIndex(t *testing.T) {
+	providers := map[string]llm.Provider{
+		"a": &failingProvider{},
+		"b": &fakeProvider{answers: []string{"答案 b"}},
+		"c": &fakeProvider{answers: []string{"答案 c"}},
+	}
+	cases := []struct {
+		name    string
+		judge   string // 辅助模型的评审结果，为空表示未配置辅助模型
+		want    int
+		content string
+	}{
+		{"未配置辅助模型时采用第一个有效回答", "", 1, "答案 b"},
+		{"评审推荐第二个有效回答", `{"agreement":false,"summary":"不一致","recommended":2}`, 2, "答案 c"},
+		{"评审下标越界时采用第一个有效回答", `{"recommended":5}`, 1, "答案 b"},
+	}
+	for _, c := range cases {
+		solver := NewSolver(&fakeProvider{answers: []string{c.judge}})
+		solver.SetProviderFactory(func(model string) llm.Provider { return providers[model] })
+		assistant := ""
+		if c.judge != "" {
+			assistant = "judge"
+		}
+
+		result, msg := solveConsistentFor(t, solver, []string{"a", "b", "c"}, assistant)
+		if result.Recommended != c.want {
+			t.Errorf("%s: 推荐下标 %d, 期望 %d", c.name, result.Recommended, c.want)
+		}
+		if msg.Content != c.content {
+			t.Errorf("%s: 返回回答 %q, 期望 %q", c.name, msg.Content, c.content)
+		}
+	}
+}
+
+func TestConsistencyDuplicateModelFirstFails(t *testing.T) {
+	// 同名模型只有一个请求成功时，不能按名称匹配到失败的回答
+	var mu sync.Mutex
+	calls := 0
+	solver := NewSolver(&fakeProvider{answers: []string{""}})
+	solver.SetProviderFactory(func(model string) llm.Provider {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			return &failingProvider{}
+		}
+		return &fakeProvider{answers: []string{"答案"}}
+	})
+
+	result, msg := solveConsistentFor(t, solver, []string{"m", "m"}, "")
+	if result.Answers[result.Recommended].Error != "" {
+		t.Errorf("推荐了失败的回答 %d", result.Recommended)
+	}
+	if msg.Content != "答案" {
+		t.Errorf("返回回答 %q, 期望 %q", msg.Content, "答案")
+	}
+}
+
+func TestConsistencyStreamsTaggedByModel(t *testing.T) {
+	solver := NewSolver(&fakeProvider{answers: []string{""}})
+	solver.SetProviderFactory(func(model string) llm.Provider {
+		return &fakeProvider{answers: []string{"答案 " + model}}
+	})
+
+	var mu sync.Mutex
+	chunks := make(map[string]string)
+	cfg := config.NewDefaultConfig()
+	cfg.ConsistencyModels = []string{"a", "b"}
+	_, err := solver.solveConsistent(context.Background(), cfg, nil, Callbacks{EmitEvent: func(event string, data ...interface{}) {
+		if event != "solution-stream-chunk" {
+			return
+		}
+		if len(data) != 2 {
+			t.Errorf("solution-stream-chunk 参数 %v, 期望带上模型名称", data)
+			return
+		}
+		mu.Lock()
+		chunks[data[1].(string)] += data[0].(string)
+		mu.Unlock()
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range cfg.ConsistencyModels {
+		if want := "答案 " + model; chunks[model] != want {
+			t.Errorf("模型 %s 的输出 %q, 期望 %q", model, chunks[model], want)
+		}
+	}
+}
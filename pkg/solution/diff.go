@@ -0,0 +1,247 @@
+package solution
+
+import (
+	imageutil "Q-Solver/pkg/ImageUtil"
+	"Q-Solver/pkg/logger"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	maxDiffHistory      = 10 // 最多保留多少条历史解答用于对比
+	similarHashDistance = 6  // 感知哈希距离不超过该值视为同一道题
+)
+
+// DiffOp 行差异类型
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = " "
+	DiffInsert DiffOp = "+"
+	DiffDelete DiffOp = "-"
+)
+
+// DiffLine 一行差异
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// CodeBlockDiff 一个代码块的差异
+type CodeBlockDiff struct {
+	Language string     `json:"language"`
+	Lines    []DiffLine `json:"lines"`
+	Added    int        `json:"added"`
+	Removed  int        `json:"removed"`
+}
+
+// SolutionDiff 重新解同一道题时新旧答案的差异（发送给前端）
+type SolutionDiff struct {
+	PreviousTime int64           `json:"previousTime"` // 上一次解答的时间（毫秒时间戳）
+	Distance     int             `json:"distance"`     // 截图感知哈希距离
+	Blocks       []CodeBlockDiff `json:"blocks"`
+	Changed      bool            `json:"changed"`
+}
+
+// answerRecord 历史解答记录
+type answerRecord struct {
+	hash      uint64
+	signature []uint8 // 截图的变化检测网格
+	key       string  // 影响答案内容的设置，不同时不复用答案
+	answer    string
+	time      time.Time
+}
+
+// CachedAnswer 截图没有变化时复用的历史答案（发送给前端）
+type CachedAnswer struct {
+	PreviousTime int64   `json:"previousTime"` // 被复用的解答时间（毫秒时间戳）
+	ChangedRatio float64 `json:"changedRatio"` // 与该次截图相比发生变化的区域比例
+}
+
+// answerKey 影响答案内容的请求参数
+// 提示词模板、回答语言、代码风格等都体现在渲染后的 System Prompt 中，取其哈希；自洽模式的设置单独加入
+func answerKey(req Request, systemPrompt string) string {
+	language := req.Language
+	if language == "" {
+		language = req.Config.CodingLanguage
+	}
+	h := fnv.New64a()
+	h.Write([]byte(systemPrompt))
+	key := fmt.Sprintf("%s|%s|%s|%x", req.Config.Model, language, req.Config.OCRMode, h.Sum64())
+	if req.Config.SelfConsistency {
+		key += "|" + strings.Join(req.Config.ConsistencyModels, ",") + "|" + req.Config.AssistantModel
+	}
+	return key
+}
+
+// findUnchanged 查找截图没有明显变化的最近一次解答，key 不同（设置有变化）的解答不复用
+func (s *Solver) findUnchanged(req Request, key string) (*answerRecord, float64) {
+	if req.ScreenshotHash == 0 || len(req.ScreenSignature) == 0 {
+		return nil, 1
+	}
+	for i := len(s.answerHistory) - 1; i >= 0; i-- {
+		record := &s.answerHistory[i]
+		if record.key != key || record.hash != req.ScreenshotHash {
+			continue
+		}
+		if ratio := imageutil.ChangedRatio(record.signature, req.ScreenSignature); ratio <= req.Config.UnchangedRatio {
+			return record, ratio
+		}
+	}
+	return nil, 1
+}
+
+// codeBlockPattern 匹配 markdown 代码块
+var codeBlockPattern = regexp.MustCompile("(?s)```([\\w+#.-]*)[^\\n]*\\n(.*?)```")
+
+// codeBlock 从回答中提取的代码块
+type codeBlock struct {
+	language string
+	code     string
+}
+
+// diffWithHistory 查找截图相似的历史解答，计算代码差异并记录本次解答
+func (s *Solver) diffWithHistory(req Request, key string, answer string) *SolutionDiff {
+	hash := req.ScreenshotHash
+	if hash == 0 {
+		return nil
+	}
+
+	var result *SolutionDiff
+	bestDistance := similarHashDistance + 1
+	for i := len(s.answerHistory) - 1; i >= 0; i-- {
+		record := s.answerHistory[i]
+		distance := imageutil.HashDistance(hash, record.hash)
+		if distance >= bestDistance {
+			continue
+		}
+		bestDistance = distance
+		result = &SolutionDiff{
+			PreviousTime: record.time.UnixMilli(),
+			Distance:     distance,
+			Blocks:       diffAnswers(record.answer, answer),
+		}
+	}
+
+	if result != nil {
+		for _, block := range result.Blocks {
+			if block.Added > 0 || block.Removed > 0 {
+				result.Changed = true
+				break
+			}
+		}
+		logger.Printf("[解题] 检测到相似截图 (距离 %d)，答案有变化: %v", result.Distance, result.Changed)
+	}
+
+	s.answerHistory = append(s.answerHistory, answerRecord{
+		hash:      hash,
+		signature: req.ScreenSignature,
+		key:       key,
+		answer:    answer,
+		time:      time.Now(),
+	})
+	if len(s.answerHistory) > maxDiffHistory {
+		s.answerHistory = s.answerHistory[len(s.answerHistory)-maxDiffHistory:]
+	}
+	return result
+}
+
+// diffAnswers 按顺序逐个对比两次回答中的代码块，没有代码块时对比全文
+func diffAnswers(oldAnswer, newAnswer string) []CodeBlockDiff {
+	oldBlocks := extractCodeBlocks(oldAnswer)
+	newBlocks := extractCodeBlocks(newAnswer)
+	if len(oldBlocks) == 0 && len(newBlocks) == 0 {
+		oldBlocks = []codeBlock{{code: oldAnswer}}
+		newBlocks = []codeBlock{{code: newAnswer}}
+	}
+
+	count := max(len(oldBlocks), len(newBlocks))
+	diffs := make([]CodeBlockDiff, 0, count)
+	for i := 0; i < count; i++ {
+		var oldBlock, newBlock codeBlock
+		if i < len(oldBlocks) {
+			oldBlock = oldBlocks[i]
+		}
+		if i < len(newBlocks) {
+			newBlock = newBlocks[i]
+		}
+
+		language := newBlock.language
+		if language == "" {
+			language = oldBlock.language
+		}
+		diff := CodeBlockDiff{Language: language, Lines: diffLines(splitLines(oldBlock.code), splitLines(newBlock.code))}
+		for _, line := range diff.Lines {
+			switch line.Op {
+			case DiffInsert:
+				diff.Added++
+			case DiffDelete:
+				diff.Removed++
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// extractCodeBlocks 提取回答中的 markdown 代码块
+func extractCodeBlocks(answer string) []codeBlock {
+	var blocks []codeBlock
+	for _, match := range codeBlockPattern.FindAllStringSubmatch(answer, -1) {
+		blocks = append(blocks, codeBlock{language: match[1], code: match[2]})
+	}
+	return blocks
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines 基于最长公共子序列的行级差异
+func diffLines(a, b []string) []DiffLine {
+	// lcs[i][j] 表示 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]DiffLine, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,43 @@
+package solution
+
+import (
+	imageutil "Q-Solver/pkg/ImageUtil"
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/ocr"
+	"context"
+	"encoding/base64"
+	"image"
+)
+
+// 文字 + 低清截图模式下截图的长边和 JPEG 质量
+const (
+	ocrThumbnailDimension = 800
+	ocrThumbnailQuality   = 60
+)
+
+// ApplyOCR 识别截图中的文字，置信度足够时用文字代替原图（text+image 模式下附带低清截图）
+// 返回识别结果和是否使用了文字，识别失败时请求保持不变
+func ApplyOCR(ctx context.Context, engine ocr.Engine, images []image.Image, req *Request) (ocr.Result, bool, error) {
+	result, err := ocr.RecognizeAll(ctx, engine, images)
+	if err != nil {
+		return ocr.Result{}, false, err
+	}
+
+	if result.Empty() || result.Confidence < req.Config.OCRMinConfidence {
+		return result, false, nil
+	}
+
+	req.OCRText = result.Text
+	req.ScreenshotBase64 = ""
+	req.ExtraScreenshots = nil
+	if req.Config.OCRMode == config.OCRModeTextImage && len(images) > 0 {
+		thumb, err := imageutil.CompressThumbnail(images[0], ocrThumbnailDimension, ocrThumbnailQuality)
+		if err != nil {
+			logger.Printf("[OCR] 低清截图编码失败: %v", err)
+			return result, true, nil
+		}
+		req.ScreenshotBase64 = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(thumb)
+	}
+	return result, true, nil
+}
@@ -0,0 +1,77 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/ocr"
+	"context"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestApplyOCR(t *testing.T) {
+	images := []image.Image{
+		image.NewRGBA(image.Rect(0, 0, 1600, 900)),
+		image.NewRGBA(image.Rect(0, 0, 1280, 720)),
+	}
+	confident := ocr.Result{Lines: []ocr.Line{
+		{Text: "def solve(nums):", Confidence: 0.9},
+		{Text: "return sum(nums)", Indent: 4, Confidence: 0.8},
+	}, Confidence: 0.85}
+
+	cases := []struct {
+		name      string
+		mode      string
+		result    ocr.Result
+		useText   bool // 是否使用文字，不使用时请求保持不变
+		wantThumb bool
+	}{
+		{"仅文字", config.OCRModeText, confident, true, false},
+		{"文字加低清截图", config.OCRModeTextImage, confident, true, true},
+		{"置信度不足", config.OCRModeText, ocr.Result{Text: "d3f s0lve", Confidence: 0.3}, false, false},
+		{"没有识别出文字", config.OCRModeTextImage, ocr.Result{Text: "  \n", Confidence: 0.9}, false, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := config.NewDefaultConfig()
+			cfg.OCRMode = c.mode
+			req := Request{
+				Config:           cfg,
+				ScreenshotBase64: "data:image/png;base64,original",
+				ExtraScreenshots: []string{"data:image/png;base64,extra"},
+			}
+
+			result, useText, err := ApplyOCR(context.Background(), &ocr.StaticEngine{Result: c.result}, images, &req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Engine != "static" {
+				t.Errorf("Engine = %q", result.Engine)
+			}
+
+			if !c.useText {
+				if useText || req.OCRText != "" || req.ScreenshotBase64 != "data:image/png;base64,original" || len(req.ExtraScreenshots) != 1 {
+					t.Errorf("不应使用文字, 实际 useText=%v 请求 %+v", useText, req)
+				}
+				return
+			}
+
+			// 每块屏幕的识别结果以空行分隔
+			screenText := "def solve(nums):\n    return sum(nums)"
+			wantText := screenText + "\n\n" + screenText
+			if !useText || req.OCRText != wantText {
+				t.Errorf("useText=%v OCRText=%q, 期望 %q", useText, req.OCRText, wantText)
+			}
+			if req.ExtraScreenshots != nil {
+				t.Error("使用文字时不应再发送其余屏幕的截图")
+			}
+			if gotThumb := strings.HasPrefix(req.ScreenshotBase64, "data:image/jpeg;base64,"); gotThumb != c.wantThumb {
+				t.Errorf("低清截图 = %.40q, 期望附带: %v", req.ScreenshotBase64, c.wantThumb)
+			}
+			if !c.wantThumb && req.ScreenshotBase64 != "" {
+				t.Error("仅文字模式不应发送截图")
+			}
+		})
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"Q-Solver/pkg/config"
 	"Q-Solver/pkg/llm"
 	"Q-Solver/pkg/logger"
-	"bytes"
+	"Q-Solver/pkg/prompts"
 	"context"
 	"errors"
+	"fmt"
 )
 
 type Callbacks struct {
@@ -16,12 +17,24 @@ type Callbacks struct {
 type Request struct {
 	Config           config.Config
 	ScreenshotBase64 string
+	ScreenshotHash   uint64   // 截图感知哈希，为 0 时不做答案对比
+	ScreenSignature  []uint8  // 截图的变化检测网格，截图没有变化时复用上次的答案
+	ExtraScreenshots []string // 多屏模式下其余屏幕的截图
+	OCRText          string   // 本地 OCR 识别出的文字，非空时代替（或配合低清）截图发送
+	InputText        string   // 剪贴板或拖入文件中的题目文字（代替截图）
 	ResumeBase64     string
+	Language         string // 本次使用的编程语言，为空使用配置中的 CodingLanguage
+	SkipCache        bool   // 不复用历史答案（重新生成，或调用方已通过 ReuseUnchanged 检查过）
 }
 
 type Solver struct {
-	llmProvider llm.Provider
-	chatHistory []llm.Message // 改用统一的 Message 类型
+	llmProvider     llm.Provider
+	providerFactory ProviderFactory  // 自洽模式下按模型获取 Provider
+	promptLibrary   *prompts.Library // 提示词模板库
+	chatHistory     []llm.Message    // 改用统一的 Message 类型
+	lastRequest     *Request         // 最近一次解题请求（用于切换语言后重新生成）
+	lastInHistory   bool             // 最近一次问答是否已写入 chatHistory
+	answerHistory   []answerRecord   // 最近的解答记录（用于重新解题时对比答案）
 }
 
 func NewSolver(provider llm.Provider) *Solver {
@@ -35,6 +48,16 @@ func (s *Solver) SetProvider(provider llm.Provider) {
 	s.llmProvider = provider
 }
 
+// SetProviderFactory 设置按模型获取 Provider 的方法（自洽模式使用）
+func (s *Solver) SetProviderFactory(factory ProviderFactory) {
+	s.providerFactory = factory
+}
+
+// SetPromptLibrary 设置提示词模板库
+func (s *Solver) SetPromptLibrary(library *prompts.Library) {
+	s.promptLibrary = library
+}
+
 func (s *Solver) ClearHistory() {
 	s.chatHistory = make([]llm.Message, 0)
 }
@@ -49,29 +72,51 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 	}
 
 	logger.Println("开始解题流程...")
+	s.lastRequest = &req
 
 	// 2. 构建 System Prompt
-	var systemPrompt bytes.Buffer
-	if req.Config.Prompt != "" {
-		systemPrompt.WriteString(req.Config.Prompt)
+	vars, systemPrompt := s.buildSystemPrompt(req)
+	if vars.Resume != "" {
+		logger.Println("使用 Markdown 简历内容")
 	}
 
-	// 如果使用 Markdown 简历，将简历内容追加到 System Prompt
-	if req.Config.UseMarkdownResume && req.Config.ResumeContent != "" {
-		logger.Println("使用 Markdown 简历内容")
-		systemPrompt.WriteString("\n\n# 候选人简历内容如下: \n")
-		systemPrompt.WriteString(req.Config.ResumeContent)
+	// 截图和上次解题时相比没有明显变化、且设置相同：直接复用上次的答案
+	key := answerKey(req, systemPrompt)
+	if !req.Config.ForceResolve && !req.SkipCache && s.reuseUnchanged(req, key, cb) {
+		return true
 	}
+	s.lastInHistory = false
 
-	// 3. 构建当前用户消息（包含截图）
-	userParts := []llm.ContentPart{
-		llm.ImagePart(req.ScreenshotBase64),
+	// 3. 构建当前用户消息（包含截图或 OCR 文字）
+	var userParts []llm.ContentPart
+	if req.OCRText != "" {
+		pack := prompts.GetPack(req.Config.Locale)
+		userParts = append(userParts, llm.TextPart(fmt.Sprintf(pack.OCRText, req.OCRText)))
+		if req.ScreenshotBase64 != "" {
+			userParts = append(userParts, llm.TextPart(pack.OCRLowResImage))
+		}
+	}
+	if req.InputText != "" {
+		userParts = append(userParts, llm.TextPart(fmt.Sprintf(prompts.GetPack(req.Config.Locale).PastedText, req.InputText)))
+	}
+	if req.ScreenshotBase64 != "" {
+		userParts = append(userParts, llm.ImagePart(req.ScreenshotBase64))
+	}
+	for _, extra := range req.ExtraScreenshots {
+		userParts = append(userParts, llm.ImagePart(extra))
+	}
+
+	// 保持上下文且已有历史时，追加追问模板
+	if req.Config.KeepContext && len(s.chatHistory) > 1 {
+		if followUp := s.promptLibrary.RenderActive(prompts.KindFollowUp, req.Config.PromptTemplates, vars); followUp != "" {
+			userParts = append(userParts, llm.TextPart(followUp))
+		}
 	}
 
 	// 如果使用 PDF 简历，将简历附件加入用户消息
 	if !req.Config.UseMarkdownResume && req.ResumeBase64 != "" {
 		userParts = append(userParts,
-			llm.TextPart("\n\n# 候选人简历已作为附件发送，请参考简历内容回答。"),
+			llm.TextPart(prompts.GetPack(req.Config.Locale).ResumeAttachment),
 			llm.PDFPart(req.ResumeBase64),
 		)
 		logger.Println("已注入简历附件 (PDF)")
@@ -84,11 +129,11 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 
 	if req.Config.KeepContext {
 		// 保持上下文模式：使用并更新历史记录
-		s.ensureSystemPrompt(systemPrompt.String())
+		s.ensureSystemPrompt(systemPrompt)
 		messagesToSend = append(messagesToSend, s.chatHistory...)
 	} else {
 		// 不保持上下文模式：每次都是全新对话
-		messagesToSend = append(messagesToSend, llm.NewSystemMessage(systemPrompt.String()))
+		messagesToSend = append(messagesToSend, llm.NewSystemMessage(systemPrompt))
 	}
 	messagesToSend = append(messagesToSend, currentUserMsg)
 
@@ -97,17 +142,24 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 		cb.EmitEvent("solution-stream-start")
 	}
 
-	response, err := s.llmProvider.GenerateContentStream(ctx, messagesToSend, func(chunk llm.StreamChunk) {
-		if cb.EmitEvent != nil {
-			// 根据 chunk 类型发送不同事件
-			switch chunk.Type {
-			case llm.ChunkThinking:
-				cb.EmitEvent("solution-stream-thinking", chunk.Content)
-			case llm.ChunkContent:
-				cb.EmitEvent("solution-stream-chunk", chunk.Content)
+	var response llm.Message
+	var err error
+	if req.Config.SelfConsistency && len(req.Config.ConsistencyModels) > 1 && s.providerFactory != nil {
+		// 自洽模式：多模型并行解题 + 辅助模型评审
+		response, err = s.solveConsistent(ctx, req.Config, messagesToSend, cb)
+	} else {
+		response, err = s.llmProvider.GenerateContentStream(ctx, messagesToSend, func(chunk llm.StreamChunk) {
+			if cb.EmitEvent != nil {
+				// 根据 chunk 类型发送不同事件
+				switch chunk.Type {
+				case llm.ChunkThinking:
+					cb.EmitEvent("solution-stream-thinking", chunk.Content)
+				case llm.ChunkContent:
+					cb.EmitEvent("solution-stream-chunk", chunk.Content)
+				}
 			}
-		}
-	})
+		})
+	}
 
 	if err != nil {
 		if errors.Is(ctx.Err(), context.Canceled) {
@@ -142,10 +194,16 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 		cb.EmitEvent("solution", response.Content)
 	}
 
+	// 同一道题重新解答时，对比新旧答案的代码差异
+	if diff := s.diffWithHistory(req, key, response.Content); diff != nil && cb.EmitEvent != nil {
+		cb.EmitEvent("solution-diff", diff)
+	}
+
 	if req.Config.KeepContext {
 		// 保持上下文模式：保存完整的用户消息和助手回复到历史
 		s.chatHistory = append(s.chatHistory, currentUserMsg)
 		s.chatHistory = append(s.chatHistory, llm.NewAssistantMessage(response.Content))
+		s.lastInHistory = true
 	} else {
 		// 不保持上下文模式：清空历史
 		s.chatHistory = []llm.Message{}
@@ -154,6 +212,83 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 	return true
 }
 
+// ReuseUnchanged 截图与之前的解答相比没有明显变化、且设置相同时直接复用答案，返回是否已复用
+// 供调用方在 OCR 等耗时的预处理之前检查，复用时不再需要调用 Solve
+func (s *Solver) ReuseUnchanged(req Request, cb Callbacks) bool {
+	if req.Config.ForceResolve || req.SkipCache {
+		return false
+	}
+	_, systemPrompt := s.buildSystemPrompt(req)
+	if !s.reuseUnchanged(req, answerKey(req, systemPrompt), cb) {
+		return false
+	}
+	s.lastRequest = &req
+	return true
+}
+
+// reuseUnchanged 查找可复用的答案并发送给前端
+func (s *Solver) reuseUnchanged(req Request, key string, cb Callbacks) bool {
+	record, ratio := s.findUnchanged(req, key)
+	if record == nil {
+		return false
+	}
+	logger.Printf("[解题] 截图没有变化 (变化比例 %.4f)，复用 %s 的答案", ratio, record.time.Format("15:04:05"))
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("solution-stream-start")
+		cb.EmitEvent("solution-cached", CachedAnswer{PreviousTime: record.time.UnixMilli(), ChangedRatio: ratio})
+		cb.EmitEvent("solution", record.answer)
+	}
+	return true
+}
+
+// buildSystemPrompt 渲染解题模板并加入代码风格和回答语言要求（Markdown 简历通过模板变量 {{.Resume}} 注入）
+func (s *Solver) buildSystemPrompt(req Request) (prompts.Vars, string) {
+	vars := prompts.VarsFromConfig(req.Config)
+	if req.Language != "" {
+		vars.Language = req.Language
+	}
+	systemPrompt := s.promptLibrary.RenderActive(prompts.KindSolve, req.Config.PromptTemplates, vars)
+	systemPrompt = prompts.WithCodingProfile(systemPrompt, req.Config, req.Language)
+	systemPrompt = prompts.WithAnswerLanguage(systemPrompt, req.Config)
+	return vars, systemPrompt
+}
+
+// CanRegenerate 是否有可以重新生成的解题请求
+func (s *Solver) CanRegenerate() bool {
+	return s.lastRequest != nil
+}
+
+// LastLanguage 最近一次解题使用的编程语言
+func (s *Solver) LastLanguage() string {
+	if s.lastRequest == nil {
+		return ""
+	}
+	if s.lastRequest.Language != "" {
+		return s.lastRequest.Language
+	}
+	return s.lastRequest.Config.CodingLanguage
+}
+
+// Regenerate 使用指定编程语言重新生成最近一次的解答
+// 保持上下文模式下会先移除上一轮的问答，避免同一截图重复出现在历史中
+func (s *Solver) Regenerate(ctx context.Context, cfg config.Config, language string, cb Callbacks) bool {
+	if s.lastRequest == nil {
+		return false
+	}
+
+	req := *s.lastRequest
+	req.Config = cfg
+	req.Language = language
+	req.SkipCache = true
+
+	if n := len(s.chatHistory); cfg.KeepContext && s.lastInHistory && n >= 2 {
+		s.chatHistory = s.chatHistory[:n-2]
+	}
+
+	logger.Printf("使用 %s 重新生成解答", language)
+	return s.Solve(ctx, req, cb)
+}
+
 // ensureSystemPrompt 确保 chatHistory 的第一条是正确的 System Prompt
 func (s *Solver) ensureSystemPrompt(prompt string) {
 	if len(s.chatHistory) == 0 {
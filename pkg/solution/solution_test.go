@@ -0,0 +1,165 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/prompts"
+	"Q-Solver/pkg/screen"
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeProvider 按顺序返回预设答案，并记录收到的消息
+type fakeProvider struct {
+	answers []string
+	calls   [][]llm.Message
+}
+
+func (p *fakeProvider) GenerateContentStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	answer := p.answers[len(p.calls)%len(p.answers)]
+	p.calls = append(p.calls, messages)
+	onChunk(llm.StreamChunk{Type: llm.ChunkContent, Content: answer})
+	return llm.NewAssistantMessage(answer), nil
+}
+
+func (p *fakeProvider) GenerateContent(ctx context.Context, model string, messages []llm.Message) (llm.Message, error) {
+	return p.GenerateContentStream(ctx, messages, func(llm.StreamChunk) {})
+}
+
+func (p *fakeProvider) GetModels(ctx context.Context) ([]string, error) {
+	return []string{"fake-model"}, nil
+}
+
+func (p *fakeProvider) TestChat(ctx context.Context) error {
+	return nil
+}
+
+// eventRecorder 记录发送给前端的事件
+type eventRecorder struct {
+	events map[string][]interface{}
+}
+
+func newEventRecorder() *eventRecorder {
+	return &eventRecorder{events: make(map[string][]interface{})}
+}
+
+func (r *eventRecorder) callbacks() Callbacks {
+	return Callbacks{EmitEvent: func(event string, data ...interface{}) {
+		var payload interface{}
+		if len(data) > 0 {
+			payload = data[0]
+		}
+		r.events[event] = append(r.events[event], payload)
+	}}
+}
+
+// captureRequest 从图片文件截图并生成解题请求（与 App 的截图解题流程一致）
+func captureRequest(t *testing.T, service *screen.Service, cfg config.Config) Request {
+	t.Helper()
+	opts := screen.OptionsFromConfig(cfg)
+	opts.TrackChange = true
+	preview, err := service.CapturePreview(opts)
+	if err != nil {
+		t.Fatalf("截图失败: %v", err)
+	}
+	return Request{
+		Config:           cfg,
+		ScreenshotBase64: preview.Base64,
+		ScreenshotHash:   preview.Hash,
+		ScreenSignature:  preview.Signature,
+	}
+}
+
+func TestSolveFromFileSource(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.APIKey = "test-key"
+	cfg.Prompt = "你是算法题助手"
+
+	provider := &fakeProvider{answers: []string{
+		"```go\nfmt.Println(1)\n```",
+		"```go\nfmt.Println(2)\n```",
+	}}
+	solver := NewSolver(provider)
+	solver.SetPromptLibrary(prompts.NewLibrary(t.TempDir()))
+	service := screen.NewServiceWithSource(screen.NewFileSource("testdata/question.png"))
+
+	// 第一次解题：截图作为图片发送给模型
+	first := newEventRecorder()
+	req := captureRequest(t, service, cfg)
+	if !solver.Solve(context.Background(), req, first.callbacks()) {
+		t.Fatal("Solve 返回 false")
+	}
+	if len(provider.calls) != 1 {
+		t.Fatalf("模型调用 %d 次, 期望 1 次", len(provider.calls))
+	}
+	messages := provider.calls[0]
+	if messages[0].Role != llm.RoleSystem || !strings.HasPrefix(messages[0].Content, cfg.Prompt) {
+		t.Errorf("第一条消息应为 System Prompt, 实际 %+v", messages[0])
+	}
+	user := messages[len(messages)-1]
+	if len(user.Parts) != 1 || user.Parts[0].Type != llm.ContentImage || user.Parts[0].Base64 != req.ScreenshotBase64 {
+		t.Errorf("用户消息应只包含截图, 实际 %+v", user.Parts)
+	}
+	if got := first.events["solution"]; len(got) != 1 || got[0] != provider.answers[0] {
+		t.Errorf("solution 事件 = %v", got)
+	}
+	if len(first.events["solution-diff"]) != 0 {
+		t.Error("第一次解题不应发送 solution-diff")
+	}
+
+	// 截图没有变化：复用答案，不再调用模型
+	cached := newEventRecorder()
+	if !solver.ReuseUnchanged(captureRequest(t, service, cfg), cached.callbacks()) {
+		t.Fatal("截图没有变化时应复用答案")
+	}
+	if len(provider.calls) != 1 || len(cached.events["solution-cached"]) != 1 {
+		t.Errorf("复用答案时模型调用 %d 次, solution-cached 事件 %d 个", len(provider.calls), len(cached.events["solution-cached"]))
+	}
+	if got := cached.events["solution"]; len(got) != 1 || got[0] != provider.answers[0] {
+		t.Errorf("复用的答案 = %v", got)
+	}
+
+	// 设置变化（编程语言不同）：不复用答案
+	changedCfg := cfg
+	changedCfg.CodingLanguage = "Python"
+	if solver.ReuseUnchanged(captureRequest(t, service, changedCfg), newEventRecorder().callbacks()) {
+		t.Error("编程语言变化时不应复用答案")
+	}
+
+	// 题目有改动：重新解题，并对比新旧答案的代码差异
+	edited := newEventRecorder()
+	service = screen.NewServiceWithSource(screen.NewFileSource("testdata/question_edited.png"))
+	req = captureRequest(t, service, cfg)
+	if solver.ReuseUnchanged(req, edited.callbacks()) {
+		t.Fatal("题目改动后不应复用答案")
+	}
+	if !solver.Solve(context.Background(), req, edited.callbacks()) {
+		t.Fatal("Solve 返回 false")
+	}
+	if len(provider.calls) != 2 {
+		t.Fatalf("模型调用 %d 次, 期望 2 次", len(provider.calls))
+	}
+	diffs := edited.events["solution-diff"]
+	if len(diffs) != 1 {
+		t.Fatalf("solution-diff 事件 %d 个, 期望 1 个", len(diffs))
+	}
+	diff := diffs[0].(*SolutionDiff)
+	if !diff.Changed || len(diff.Blocks) != 1 || diff.Blocks[0].Added != 1 || diff.Blocks[0].Removed != 1 {
+		t.Errorf("代码差异 = %+v", diff)
+	}
+}
+
+func TestSolveRequiresAPIKey(t *testing.T) {
+	provider := &fakeProvider{answers: []string{"answer"}}
+	solver := NewSolver(provider)
+	solver.SetPromptLibrary(prompts.NewLibrary(t.TempDir()))
+
+	events := newEventRecorder()
+	if solver.Solve(context.Background(), Request{Config: config.NewDefaultConfig()}, events.callbacks()) {
+		t.Error("没有 API Key 时 Solve 应返回 false")
+	}
+	if len(provider.calls) != 0 || len(events.events["require-login"]) != 1 {
+		t.Errorf("模型调用 %d 次, require-login 事件 %d 个", len(provider.calls), len(events.events["require-login"]))
+	}
+}